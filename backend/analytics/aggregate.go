@@ -0,0 +1,544 @@
+package analytics
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"custom-form-builder/models"
+)
+
+// maxTextSamples is how many of the latest text answers are kept per field
+const maxTextSamples = 20
+
+// NewAggregate returns an empty aggregate for form
+func NewAggregate(form *models.Form) *models.FormAggregate {
+	agg := &models.FormAggregate{
+		FormID:     form.ID,
+		SchemaHash: SchemaHash(form),
+		Fields:     make(map[string]*models.FieldAggregate, len(form.Fields)),
+		Days:       map[string]*models.DayBucket{},
+		UpdatedAt:  time.Now(),
+	}
+	for _, f := range form.Fields {
+		fieldAggregate(agg, f)
+	}
+	return agg
+}
+
+func fieldAggregate(agg *models.FormAggregate, f models.Field) *models.FieldAggregate {
+	if agg.Fields == nil {
+		agg.Fields = map[string]*models.FieldAggregate{}
+	}
+	fa := agg.Fields[f.ID]
+	if fa == nil {
+		fa = &models.FieldAggregate{}
+		agg.Fields[f.ID] = fa
+	}
+	switch f.Type {
+	case models.FieldTypeMultipleChoice, models.FieldTypeDropdown, models.FieldTypeCheckbox, models.FieldTypeBoolean:
+		if fa.OptionCounts == nil {
+			fa.OptionCounts = map[string]int{}
+		}
+	case models.FieldTypeRating, models.FieldTypeNPS:
+		if fa.RatingDistribution == nil {
+			fa.RatingDistribution = map[string]int{}
+		}
+	case models.FieldTypeDate, models.FieldTypeDateTime:
+		if fa.DateCounts == nil {
+			fa.DateCounts = map[string]int{}
+		}
+	case models.FieldTypeMatrix:
+		if fa.RowCounts == nil {
+			fa.RowCounts = map[string]map[string]int{}
+		}
+	}
+	return fa
+}
+
+// Apply adds one response to agg and returns what changed
+func Apply(agg *models.FormAggregate, form *models.Form, resp models.FormResponse) models.AnalyticsDelta {
+	agg.TotalResponses++
+	if !resp.DraftID.IsZero() {
+		agg.DraftsCompleted++
+	}
+	agg.UpdatedAt = time.Now()
+
+	if agg.Days == nil {
+		agg.Days = map[string]*models.DayBucket{}
+	}
+	day := resp.SubmittedAt.Format("2006-01-02")
+	bucket := agg.Days[day]
+	if bucket == nil {
+		bucket = &models.DayBucket{}
+		agg.Days[day] = bucket
+	}
+	bucket.Responses++
+	ratingSeen := false
+
+	delta := models.AnalyticsDelta{
+		FormID:      form.ID,
+		ResponseID:  resp.ID,
+		SubmittedAt: resp.SubmittedAt,
+		Fields:      make(map[string]models.FieldDelta, len(form.Fields)),
+		Skipped:     []string{},
+		Hidden:      []string{},
+	}
+
+	for _, f := range form.Fields {
+		fa := fieldAggregate(agg, f)
+		fd := models.FieldDelta{FieldID: f.ID}
+
+		// A field hidden by conditional rules wasn't skipped by the
+		// respondent, so it's counted apart
+		if contains(resp.Hidden, f.ID) {
+			fa.HiddenCount++
+			fd.Hidden = true
+			fd.ResponseCount = fa.ResponseCount
+			delta.Hidden = append(delta.Hidden, f.ID)
+			delta.Fields[f.ID] = fd
+			continue
+		}
+
+		val := resp.Responses[f.ID]
+		other := resp.Other[f.ID]
+		if val.IsEmpty() && other == "" {
+			fa.SkipCount++
+			fd.Skipped = true
+			fd.ResponseCount = fa.ResponseCount
+			delta.Skipped = append(delta.Skipped, f.ID)
+			delta.Fields[f.ID] = fd
+			continue
+		}
+
+		switch f.Type {
+		case models.FieldTypeMultipleChoice, models.FieldTypeDropdown:
+			if val.Kind == models.AnswerText && val.Text != "" {
+				fa.OptionCounts[val.Text]++
+				fd.OptionIncrements = map[string]int{val.Text: 1}
+			}
+			if fd.OptionIncrements != nil || other != "" {
+				fa.ResponseCount++
+			}
+		case models.FieldTypeCheckbox:
+			if val.Kind == models.AnswerChoices && len(val.Choices) > 0 {
+				fd.OptionIncrements = map[string]int{}
+				for _, s := range val.Choices {
+					fa.OptionCounts[s]++
+					fd.OptionIncrements[s]++
+				}
+			}
+			if fd.OptionIncrements != nil || other != "" {
+				fa.ResponseCount++
+			}
+		case models.FieldTypeBoolean:
+			if val.Kind == models.AnswerBool {
+				fa.ResponseCount++
+				fa.OptionCounts[strconv.FormatBool(val.Bool)]++
+				fd.Boolean = booleanSummary(fa)
+			}
+		case models.FieldTypeRating:
+			if val.Kind == models.AnswerNumber {
+				v := val.Number
+				fd.MinChanged, fd.MaxChanged = addNumber(fa, v)
+				key := strconv.FormatFloat(v, 'f', -1, 64)
+				fa.RatingDistribution[key]++
+				fd.RatingBucket = key
+				avg := fa.Sum / float64(fa.ResponseCount)
+				fd.AverageRating = &avg
+
+				// rating trend per day (aggregate across rating fields)
+				bucket.RatingSum += v
+				bucket.RatingCount++
+				ratingSeen = true
+			}
+		case models.FieldTypeRanking:
+			if val.Kind == models.AnswerChoices && len(val.Choices) > 0 {
+				addRanking(fa, f, val.Choices)
+				fd.Ranking = rankStats(fa, f)
+			}
+		case models.FieldTypeNPS:
+			if val.Kind == models.AnswerNumber {
+				addNPS(fa, day, int(val.Number))
+				fd.NPS = npsSummary(fa, false)
+				p := npsPoint(day, fa.NPSDays[day])
+				fd.NPSPoint = &p
+			}
+		case models.FieldTypeNumber:
+			if val.Kind == models.AnswerNumber {
+				fd.MinChanged, fd.MaxChanged = addNumber(fa, val.Number)
+				fd.NumberSummary = numberSummary(fa)
+			}
+		case models.FieldTypeSlider:
+			if val.Kind == models.AnswerNumber {
+				fd.MinChanged, fd.MaxChanged = addSlider(fa, f, val.Number)
+				fd.NumberSummary = numberSummary(fa)
+				fd.Histogram = histogram(fa, f)
+			}
+		case models.FieldTypeDate, models.FieldTypeTime, models.FieldTypeDateTime:
+			if t, err := f.ParseTemporal(val.String()); err == nil {
+				addTemporal(fa, f, t)
+				fd.DateSummary = dateSummary(fa, f)
+			}
+		case models.FieldTypeFile:
+			// Sum counts the files uploaded across responses
+			if n := len(val.Files); n > 0 {
+				fa.ResponseCount++
+				fa.Sum += float64(n)
+				fd.FileCount = int(fa.Sum)
+			}
+		case models.FieldTypeMatrix:
+			if val.Kind == models.AnswerMatrix && len(val.Matrix) > 0 {
+				fa.ResponseCount++
+				fd.RowIncrements = map[string]map[string]int{}
+				for row, cols := range val.Matrix {
+					if fa.RowCounts[row] == nil {
+						fa.RowCounts[row] = map[string]int{}
+					}
+					fd.RowIncrements[row] = map[string]int{}
+					for _, c := range cols {
+						fa.RowCounts[row][c]++
+						fd.RowIncrements[row][c]++
+					}
+				}
+			}
+		case models.FieldTypeText, models.FieldTypeTextarea, models.FieldTypeEmail, models.FieldTypePhone, models.FieldTypeURL:
+			if s := val.String(); s != "" {
+				fa.ResponseCount++
+				fa.TextResponses = append(fa.TextResponses, s)
+				if len(fa.TextResponses) > maxTextSamples {
+					fa.TextResponses = fa.TextResponses[len(fa.TextResponses)-maxTextSamples:]
+				}
+				fd.TextResponse = s
+			}
+		}
+		// Free-text "Other" answers are sampled like text answers
+		if other != "" {
+			fa.OtherCount++
+			fa.OtherResponses = append(fa.OtherResponses, other)
+			if len(fa.OtherResponses) > maxTextSamples {
+				fa.OtherResponses = fa.OtherResponses[len(fa.OtherResponses)-maxTextSamples:]
+			}
+			fd.OtherResponse = other
+		}
+		fd.ResponseCount = fa.ResponseCount
+		delta.Fields[f.ID] = fd
+	}
+
+	delta.TotalResponses = agg.TotalResponses
+	if ratingSeen {
+		delta.RatingPoint = &models.RatingPoint{Date: day, Average: bucket.RatingSum / float64(bucket.RatingCount)}
+	}
+	return delta
+}
+
+// addNumber records v in fa's running sum/min/max and reports whether the
+// minimum or maximum changed
+func addNumber(fa *models.FieldAggregate, v float64) (minChanged, maxChanged bool) {
+	fa.ResponseCount++
+	fa.Sum += v
+	if fa.Min == nil || v < *fa.Min {
+		fa.Min = &v
+		minChanged = true
+	}
+	if fa.Max == nil || v > *fa.Max {
+		fa.Max = &v
+		maxChanged = true
+	}
+	return minChanged, maxChanged
+}
+
+// addTemporal records t, an answer to the date, time or datetime field f,
+// in fa's earliest/latest and its per-day counts
+func addTemporal(fa *models.FieldAggregate, f models.Field, t time.Time) {
+	fa.ResponseCount++
+	s := f.FormatTemporal(t)
+	if min, err := f.ParseTemporal(fa.Earliest); err != nil || t.Before(min) {
+		fa.Earliest = s
+	}
+	if max, err := f.ParseTemporal(fa.Latest); err != nil || t.After(max) {
+		fa.Latest = s
+	}
+	if f.Type != models.FieldTypeTime {
+		fa.DateCounts[t.In(f.Location()).Format(models.DateLayout)]++
+	}
+}
+
+// dateSummary rolls fa's per-day counts up into weeks and months
+func dateSummary(fa *models.FieldAggregate, f models.Field) *models.DateSummary {
+	if fa.ResponseCount == 0 {
+		return nil
+	}
+	ds := &models.DateSummary{Earliest: fa.Earliest, Latest: fa.Latest}
+	if f.Type == models.FieldTypeTime {
+		return ds
+	}
+	ds.ByDay, ds.ByWeek, ds.ByMonth = map[string]int{}, map[string]int{}, map[string]int{}
+	for day, n := range fa.DateCounts {
+		d, err := time.Parse(models.DateLayout, day)
+		if err != nil {
+			continue
+		}
+		year, week := d.ISOWeek()
+		ds.ByDay[day] += n
+		ds.ByWeek[fmt.Sprintf("%d-W%02d", year, week)] += n
+		ds.ByMonth[d.Format("2006-01")] += n
+	}
+	return ds
+}
+
+// rowCounts lists every row and column of a matrix field, with the number
+// of times each column was picked in each row
+func rowCounts(fa *models.FieldAggregate, f models.Field) map[string]map[string]int {
+	out := make(map[string]map[string]int, len(f.Rows))
+	for _, row := range f.Rows {
+		out[row] = make(map[string]int, len(f.Columns))
+		for _, c := range f.Columns {
+			out[row][c] = 0
+		}
+	}
+	for row, counts := range fa.RowCounts {
+		if out[row] == nil {
+			out[row] = map[string]int{}
+		}
+		for c, n := range counts {
+			out[row][c] = n
+		}
+	}
+	return out
+}
+
+// booleanSummary counts the yes and no answers of a yes/no field; nil
+// before the first answer
+func booleanSummary(fa *models.FieldAggregate) *models.BooleanSummary {
+	if fa.ResponseCount == 0 {
+		return nil
+	}
+	s := &models.BooleanSummary{True: fa.OptionCounts["true"], False: fa.OptionCounts["false"]}
+	if n := s.True + s.False; n > 0 {
+		s.TrueRatio = float64(s.True) / float64(n)
+	}
+	return s
+}
+
+func numberSummary(fa *models.FieldAggregate) *models.NumberSummary {
+	if fa.ResponseCount == 0 {
+		return nil
+	}
+	ns := &models.NumberSummary{Average: fa.Sum / float64(fa.ResponseCount)}
+	if fa.Min != nil {
+		ns.Min = *fa.Min
+	}
+	if fa.Max != nil {
+		ns.Max = *fa.Max
+	}
+	return ns
+}
+
+// carryOver copies into agg the counters of prev that can't be rebuilt
+// from the stored responses
+func carryOver(agg, prev *models.FormAggregate) {
+	if prev == nil {
+		return
+	}
+	if len(prev.PageCompletions) > 0 {
+		agg.PageCompletions = prev.PageCompletions
+	}
+	agg.DraftsStarted = prev.DraftsStarted
+}
+
+// Build turns agg into the analytics payload for form
+func Build(agg *models.FormAggregate, form *models.Form) models.Analytics {
+	fieldStats := make(map[string]models.FieldStats, len(form.Fields))
+	topOptions := map[string]models.TopOption{}
+
+	for _, f := range form.Fields {
+		fa := agg.Fields[f.ID]
+		if fa == nil {
+			fa = &models.FieldAggregate{}
+		}
+		fs := models.FieldStats{
+			FieldID:       f.ID,
+			FieldLabel:    f.Label,
+			FieldType:     f.Type,
+			ResponseCount: fa.ResponseCount,
+			SkipCount:     fa.SkipCount,
+			HiddenCount:   fa.HiddenCount,
+		}
+
+		switch f.Type {
+		case models.FieldTypeMultipleChoice, models.FieldTypeDropdown, models.FieldTypeCheckbox:
+			fs.OptionCounts = map[string]int{}
+			for _, opt := range f.Options {
+				fs.OptionCounts[opt] = 0
+			}
+			for opt, n := range fa.OptionCounts {
+				fs.OptionCounts[opt] = n
+			}
+			if top, ok := topOption(f.Options, fs.OptionCounts); ok {
+				topOptions[f.ID] = top
+			}
+			if fa.OtherCount > 0 {
+				fs.OtherCount = fa.OtherCount
+				fs.OtherResponses = append([]string{}, fa.OtherResponses...)
+			}
+		case models.FieldTypeRating:
+			if fa.ResponseCount > 0 {
+				avg := fa.Sum / float64(fa.ResponseCount)
+				fs.AverageRating = &avg
+				fs.RatingDistribution = map[string]int{}
+				for k, v := range fa.RatingDistribution {
+					fs.RatingDistribution[k] = v
+				}
+			}
+		case models.FieldTypeNumber:
+			fs.NumberSummary = numberSummary(fa)
+		case models.FieldTypeSlider:
+			fs.NumberSummary = numberSummary(fa)
+			fs.Histogram = histogram(fa, f)
+		case models.FieldTypeBoolean:
+			fs.Boolean = booleanSummary(fa)
+		case models.FieldTypeDate, models.FieldTypeTime, models.FieldTypeDateTime:
+			fs.DateSummary = dateSummary(fa, f)
+		case models.FieldTypeFile:
+			fs.FileCount = int(fa.Sum)
+		case models.FieldTypeMatrix:
+			fs.RowCounts = rowCounts(fa, f)
+		case models.FieldTypeNPS:
+			fs.NPS = npsSummary(fa, true)
+		case models.FieldTypeRanking:
+			fs.Ranking = rankStats(fa, f)
+		case models.FieldTypeText, models.FieldTypeTextarea, models.FieldTypeEmail, models.FieldTypePhone, models.FieldTypeURL:
+			fs.TextResponses = append([]string{}, fa.TextResponses...)
+		}
+		fieldStats[f.ID] = fs
+	}
+
+	// Build rating trend points in chronological order
+	ratingOverTime := []models.RatingPoint{}
+	for day, b := range agg.Days {
+		if b.RatingCount > 0 {
+			ratingOverTime = append(ratingOverTime, models.RatingPoint{Date: day, Average: b.RatingSum / float64(b.RatingCount)})
+		}
+	}
+	sort.Slice(ratingOverTime, func(i, j int) bool { return ratingOverTime[i].Date < ratingOverTime[j].Date })
+
+	// Most skipped fields (top 3)
+	skipped := []models.MostSkippedItem{}
+	for _, f := range form.Fields {
+		if fa := agg.Fields[f.ID]; fa != nil && fa.SkipCount > 0 {
+			skipped = append(skipped, models.MostSkippedItem{FieldID: f.ID, FieldLabel: f.Label, Count: fa.SkipCount})
+		}
+	}
+	sort.SliceStable(skipped, func(i, j int) bool { return skipped[i].Count > skipped[j].Count })
+	if len(skipped) > 3 {
+		skipped = skipped[:3]
+	}
+
+	return models.Analytics{
+		FormID:         form.ID,
+		TotalResponses: agg.TotalResponses,
+		FieldAnalytics: fieldStats,
+		LastUpdated:    time.Now(),
+		RatingOverTime: ratingOverTime,
+		MostSkipped:    skipped,
+		TopOptions:     topOptions,
+		PageDropOff:    pageDropOff(agg, form),
+		Completion:     completion(agg),
+	}
+}
+
+// completion counts every draft as a started response, plus the
+// submissions that were made directly without one
+func completion(agg *models.FormAggregate) models.CompletionStats {
+	direct := maxInt(agg.TotalResponses-agg.DraftsCompleted, 0)
+	cs := models.CompletionStats{
+		Started:   maxInt(agg.DraftsStarted, agg.DraftsCompleted) + direct,
+		Completed: agg.TotalResponses,
+	}
+	if cs.Started > 0 {
+		cs.CompletionRate = float64(cs.Completed) / float64(cs.Started)
+	}
+	return cs
+}
+
+// pageDropOff reports, for each page of a multi-page form, how many
+// respondents reached it and how many moved past it. The first page is
+// reached by starting the form, later ones by completing the page before,
+// and the last page is completed by submitting. Submissions that skipped
+// page validation still count as having reached every page, so the
+// numbers never go negative.
+func pageDropOff(agg *models.FormAggregate, form *models.Form) []models.PageStats {
+	if len(form.Pages) < 2 {
+		return nil
+	}
+	completed := make([]int, len(form.Pages))
+	for i, p := range form.Pages {
+		// Every submission has been through every page
+		completed[i] = maxInt(agg.PageCompletions[p.ID], agg.TotalResponses)
+	}
+	// Later pages can't be completed by more people than earlier ones
+	for i := len(completed) - 2; i >= 0; i-- {
+		completed[i] = maxInt(completed[i], completed[i+1])
+	}
+
+	out := make([]models.PageStats, 0, len(form.Pages))
+	for i, p := range form.Pages {
+		ps := models.PageStats{PageID: p.ID, Title: p.Title, Completed: completed[i]}
+		if i == 0 {
+			ps.Reached = maxInt(completion(agg).Started, completed[0])
+		} else {
+			ps.Reached = completed[i-1]
+		}
+		ps.DropOff = ps.Reached - ps.Completed
+		if ps.Reached > 0 {
+			ps.DropOffRate = float64(ps.DropOff) / float64(ps.Reached)
+		}
+		out = append(out, ps)
+	}
+	return out
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// topOption picks the most chosen option, preferring the form's option
+// order on ties so the result is stable
+func topOption(order []string, counts map[string]int) (models.TopOption, bool) {
+	if len(counts) == 0 {
+		return models.TopOption{}, false
+	}
+	keys := append([]string{}, order...)
+	extra := make([]string, 0)
+	for opt := range counts {
+		if !contains(order, opt) {
+			extra = append(extra, opt)
+		}
+	}
+	sort.Strings(extra)
+	keys = append(keys, extra...)
+
+	best := models.TopOption{Count: -1}
+	for _, opt := range keys {
+		if n := counts[opt]; n > best.Count {
+			best = models.TopOption{Option: opt, Count: n}
+		}
+	}
+	return best, true
+}
+
+// ---- value helpers ----
+
+func contains(xs []string, s string) bool {
+	for _, x := range xs {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+package analytics
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"custom-form-builder/models"
+)
+
+func TestApplyDeltas(t *testing.T) {
+	form := &models.Form{
+		ID: primitive.NewObjectID(),
+		Fields: []models.Field{
+			{ID: "color", Type: models.FieldTypeMultipleChoice, Options: []string{"Red", "Blue"}, AllowOther: true},
+			{ID: "tags", Type: models.FieldTypeCheckbox, Options: []string{"A", "B"}},
+			{ID: "score", Type: models.FieldTypeRating},
+			{ID: "age", Type: models.FieldTypeNumber},
+			{ID: "note", Type: models.FieldTypeText},
+		},
+	}
+	agg := NewAggregate(form)
+	day := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		resp    models.FormResponse
+		total   int
+		skipped []string
+		hidden  []string
+		check   func(t *testing.T, d models.AnalyticsDelta)
+	}{
+		{
+			name: "first response",
+			resp: models.FormResponse{Responses: map[string]models.Answer{
+				"color": models.TextAnswer("Red"),
+				"tags":  models.ChoicesAnswer([]string{"A", "B"}),
+				"score": models.NumberAnswer(4),
+				"age":   models.NumberAnswer(30),
+				"note":  models.TextAnswer("Nice"),
+			}},
+			total:   1,
+			skipped: []string{},
+			hidden:  []string{},
+			check: func(t *testing.T, d models.AnalyticsDelta) {
+				if got := d.Fields["color"].OptionIncrements; !reflect.DeepEqual(got, map[string]int{"Red": 1}) {
+					t.Errorf("color increments = %v", got)
+				}
+				if got := d.Fields["tags"].OptionIncrements; !reflect.DeepEqual(got, map[string]int{"A": 1, "B": 1}) {
+					t.Errorf("tags increments = %v", got)
+				}
+				if fd := d.Fields["score"]; fd.RatingBucket != "4" || fd.AverageRating == nil || *fd.AverageRating != 4 {
+					t.Errorf("score delta = %+v", fd)
+				}
+				if fd := d.Fields["age"]; !fd.MinChanged || !fd.MaxChanged {
+					t.Errorf("first number doesn't change min and max: %+v", fd)
+				}
+				if d.Fields["note"].TextResponse != "Nice" {
+					t.Errorf("note delta = %+v", d.Fields["note"])
+				}
+				if d.RatingPoint == nil || d.RatingPoint.Date != "2026-03-01" || d.RatingPoint.Average != 4 {
+					t.Errorf("rating point = %+v", d.RatingPoint)
+				}
+			},
+		},
+		{
+			name: "skips and other",
+			resp: models.FormResponse{
+				Responses: map[string]models.Answer{"score": models.NumberAnswer(2), "age": models.NumberAnswer(20)},
+				Other:     map[string]string{"color": "Green"},
+			},
+			total:   2,
+			skipped: []string{"tags", "note"},
+			hidden:  []string{},
+			check: func(t *testing.T, d models.AnalyticsDelta) {
+				if fd := d.Fields["color"]; fd.OptionIncrements != nil || fd.OtherResponse != "Green" || fd.ResponseCount != 2 {
+					t.Errorf("color delta = %+v", fd)
+				}
+				if fd := d.Fields["tags"]; !fd.Skipped || fd.ResponseCount != 1 {
+					t.Errorf("tags delta = %+v", fd)
+				}
+				if fd := d.Fields["score"]; *fd.AverageRating != 3 {
+					t.Errorf("average rating = %v, want 3", *fd.AverageRating)
+				}
+				if fd := d.Fields["age"]; !fd.MinChanged || fd.MaxChanged {
+					t.Errorf("lower number: %+v", fd)
+				}
+				if d.RatingPoint.Average != 3 {
+					t.Errorf("day average = %v, want 3", d.RatingPoint.Average)
+				}
+			},
+		},
+		{
+			name: "hidden fields",
+			resp: models.FormResponse{
+				Responses: map[string]models.Answer{"age": models.NumberAnswer(25)},
+				Hidden:    []string{"color", "tags", "score", "note"},
+			},
+			total:   3,
+			skipped: []string{},
+			hidden:  []string{"color", "tags", "score", "note"},
+			check: func(t *testing.T, d models.AnalyticsDelta) {
+				if fd := d.Fields["color"]; !fd.Hidden || fd.Skipped || fd.ResponseCount != 2 {
+					t.Errorf("color delta = %+v", fd)
+				}
+				if fd := d.Fields["age"]; fd.MinChanged || fd.MaxChanged || fd.ResponseCount != 3 {
+					t.Errorf("number in range: %+v", fd)
+				}
+				if d.RatingPoint != nil {
+					t.Errorf("rating point without a rating: %+v", d.RatingPoint)
+				}
+			},
+		},
+		{
+			name: "completed draft",
+			resp: models.FormResponse{
+				Responses: map[string]models.Answer{"color": models.TextAnswer("Blue")},
+				DraftID:   primitive.NewObjectID(),
+			},
+			total:   4,
+			skipped: []string{"tags", "score", "age", "note"},
+			hidden:  []string{},
+			check: func(t *testing.T, d models.AnalyticsDelta) {
+				if agg.DraftsCompleted != 1 {
+					t.Errorf("drafts completed = %d, want 1", agg.DraftsCompleted)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.resp.ID = primitive.NewObjectID()
+			tt.resp.SubmittedAt = day
+			d := Apply(agg, form, tt.resp)
+			if d.FormID != form.ID || d.ResponseID != tt.resp.ID || d.TotalResponses != tt.total {
+				t.Errorf("delta header = %v %v %d, want total %d", d.FormID, d.ResponseID, d.TotalResponses, tt.total)
+			}
+			if !reflect.DeepEqual(d.Skipped, tt.skipped) || !reflect.DeepEqual(d.Hidden, tt.hidden) {
+				t.Errorf("skipped %v hidden %v, want %v %v", d.Skipped, d.Hidden, tt.skipped, tt.hidden)
+			}
+			if len(d.Fields) != len(form.Fields) {
+				t.Errorf("delta has %d fields, want %d", len(d.Fields), len(form.Fields))
+			}
+			tt.check(t, d)
+		})
+	}
+
+	if got := agg.Fields["color"].OptionCounts; !reflect.DeepEqual(got, map[string]int{"Red": 1, "Blue": 1}) {
+		t.Errorf("color counts = %v", got)
+	}
+	if got := agg.Days["2026-03-01"].Responses; got != 4 {
+		t.Errorf("responses on the day = %d, want 4", got)
+	}
+}
+
+func TestDeltaRedacted(t *testing.T) {
+	d := models.AnalyticsDelta{Fields: map[string]models.FieldDelta{
+		"note":  {FieldID: "note", TextResponse: "secret", ResponseCount: 1},
+		"color": {FieldID: "color", OtherResponse: "Green", OptionIncrements: map[string]int{"Red": 1}},
+	}}
+	r := d.Redacted()
+	if r.Fields["note"].TextResponse != "" || r.Fields["color"].OtherResponse != "" {
+		t.Errorf("redacted delta keeps answers: %+v", r.Fields)
+	}
+	if r.Fields["note"].ResponseCount != 1 || r.Fields["color"].OptionIncrements["Red"] != 1 {
+		t.Errorf("redacted delta lost counts: %+v", r.Fields)
+	}
+	if d.Fields["note"].TextResponse != "secret" {
+		t.Error("Redacted changed the original delta")
+	}
+}
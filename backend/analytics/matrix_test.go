@@ -0,0 +1,87 @@
+package analytics
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"custom-form-builder/models"
+)
+
+func TestApplyMatrix(t *testing.T) {
+	form := &models.Form{
+		ID: primitive.NewObjectID(),
+		Fields: []models.Field{
+			{ID: "m", Type: models.FieldTypeMatrix, Rows: []string{"Speed", "Price"}, Columns: []string{"Good", "Bad"}, MultiplePerRow: true},
+		},
+	}
+	agg := NewAggregate(form)
+
+	tests := []struct {
+		name       string
+		answer     *models.Answer
+		increments map[string]map[string]int
+		responses  int
+		skips      int
+	}{
+		{
+			name:       "every row",
+			answer:     answerPtr(models.MatrixAnswer(map[string][]string{"Speed": {"Good"}, "Price": {"Bad"}})),
+			increments: map[string]map[string]int{"Speed": {"Good": 1}, "Price": {"Bad": 1}},
+			responses:  1,
+		},
+		{
+			name:       "several columns in a row",
+			answer:     answerPtr(models.MatrixAnswer(map[string][]string{"Speed": {"Good", "Bad"}})),
+			increments: map[string]map[string]int{"Speed": {"Good": 1, "Bad": 1}},
+			responses:  2,
+		},
+		{
+			name:      "skipped",
+			responses: 2,
+			skips:     1,
+		},
+		{
+			name:      "empty matrix",
+			answer:    answerPtr(models.MatrixAnswer(map[string][]string{})),
+			responses: 2,
+			skips:     2,
+		},
+	}
+	for _, tt := range tests {
+		resp := models.FormResponse{Responses: map[string]models.Answer{}}
+		if tt.answer != nil {
+			resp.Responses["m"] = *tt.answer
+		}
+		fd := Apply(agg, form, resp).Fields["m"]
+		if !reflect.DeepEqual(fd.RowIncrements, tt.increments) {
+			t.Errorf("%s: row increments = %v, want %v", tt.name, fd.RowIncrements, tt.increments)
+		}
+		if fd.ResponseCount != tt.responses {
+			t.Errorf("%s: response count = %d, want %d", tt.name, fd.ResponseCount, tt.responses)
+		}
+		if got := agg.Fields["m"].SkipCount; got != tt.skips {
+			t.Errorf("%s: skip count = %d, want %d", tt.name, got, tt.skips)
+		}
+	}
+
+	want := map[string]map[string]int{
+		"Speed": {"Good": 2, "Bad": 1},
+		"Price": {"Good": 0, "Bad": 1},
+	}
+	if got := Build(agg, form).FieldAnalytics["m"].RowCounts; !reflect.DeepEqual(got, want) {
+		t.Errorf("row counts = %v, want %v", got, want)
+	}
+}
+
+func TestRowCountsKeepsRemovedRows(t *testing.T) {
+	field := models.Field{ID: "m", Type: models.FieldTypeMatrix, Rows: []string{"Speed"}, Columns: []string{"Good"}}
+	fa := &models.FieldAggregate{RowCounts: map[string]map[string]int{"Gone": {"Good": 3}}}
+	want := map[string]map[string]int{"Speed": {"Good": 0}, "Gone": {"Good": 3}}
+	if got := rowCounts(fa, field); !reflect.DeepEqual(got, want) {
+		t.Errorf("rowCounts = %v, want %v", got, want)
+	}
+}
+
+func answerPtr(a models.Answer) *models.Answer { return &a }
@@ -0,0 +1,79 @@
+package analytics
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"custom-form-builder/models"
+)
+
+// addNPS records an NPS answer given on day
+func addNPS(fa *models.FieldAggregate, day string, score int) {
+	fa.ResponseCount++
+	fa.RatingDistribution[strconv.Itoa(score)]++
+	if fa.NPSDays == nil {
+		fa.NPSDays = map[string]*models.NPSCounts{}
+	}
+	c := fa.NPSDays[day]
+	if c == nil {
+		c = &models.NPSCounts{}
+		fa.NPSDays[day] = c
+	}
+	c.Add(score, 1)
+}
+
+// npsSummary describes the answers counted in fa, with the daily trend
+// when trend is set; nil before the first answer
+func npsSummary(fa *models.FieldAggregate, trend bool) *models.NPSSummary {
+	if fa.ResponseCount == 0 {
+		return nil
+	}
+	var c models.NPSCounts
+	dist := make(map[string]int, 11)
+	for score := 0; score <= 10; score++ {
+		key := strconv.Itoa(score)
+		n := fa.RatingDistribution[key]
+		dist[key] = n
+		c.Add(score, n)
+	}
+	s := &models.NPSSummary{
+		Promoters:    c.Promoters,
+		Passives:     c.Passives,
+		Detractors:   c.Detractors,
+		Distribution: dist,
+	}
+	s.Score, s.Low, s.High = npsScore(c)
+
+	if trend {
+		s.Trend = []models.NPSPoint{}
+		for day, c := range fa.NPSDays {
+			s.Trend = append(s.Trend, npsPoint(day, c))
+		}
+		sort.Slice(s.Trend, func(i, j int) bool { return s.Trend[i].Date < s.Trend[j].Date })
+	}
+	return s
+}
+
+func npsPoint(day string, c *models.NPSCounts) models.NPSPoint {
+	score, _, _ := npsScore(*c)
+	return models.NPSPoint{Date: day, Score: score, Responses: c.Promoters + c.Passives + c.Detractors}
+}
+
+// npsScore returns the NPS of c and its 95% confidence interval. Each
+// answer counts +1 (promoter), 0 (passive) or -1 (detractor), so the score
+// is their mean and its variance p + d - (p - d)², for shares p and d.
+func npsScore(c models.NPSCounts) (score, low, high float64) {
+	n := float64(c.Promoters + c.Passives + c.Detractors)
+	if n == 0 {
+		return 0, 0, 0
+	}
+	p, d := float64(c.Promoters)/n, float64(c.Detractors)/n
+	nps := p - d
+	margin := 1.96 * math.Sqrt((p+d-nps*nps)/n)
+	return round1(nps * 100), round1(math.Max(nps-margin, -1) * 100), round1(math.Min(nps+margin, 1) * 100)
+}
+
+func round1(x float64) float64 {
+	return math.Round(x*10) / 10
+}
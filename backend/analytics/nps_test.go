@@ -0,0 +1,73 @@
+package analytics
+
+import (
+	"reflect"
+	"testing"
+
+	"custom-form-builder/models"
+)
+
+func TestNPSScore(t *testing.T) {
+	tests := []struct {
+		name             string
+		counts           models.NPSCounts
+		score, low, high float64
+	}{
+		{"no answers", models.NPSCounts{}, 0, 0, 0},
+		{"only promoters", models.NPSCounts{Promoters: 4}, 100, 100, 100},
+		{"only detractors", models.NPSCounts{Detractors: 4}, -100, -100, -100},
+		{"only passives", models.NPSCounts{Passives: 4}, 0, 0, 0},
+		{"split evenly", models.NPSCounts{Promoters: 5, Detractors: 5}, 0, -62, 62},
+		{"mixed", models.NPSCounts{Promoters: 50, Passives: 30, Detractors: 20}, 30, 14.7, 45.3},
+		{"interval clamped to 100", models.NPSCounts{Promoters: 2, Passives: 1}, 66.7, 13.3, 100},
+		{"interval clamped to -100", models.NPSCounts{Detractors: 2, Passives: 1}, -66.7, -100, -13.3},
+	}
+	for _, tt := range tests {
+		score, low, high := npsScore(tt.counts)
+		if score != tt.score || low != tt.low || high != tt.high {
+			t.Errorf("%s: npsScore = %g [%g, %g], want %g [%g, %g]", tt.name, score, low, high, tt.score, tt.low, tt.high)
+		}
+	}
+}
+
+func TestNPSCategories(t *testing.T) {
+	var c models.NPSCounts
+	for score := 0; score <= 10; score++ {
+		c.Add(score, 1)
+	}
+	if want := (models.NPSCounts{Promoters: 2, Passives: 2, Detractors: 7}); c != want {
+		t.Errorf("counts = %+v, want %+v", c, want)
+	}
+}
+
+func TestNPSSummary(t *testing.T) {
+	fa := &models.FieldAggregate{RatingDistribution: map[string]int{}}
+	if s := npsSummary(fa, true); s != nil {
+		t.Errorf("summary before the first answer = %+v, want nil", s)
+	}
+	for _, a := range []struct {
+		day   string
+		score int
+	}{{"2026-03-02", 10}, {"2026-03-01", 3}, {"2026-03-02", 9}, {"2026-03-02", 7}} {
+		addNPS(fa, a.day, a.score)
+	}
+
+	s := npsSummary(fa, false)
+	if s.Promoters != 2 || s.Passives != 1 || s.Detractors != 1 || s.Score != 25 {
+		t.Errorf("summary = %+v", s)
+	}
+	if s.Trend != nil {
+		t.Errorf("trend without trend = %+v", s.Trend)
+	}
+	if len(s.Distribution) != 11 || s.Distribution["0"] != 0 || s.Distribution["9"] != 1 {
+		t.Errorf("distribution = %v", s.Distribution)
+	}
+
+	want := []models.NPSPoint{
+		{Date: "2026-03-01", Score: -100, Responses: 1},
+		{Date: "2026-03-02", Score: 66.7, Responses: 3},
+	}
+	if got := npsSummary(fa, true).Trend; !reflect.DeepEqual(got, want) {
+		t.Errorf("trend = %+v, want %+v", got, want)
+	}
+}
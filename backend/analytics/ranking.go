@@ -0,0 +1,65 @@
+package analytics
+
+import (
+	"sort"
+
+	"custom-form-builder/models"
+)
+
+// addRanking records a ranking answer, best option first
+func addRanking(fa *models.FieldAggregate, f models.Field, ranked []string) {
+	fa.ResponseCount++
+	if fa.Ranks == nil {
+		fa.Ranks = map[string]*models.RankCounts{}
+	}
+	n := len(f.Options)
+	for i, opt := range ranked {
+		rc := fa.Ranks[opt]
+		if rc == nil {
+			rc = &models.RankCounts{}
+			fa.Ranks[opt] = rc
+		}
+		rc.RankSum += i + 1
+		rc.Ranked++
+		if points := n - 1 - i; points > 0 {
+			rc.Borda += points
+		}
+		if i == 0 {
+			rc.First++
+		}
+	}
+}
+
+// rankStats sums up the ranks given to each option of a ranking field,
+// highest Borda count first; ties keep the field's option order. Options
+// ranked before they were removed from the field are listed too.
+func rankStats(fa *models.FieldAggregate, f models.Field) []models.RankStats {
+	options := append([]string{}, f.Options...)
+	var removed []string
+	for opt := range fa.Ranks {
+		if !contains(f.Options, opt) {
+			removed = append(removed, opt)
+		}
+	}
+	sort.Strings(removed)
+	options = append(options, removed...)
+
+	out := make([]models.RankStats, 0, len(options))
+	for _, opt := range options {
+		rs := models.RankStats{Option: opt}
+		if rc := fa.Ranks[opt]; rc != nil {
+			rs.Borda = rc.Borda
+			rs.FirstChoices = rc.First
+			if rc.Ranked > 0 {
+				avg := float64(rc.RankSum) / float64(rc.Ranked)
+				rs.AverageRank = &avg
+			}
+		}
+		if fa.ResponseCount > 0 {
+			rs.FirstChoiceShare = float64(rs.FirstChoices) / float64(fa.ResponseCount)
+		}
+		out = append(out, rs)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Borda > out[j].Borda })
+	return out
+}
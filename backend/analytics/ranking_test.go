@@ -0,0 +1,56 @@
+package analytics
+
+import (
+	"testing"
+
+	"custom-form-builder/models"
+)
+
+func TestRankStats(t *testing.T) {
+	field := models.Field{ID: "r", Type: models.FieldTypeRanking, Options: []string{"A", "B", "C"}}
+	fa := &models.FieldAggregate{}
+	addRanking(fa, field, []string{"B", "A", "C"})
+	addRanking(fa, field, []string{"B", "C", "A"})
+	addRanking(fa, field, []string{"A", "B"})
+	addRanking(fa, field, []string{"Gone", "A"})
+
+	type want struct {
+		option  string
+		average float64
+		borda   int
+		first   int
+		share   float64
+	}
+	wants := []want{
+		{"B", 4.0 / 3, 5, 2, 0.5},
+		{"A", 2, 4, 1, 0.25},
+		{"Gone", 1, 2, 1, 0.25},
+		{"C", 2.5, 1, 0, 0},
+	}
+	stats := rankStats(fa, field)
+	if len(stats) != len(wants) {
+		t.Fatalf("got %d options, want %d: %+v", len(stats), len(wants), stats)
+	}
+	for i, w := range wants {
+		got := stats[i]
+		if got.Option != w.option || got.Borda != w.borda || got.FirstChoices != w.first || got.FirstChoiceShare != w.share {
+			t.Errorf("stats[%d] = %+v, want %+v", i, got, w)
+		}
+		if got.AverageRank == nil || *got.AverageRank != w.average {
+			t.Errorf("%s: average rank = %v, want %v", w.option, got.AverageRank, w.average)
+		}
+	}
+}
+
+func TestRankStatsUnranked(t *testing.T) {
+	field := models.Field{ID: "r", Type: models.FieldTypeRanking, Options: []string{"A", "B"}}
+	stats := rankStats(&models.FieldAggregate{}, field)
+	if len(stats) != 2 || stats[0].Option != "A" || stats[1].Option != "B" {
+		t.Fatalf("stats = %+v, want A and B in option order", stats)
+	}
+	for _, s := range stats {
+		if s.AverageRank != nil || s.Borda != 0 || s.FirstChoiceShare != 0 {
+			t.Errorf("unranked option %s has stats %+v", s.Option, s)
+		}
+	}
+}
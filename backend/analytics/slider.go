@@ -0,0 +1,62 @@
+package analytics
+
+import (
+	"math"
+
+	"custom-form-builder/models"
+)
+
+// histogramBins is how many bins a slider's histogram is split into. A
+// slider taking at most twice as many values gets a bin per value.
+const histogramBins = 10
+
+// sliderBins returns how many of a slider's values each histogram bin
+// holds, the number of bins and the number of values; the last bin also
+// takes the values left over
+func sliderBins(f models.Field) (per, n, values int) {
+	lo, hi, step := f.SliderRange()
+	values = int(math.Floor((hi-lo)/step+1e-9)) + 1
+	if values <= 2*histogramBins {
+		return 1, values, values
+	}
+	per = (values - 1 + histogramBins - 1) / histogramBins
+	return per, (values - 1) / per, values
+}
+
+// addSlider records a slider answer in fa's running sum/min/max and its
+// histogram bin, and reports whether the minimum or maximum changed
+func addSlider(fa *models.FieldAggregate, f models.Field, v float64) (minChanged, maxChanged bool) {
+	minChanged, maxChanged = addNumber(fa, v)
+	per, n, _ := sliderBins(f)
+	if len(fa.Bins) != n {
+		bins := make([]int, n)
+		copy(bins, fa.Bins)
+		fa.Bins = bins
+	}
+	lo, _, step := f.SliderRange()
+	i := int(math.Round((v-lo)/step)) / per
+	if i < 0 {
+		i = 0
+	} else if i >= n {
+		i = n - 1
+	}
+	fa.Bins[i]++
+	return minChanged, maxChanged
+}
+
+// histogram lists the bins of a slider's answers, from its lowest value up
+func histogram(fa *models.FieldAggregate, f models.Field) []models.HistogramBin {
+	per, n, values := sliderBins(f)
+	lo, _, step := f.SliderRange()
+	value := func(i int) float64 { return math.Round((lo+float64(i)*step)*1e9) / 1e9 }
+
+	out := make([]models.HistogramBin, n)
+	for i := range out {
+		out[i] = models.HistogramBin{From: value(i * per), To: value((i+1)*per - 1)}
+		if i < len(fa.Bins) {
+			out[i].Count = fa.Bins[i]
+		}
+	}
+	out[n-1].To = value(values - 1)
+	return out
+}
@@ -0,0 +1,43 @@
+package analytics
+
+import (
+	"reflect"
+	"testing"
+
+	"custom-form-builder/models"
+)
+
+func bin(from, to float64, count int) models.HistogramBin {
+	return models.HistogramBin{From: from, To: to, Count: count}
+}
+
+func TestHistogram(t *testing.T) {
+	one, five := 1, 5
+	tests := []struct {
+		name   string
+		field  models.Field
+		values []float64
+		want   []models.HistogramBin
+	}{
+		{"bin per value", models.Field{MinValue: &one, MaxValue: &five}, []float64{1, 3, 3, 5},
+			[]models.HistogramBin{bin(1, 1, 1), bin(2, 2, 0), bin(3, 3, 2), bin(4, 4, 0), bin(5, 5, 1)}},
+		{"default range in ten bins", models.Field{}, []float64{0, 9, 10, 95, 100},
+			[]models.HistogramBin{bin(0, 9, 2), bin(10, 19, 1), bin(20, 29, 0), bin(30, 39, 0), bin(40, 49, 0),
+				bin(50, 59, 0), bin(60, 69, 0), bin(70, 79, 0), bin(80, 89, 0), bin(90, 100, 2)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.field.Type = models.FieldTypeSlider
+			fa := &models.FieldAggregate{}
+			for _, v := range tt.values {
+				addSlider(fa, tt.field, v)
+			}
+			if got := histogram(fa, tt.field); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("histogram = %v, want %v", got, tt.want)
+			}
+			if fa.ResponseCount != len(tt.values) {
+				t.Errorf("response count = %d, want %d", fa.ResponseCount, len(tt.values))
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package analytics
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"custom-form-builder/models"
+	"custom-form-builder/store"
+)
+
+// ErrResponseLimit is returned by Record when the form already has its
+// maximum number of responses
+var ErrResponseLimit = errors.New("analytics: response limit reached")
+
+// SchemaHash fingerprints the parts of a form's definition an aggregate
+// depends on (field IDs and types)
+func SchemaHash(form *models.Form) string {
+	h := sha256.New()
+	for _, f := range form.Fields {
+		h.Write([]byte(f.ID))
+		h.Write([]byte{0})
+		h.Write([]byte(f.Type))
+		h.Write([]byte{0})
+		// Datetime answers are bucketed by day in the field's timezone
+		if f.Type == models.FieldTypeDateTime && f.Timezone != "" {
+			h.Write([]byte(f.Timezone))
+			h.Write([]byte{0})
+		}
+		// Slider answers are binned over the field's range
+		if f.Type == models.FieldTypeSlider {
+			lo, hi, step := f.SliderRange()
+			fmt.Fprintf(h, "%g:%g:%g", lo, hi, step)
+			h.Write([]byte{0})
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// BuildAggregate computes a form's aggregate from scratch by replaying
+// every stored response
+func BuildAggregate(ctx context.Context, db store.ResponseStore, form *models.Form) (*models.FormAggregate, error) {
+	agg := NewAggregate(form)
+	err := db.ForEachResponse(ctx, form.ID, func(resp models.FormResponse) error {
+		Apply(agg, form, resp)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return agg, nil
+}
+
+// BuildScoped computes an aggregate over a subset of a form's responses
+// without storing it. view is the form the result describes; pick returns
+// the form to apply each response with (a view restricted to the fields
+// that response could have answered), or nil to leave it out.
+func BuildScoped(ctx context.Context, db store.ResponseStore, view *models.Form, pick func(models.FormResponse) *models.Form) (*models.FormAggregate, error) {
+	agg := NewAggregate(view)
+	err := db.ForEachResponse(ctx, view.ID, func(resp models.FormResponse) error {
+		if form := pick(resp); form != nil {
+			Apply(agg, form, resp)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return agg, nil
+}
+
+// Tracker maintains the persisted per-form aggregates. Each submission is
+// applied to the stored aggregate, so reading analytics never rescans
+// responses. An aggregate that is missing or was built for a different
+// field list is rebuilt from the responses on first use.
+//
+// Updates to a form's aggregate are serialized within this process.
+type Tracker struct {
+	db store.Store
+
+	mu    sync.Mutex
+	locks map[primitive.ObjectID]*formLock
+}
+
+// formLock serializes updates to one form's aggregate. It is dropped from
+// Tracker.locks once no one holds or waits for it.
+type formLock struct {
+	sync.Mutex
+	refs int
+}
+
+// NewTracker creates a Tracker reading and writing through db
+func NewTracker(db store.Store) *Tracker {
+	return &Tracker{db: db, locks: make(map[primitive.ObjectID]*formLock)}
+}
+
+// Record stores resp and applies it to the form's aggregate, returning the
+// resulting delta. It fails with ErrResponseLimit once the form has
+// MaxResponses responses.
+func (t *Tracker) Record(ctx context.Context, form *models.Form, resp *models.FormResponse) (models.AnalyticsDelta, error) {
+	unlock := t.lock(form.ID)
+	defer unlock()
+
+	agg, err := t.load(ctx, form)
+	if err != nil {
+		return models.AnalyticsDelta{}, err
+	}
+	// Checked under the form's lock so concurrent submissions can't
+	// overshoot the limit
+	if form.MaxResponses > 0 && agg.TotalResponses >= form.MaxResponses {
+		return models.AnalyticsDelta{}, ErrResponseLimit
+	}
+	if err := t.db.CreateResponse(ctx, resp); err != nil {
+		return models.AnalyticsDelta{}, err
+	}
+
+	delta := Apply(agg, form, *resp)
+	if err := t.db.SaveAggregate(ctx, agg); err != nil {
+		// The response is stored; drop the stale aggregate so the next
+		// read rebuilds it instead of failing the submission.
+		log.Printf("analytics: saving aggregate for form %s: %v", form.ID.Hex(), err)
+		if err := t.db.DeleteAggregate(ctx, form.ID); err != nil {
+			log.Printf("analytics: dropping aggregate for form %s: %v", form.ID.Hex(), err)
+		}
+	}
+	return delta, nil
+}
+
+// RecordPage counts one respondent moving past a page of a multi-page form
+func (t *Tracker) RecordPage(ctx context.Context, form *models.Form, pageID string) error {
+	unlock := t.lock(form.ID)
+	defer unlock()
+
+	agg, err := t.load(ctx, form)
+	if err != nil {
+		return err
+	}
+	if agg.PageCompletions == nil {
+		agg.PageCompletions = map[string]int{}
+	}
+	agg.PageCompletions[pageID]++
+	agg.UpdatedAt = time.Now()
+	return t.db.SaveAggregate(ctx, agg)
+}
+
+// RecordDraftStart counts a respondent saving a first draft of form
+func (t *Tracker) RecordDraftStart(ctx context.Context, form *models.Form) error {
+	unlock := t.lock(form.ID)
+	defer unlock()
+
+	agg, err := t.load(ctx, form)
+	if err != nil {
+		return err
+	}
+	agg.DraftsStarted++
+	agg.UpdatedAt = time.Now()
+	return t.db.SaveAggregate(ctx, agg)
+}
+
+// Aggregate returns the current aggregate of form
+func (t *Tracker) Aggregate(ctx context.Context, form *models.Form) (*models.FormAggregate, error) {
+	unlock := t.lock(form.ID)
+	defer unlock()
+	return t.load(ctx, form)
+}
+
+// Rebuild recomputes and stores the aggregate of form from its responses
+func (t *Tracker) Rebuild(ctx context.Context, form *models.Form) (*models.FormAggregate, error) {
+	unlock := t.lock(form.ID)
+	defer unlock()
+
+	prev, err := t.db.GetAggregate(ctx, form.ID)
+	if err != nil && err != store.ErrNotFound {
+		return nil, err
+	}
+	return t.rebuild(ctx, form, prev)
+}
+
+func (t *Tracker) load(ctx context.Context, form *models.Form) (*models.FormAggregate, error) {
+	agg, err := t.db.GetAggregate(ctx, form.ID)
+	if err == nil && agg.SchemaHash == SchemaHash(form) {
+		return agg, nil
+	}
+	if err != nil && err != store.ErrNotFound {
+		return nil, err
+	}
+	return t.rebuild(ctx, form, agg)
+}
+
+// rebuild replays the responses of form into a new aggregate, keeping the
+// progress counters of prev (which may be nil)
+func (t *Tracker) rebuild(ctx context.Context, form *models.Form, prev *models.FormAggregate) (*models.FormAggregate, error) {
+	agg, err := BuildAggregate(ctx, t.db, form)
+	if err != nil {
+		return nil, err
+	}
+	carryOver(agg, prev)
+	if err := t.db.SaveAggregate(ctx, agg); err != nil {
+		return nil, err
+	}
+	return agg, nil
+}
+
+func (t *Tracker) lock(formID primitive.ObjectID) (unlock func()) {
+	t.mu.Lock()
+	l := t.locks[formID]
+	if l == nil {
+		l = &formLock{}
+		t.locks[formID] = l
+	}
+	l.refs++
+	t.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		t.mu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(t.locks, formID)
+		}
+		t.mu.Unlock()
+	}
+}
@@ -0,0 +1,197 @@
+package analytics
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"custom-form-builder/models"
+	"custom-form-builder/store"
+)
+
+func newTrackedForm(t *testing.T, db *store.MemoryStore, maxResponses int) *models.Form {
+	t.Helper()
+	form := &models.Form{
+		Title:        "Tracked",
+		Fields:       []models.Field{{ID: "score", Type: models.FieldTypeRating}},
+		MaxResponses: maxResponses,
+		CreatedAt:    time.Now(),
+	}
+	if err := db.CreateForm(context.Background(), form); err != nil {
+		t.Fatal(err)
+	}
+	return form
+}
+
+// recordConcurrently submits n responses to form at once and returns how
+// many were stored and how many hit the response limit
+func recordConcurrently(t *testing.T, tracker *Tracker, form *models.Form, n int) (stored, limited int) {
+	t.Helper()
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp := &models.FormResponse{
+				FormID:      form.ID,
+				Responses:   map[string]models.Answer{"score": models.NumberAnswer(float64(i%5 + 1))},
+				SubmittedAt: time.Now(),
+			}
+			_, err := tracker.Record(context.Background(), form, resp)
+			mu.Lock()
+			defer mu.Unlock()
+			switch err {
+			case nil:
+				stored++
+			case ErrResponseLimit:
+				limited++
+			default:
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	return stored, limited
+}
+
+func TestTrackerRecord(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxResponses int
+		submissions  int
+		stored       int
+	}{
+		{"no limit", 0, 20, 20},
+		{"under the limit", 30, 20, 20},
+		{"at the limit", 20, 20, 20},
+		{"over the limit", 5, 20, 5},
+		{"limit of one", 1, 20, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := store.NewMemoryStore()
+			tracker := NewTracker(db)
+			form := newTrackedForm(t, db, tt.maxResponses)
+
+			stored, limited := recordConcurrently(t, tracker, form, tt.submissions)
+			if stored != tt.stored || limited != tt.submissions-tt.stored {
+				t.Errorf("stored %d, limited %d; want %d stored", stored, limited, tt.stored)
+			}
+			responses, err := db.ListResponses(context.Background(), form.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(responses) != tt.stored {
+				t.Errorf("%d responses in the store, want %d", len(responses), tt.stored)
+			}
+			if n := len(tracker.locks); n != 0 {
+				t.Errorf("%d locks kept after recording, want 0", n)
+			}
+			// No update to the aggregate is lost
+			agg, err := tracker.Aggregate(context.Background(), form)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if agg.TotalResponses != tt.stored || agg.Fields["score"].ResponseCount != tt.stored {
+				t.Errorf("aggregate counts %d responses, %d scores; want %d", agg.TotalResponses, agg.Fields["score"].ResponseCount, tt.stored)
+			}
+		})
+	}
+}
+
+func TestTrackerProgress(t *testing.T) {
+	db := store.NewMemoryStore()
+	tracker := NewTracker(db)
+	form := newTrackedForm(t, db, 0)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := tracker.RecordDraftStart(ctx, form); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := tracker.RecordPage(ctx, form, "p1"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	agg, err := tracker.Aggregate(ctx, form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if agg.DraftsStarted != 10 || agg.PageCompletions["p1"] != 10 {
+		t.Errorf("drafts started %d, page completions %v; want 10 each", agg.DraftsStarted, agg.PageCompletions)
+	}
+}
+
+func TestTrackerRebuildsChangedSchema(t *testing.T) {
+	db := store.NewMemoryStore()
+	tracker := NewTracker(db)
+	form := newTrackedForm(t, db, 0)
+	ctx := context.Background()
+	recordConcurrently(t, tracker, form, 3)
+	if err := tracker.RecordDraftStart(ctx, form); err != nil {
+		t.Fatal(err)
+	}
+
+	changed := *form
+	changed.Fields = append([]models.Field{}, form.Fields...)
+	changed.Fields = append(changed.Fields, models.Field{ID: "note", Type: models.FieldTypeText})
+	agg, err := tracker.Aggregate(ctx, &changed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if agg.SchemaHash != SchemaHash(&changed) || agg.Fields["note"] == nil {
+		t.Errorf("aggregate wasn't rebuilt for the new fields: %+v", agg)
+	}
+	if agg.TotalResponses != 3 || agg.DraftsStarted != 1 {
+		t.Errorf("rebuilt aggregate counts %d responses, %d drafts; want 3, 1", agg.TotalResponses, agg.DraftsStarted)
+	}
+}
+
+func TestTrackerLocksPerForm(t *testing.T) {
+	tracker := NewTracker(store.NewMemoryStore())
+	a, b := primitive.NewObjectID(), primitive.NewObjectID()
+
+	unlockA := tracker.lock(a)
+	done := make(chan struct{})
+	go func() {
+		// Another form's lock isn't held up by a
+		tracker.lock(b)()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("locking another form blocked")
+	}
+
+	locked := make(chan struct{})
+	go func() {
+		tracker.lock(a)()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+		t.Fatal("the same form was locked twice")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlockA()
+	<-locked
+
+	if n := len(tracker.locks); n != 0 {
+		t.Errorf("%d locks kept after release, want 0", n)
+	}
+}
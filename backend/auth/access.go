@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"custom-form-builder/models"
+	"custom-form-builder/store"
+)
+
+// WorkspaceRole returns the role userID holds in a workspace, or "" if the
+// user is not a member
+func WorkspaceRole(ctx context.Context, db store.WorkspaceStore, workspaceID, userID primitive.ObjectID) (models.Role, error) {
+	member, err := db.GetMember(ctx, workspaceID, userID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return "", nil
+		}
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// FormRole returns the role userID holds on form, or "" if the user has
+// no access. Workspace forms take the member's workspace role; personal
+// forms give their owner full rights. Forms created before accounts
+// existed have no owner and are closed to everyone until claimed.
+func FormRole(ctx context.Context, db store.WorkspaceStore, form *models.Form, userID primitive.ObjectID) (models.Role, error) {
+	if !form.WorkspaceID.IsZero() {
+		return WorkspaceRole(ctx, db, form.WorkspaceID, userID)
+	}
+	if !form.OwnerID.IsZero() && form.OwnerID == userID {
+		return models.RoleOwner, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"custom-form-builder/models"
+	"custom-form-builder/store"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, prefix, hash, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(key, APIKeyPrefix) || !strings.HasPrefix(key, prefix) || len(prefix) != displayPrefixLen {
+		t.Errorf("key %q, prefix %q", key, prefix)
+	}
+	if hash != HashAPIKey(key) || hash == key {
+		t.Errorf("hash %q doesn't match the key", hash)
+	}
+	other, _, _, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == key {
+		t.Error("two keys are the same")
+	}
+}
+
+// newKeyApp serves a route needing forms:write, one needing a signed-in
+// user and one open to anyone, each answering with who called
+func newKeyApp(t *testing.T, db store.APIKeyStore) *fiber.App {
+	t.Helper()
+	issuer, err := NewIssuer("test-secret", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	whoami := func(c *fiber.Ctx) error {
+		id, _ := UserID(c)
+		return c.SendString(id.Hex())
+	}
+	app := fiber.New()
+	app.Use(Middleware(issuer, db))
+	app.Get("/write", RequireScope(models.ScopeFormsWrite), whoami)
+	app.Get("/me", RequireUser(), whoami)
+	app.Get("/open", whoami)
+	return app
+}
+
+func createKey(t *testing.T, db store.APIKeyStore, userID primitive.ObjectID, key *models.APIKey, scopes ...models.Scope) string {
+	t.Helper()
+	plain, prefix, hash, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key.UserID, key.Prefix, key.KeyHash, key.Scopes, key.CreatedAt = userID, prefix, hash, scopes, time.Now()
+	if err := db.CreateAPIKey(context.Background(), key); err != nil {
+		t.Fatal(err)
+	}
+	return plain
+}
+
+func TestMiddlewareAPIKeys(t *testing.T) {
+	db := store.NewMemoryStore()
+	userID := primitive.NewObjectID()
+	writer := createKey(t, db, userID, &models.APIKey{Name: "writer"}, models.ScopeFormsWrite)
+	reader := createKey(t, db, userID, &models.APIKey{Name: "reader"}, models.ScopeResponsesRead)
+	revokedAt := time.Now()
+	revoked := createKey(t, db, userID, &models.APIKey{Name: "revoked", RevokedAt: &revokedAt}, models.ScopeFormsWrite)
+	app := newKeyApp(t, db)
+
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		value  string
+		status int
+		user   string
+	}{
+		{"scoped key", "/write", "Authorization", "Bearer " + writer, fiber.StatusOK, userID.Hex()},
+		{"key in X-API-Key", "/write", HeaderAPIKey, writer, fiber.StatusOK, userID.Hex()},
+		{"key without the scope", "/write", "Authorization", "Bearer " + reader, fiber.StatusForbidden, ""},
+		{"revoked key", "/write", "Authorization", "Bearer " + revoked, fiber.StatusUnauthorized, ""},
+		{"unknown key", "/write", "Authorization", "Bearer " + APIKeyPrefix + "nope", fiber.StatusUnauthorized, ""},
+		{"no credentials", "/write", "", "", fiber.StatusUnauthorized, ""},
+		{"key on a user-only route", "/me", "Authorization", "Bearer " + writer, fiber.StatusForbidden, ""},
+		{"revoked key on an open route", "/open", "Authorization", "Bearer " + revoked, fiber.StatusOK, primitive.NilObjectID.Hex()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if tt.user != "" {
+				body := make([]byte, 64)
+				n, _ := resp.Body.Read(body)
+				if got := string(body[:n]); got != tt.user {
+					t.Errorf("user = %q, want %q", got, tt.user)
+				}
+			}
+		})
+	}
+}
+
+func TestMiddlewareStampsLastUse(t *testing.T) {
+	db := store.NewMemoryStore()
+	userID := primitive.NewObjectID()
+	recent := time.Now().Add(-10 * time.Second)
+	stale := time.Now().Add(-time.Hour)
+	fresh := &models.APIKey{Name: "fresh"}
+	used := &models.APIKey{Name: "used", LastUsedAt: &recent}
+	old := &models.APIKey{Name: "old", LastUsedAt: &stale}
+	keys := map[*models.APIKey]string{
+		fresh: createKey(t, db, userID, fresh, models.ScopeFormsWrite),
+		used:  createKey(t, db, userID, used, models.ScopeFormsWrite),
+		old:   createKey(t, db, userID, old, models.ScopeFormsWrite),
+	}
+	app := newKeyApp(t, db)
+	start := time.Now()
+	for _, plain := range keys {
+		req := httptest.NewRequest("GET", "/write", nil)
+		req.Header.Set("Authorization", "Bearer "+plain)
+		if _, err := app.Test(req, -1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		key     *models.APIKey
+		touched bool
+	}{
+		{fresh, true},
+		{used, false},
+		{old, true},
+	}
+	for _, tt := range tests {
+		got, err := db.GetAPIKey(context.Background(), tt.key.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		touched := got.LastUsedAt != nil && !got.LastUsedAt.Before(start)
+		if touched != tt.touched {
+			t.Errorf("%s: last used %v, want touched = %v", tt.key.Name, got.LastUsedAt, tt.touched)
+		}
+	}
+}
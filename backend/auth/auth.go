@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Locals keys set by Middleware
+const (
+	// LocalsUserID holds the ID of the signed-in user, or of the user an
+	// API key belongs to
+	LocalsUserID = "userId"
+	// LocalsAPIKey holds the *models.APIKey when the request used one
+	LocalsAPIKey = "apiKey"
+)
+
+// DefaultTTL is how long issued tokens stay valid
+const DefaultTTL = 7 * 24 * time.Hour
+
+// ErrInvalidToken is returned for tokens that are malformed, expired or
+// not signed by this server
+var ErrInvalidToken = errors.New("invalid token")
+
+// Issuer signs and verifies HS256 access tokens
+type Issuer struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewIssuer creates an Issuer signing with secret. An empty secret is
+// replaced by a random one, so tokens won't survive a restart.
+func NewIssuer(secret string, ttl time.Duration) (*Issuer, error) {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Issuer{secret: key, ttl: ttl}, nil
+}
+
+// Issue returns a signed token for userID and its expiry time
+func (i *Issuer) Issue(userID primitive.ObjectID) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(i.ttl)
+	claims := jwt.RegisteredClaims{
+		Subject:   userID.Hex(),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(i.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// Verify checks token and returns the user ID it was issued for
+func (i *Issuer) Verify(token string) (primitive.ObjectID, error) {
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		return i.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	id, err := primitive.ObjectIDFromHex(claims.Subject)
+	if err != nil {
+		return primitive.NilObjectID, ErrInvalidToken
+	}
+	return id, nil
+}
+
+// Authenticate reads the bearer token from the Authorization header, or
+// from the "token" query parameter for clients that can't set headers
+// (WebSocket, download links), and returns the user it belongs to.
+func (i *Issuer) Authenticate(c *fiber.Ctx) (primitive.ObjectID, error) {
+	token := credential(c)
+	if token == "" {
+		return primitive.NilObjectID, ErrInvalidToken
+	}
+	return i.Verify(token)
+}
+
+// credential returns the bearer token or API key sent with the request
+func credential(c *fiber.Ctx) string {
+	if h := c.Get(fiber.HeaderAuthorization); h != "" {
+		scheme, rest, ok := strings.Cut(h, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			return ""
+		}
+		return strings.TrimSpace(rest)
+	}
+	if key := c.Get(HeaderAPIKey); key != "" {
+		return key
+	}
+	return c.Query("token")
+}
+
+// UserID returns the user identified by Middleware
+func UserID(c *fiber.Ctx) (primitive.ObjectID, bool) {
+	id, ok := c.Locals(LocalsUserID).(primitive.ObjectID)
+	return id, ok && !id.IsZero()
+}
+
+// HashPassword returns the bcrypt hash of password
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether password matches hash
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"custom-form-builder/models"
+	"custom-form-builder/store"
+)
+
+func TestIssuerVerify(t *testing.T) {
+	issuer, err := NewIssuer("test-secret", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID := primitive.NewObjectID()
+	valid, _, err := issuer.Issue(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, _, err := (&Issuer{secret: []byte("test-secret"), ttl: -time.Minute}).Issue(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherSecret, _, err := (&Issuer{secret: []byte("other-secret"), ttl: time.Hour}).Issue(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := func(method jwt.SigningMethod, key interface{}, claims jwt.Claims) string {
+		t.Helper()
+		token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	exp := jwt.NewNumericDate(time.Now().Add(time.Hour))
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"valid", valid, true},
+		{"expired", expired, false},
+		{"other secret", otherSecret, false},
+		{"HS512", sign(jwt.SigningMethodHS512, []byte("test-secret"), jwt.RegisteredClaims{Subject: userID.Hex(), ExpiresAt: exp}), false},
+		{"alg none", sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, jwt.RegisteredClaims{Subject: userID.Hex(), ExpiresAt: exp}), false},
+		{"no expiry", sign(jwt.SigningMethodHS256, []byte("test-secret"), jwt.RegisteredClaims{Subject: userID.Hex()}), false},
+		{"bad subject", sign(jwt.SigningMethodHS256, []byte("test-secret"), jwt.RegisteredClaims{Subject: "someone", ExpiresAt: exp}), false},
+		{"garbage", "not.a.token", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := issuer.Verify(tt.token)
+			if tt.ok {
+				if err != nil || got != userID {
+					t.Errorf("Verify = %v, %v; want %v", got, err, userID)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Verify error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestCheckPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !CheckPassword(hash, "correct horse") {
+		t.Error("right password refused")
+	}
+	if CheckPassword(hash, "wrong horse") {
+		t.Error("wrong password accepted")
+	}
+}
+
+func TestFormRole(t *testing.T) {
+	ctx := context.Background()
+	db := store.NewMemoryStore()
+	owner, editor, stranger := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	ws := models.Workspace{Name: "Team", CreatedBy: owner, CreatedAt: time.Now()}
+	if err := db.CreateWorkspace(ctx, &ws); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetMember(ctx, &models.WorkspaceMember{WorkspaceID: ws.ID, UserID: editor, Role: models.RoleEditor}); err != nil {
+		t.Fatal(err)
+	}
+	personal := &models.Form{OwnerID: owner}
+	shared := &models.Form{OwnerID: owner, WorkspaceID: ws.ID}
+	ownerless := &models.Form{}
+
+	tests := []struct {
+		name string
+		form *models.Form
+		user primitive.ObjectID
+		want models.Role
+	}{
+		{"owner of a personal form", personal, owner, models.RoleOwner},
+		{"stranger on a personal form", personal, stranger, ""},
+		{"anonymous on a personal form", personal, primitive.NilObjectID, ""},
+		{"workspace member", shared, editor, models.RoleEditor},
+		{"creator outside the workspace", shared, owner, ""},
+		{"ownerless form", ownerless, stranger, ""},
+		{"anonymous on an ownerless form", ownerless, primitive.NilObjectID, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FormRole(ctx, db, tt.form, tt.user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("FormRole = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
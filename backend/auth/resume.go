@@ -0,0 +1,26 @@
+package auth
+
+import (
+	"crypto/rand"
+	"strings"
+)
+
+// ResumeTokenPrefix starts every draft resume token
+const ResumeTokenPrefix = "fbr_"
+
+// GenerateResumeToken returns a new token for resuming a draft response,
+// along with the hash to store
+func GenerateResumeToken() (token, hash string, err error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = ResumeTokenPrefix + strings.ToLower(keyEncoding.EncodeToString(b))
+	return token, HashResumeToken(token), nil
+}
+
+// HashResumeToken returns the hash a resume token is stored and looked up
+// by
+func HashResumeToken(token string) string {
+	return hashSecret(token)
+}
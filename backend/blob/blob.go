@@ -0,0 +1,24 @@
+// Package blob stores uploaded files outside the database.
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned when no blob is stored under a key
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps blobs under slash-separated keys such as
+// "forms/<formId>/<fileId>"
+type Store interface {
+	// Put stores size bytes read from r under key, replacing any blob
+	// already there
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open returns the contents of the blob under key
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob under key; deleting a missing blob is not
+	// an error
+	Delete(ctx context.Context, key string) error
+}
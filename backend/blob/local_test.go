@@ -0,0 +1,82 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStorePath(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewLocalStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"forms/abc/file1", filepath.Join(dir, "forms", "abc", "file1")},
+		{"/forms//abc/./file1", filepath.Join(dir, "forms", "abc", "file1")},
+		{"../secret", ""},
+		{"forms/../../secret", ""},
+		{"forms/a..b", ""},
+		{"", ""},
+		{"/", ""},
+		{".", ""},
+	}
+	for _, tt := range tests {
+		got, err := s.path(tt.key)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("path(%q) = %q, want an error", tt.key, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("path(%q) = %q, %v, want %q", tt.key, got, err, tt.want)
+		}
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewLocalStore(filepath.Join(dir, "blobs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Put(ctx, "../outside", strings.NewReader("x"), 1, ""); err == nil {
+		t.Error("Put outside the store's directory succeeded")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "outside")); !os.IsNotExist(err) {
+		t.Errorf("file written outside the store's directory: %v", err)
+	}
+
+	if err := s.Put(ctx, "forms/f/1", strings.NewReader("hello"), 5, "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	r, err := s.Open(ctx, "forms/f/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(r)
+	r.Close()
+	if string(b) != "hello" {
+		t.Errorf("Open = %q, want %q", b, "hello")
+	}
+
+	if err := s.Delete(ctx, "forms/f/1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Open(ctx, "forms/f/1"); err != ErrNotFound {
+		t.Errorf("Open after Delete = %v, want ErrNotFound", err)
+	}
+	if err := s.Delete(ctx, "forms/f/1"); err != nil {
+		t.Errorf("deleting a missing blob = %v, want nil", err)
+	}
+}
@@ -0,0 +1,325 @@
+// Package conditions evaluates the show/hide rules of form fields.
+package conditions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"custom-form-builder/models"
+)
+
+// Validate checks that every visibility rule in fields is well formed:
+// known operators, conditions on other fields of the form and no rule
+// depending on itself through other fields.
+func Validate(fields []models.Field) error {
+	byID := make(map[string]models.Field, len(fields))
+	for _, f := range fields {
+		byID[f.ID] = f
+	}
+
+	for _, f := range fields {
+		rule := f.Visibility
+		if rule == nil {
+			continue
+		}
+		switch rule.Action {
+		case "", models.ActionShow, models.ActionHide:
+		default:
+			return fmt.Errorf("field %q: unknown visibility action %q", f.Label, rule.Action)
+		}
+		switch rule.Logic {
+		case "", models.LogicAnd, models.LogicOr:
+		default:
+			return fmt.Errorf("field %q: unknown visibility logic %q", f.Label, rule.Logic)
+		}
+		if len(rule.Conditions) == 0 {
+			return fmt.Errorf("field %q: visibility rule has no conditions", f.Label)
+		}
+		for _, cond := range rule.Conditions {
+			if cond.FieldID == f.ID {
+				return fmt.Errorf("field %q: a condition can't refer to its own field", f.Label)
+			}
+			if _, ok := byID[cond.FieldID]; !ok {
+				return fmt.Errorf("field %q: condition refers to unknown field %q", f.Label, cond.FieldID)
+			}
+			switch cond.Operator {
+			case models.OpEquals, models.OpNotEquals, models.OpContains:
+			case models.OpGreaterThan, models.OpLessThan:
+				if _, ok := asFloat(cond.Value); !ok {
+					return fmt.Errorf("field %q: %s needs a numeric value", f.Label, cond.Operator)
+				}
+			default:
+				return fmt.Errorf("field %q: unknown operator %q", f.Label, cond.Operator)
+			}
+		}
+	}
+
+	// Reject cycles, which would make visibility undecidable
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(fields))
+	var visit func(id string) error
+	visit = func(id string) error {
+		switch state[id] {
+		case visiting:
+			return fmt.Errorf("field %q: visibility rules form a cycle", byID[id].Label)
+		case done:
+			return nil
+		}
+		state[id] = visiting
+		if rule := byID[id].Visibility; rule != nil {
+			for _, cond := range rule.Conditions {
+				if err := visit(cond.FieldID); err != nil {
+					return err
+				}
+			}
+		}
+		state[id] = done
+		return nil
+	}
+	for _, f := range fields {
+		if err := visit(f.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Hidden returns the IDs of the fields the rules hide for the given
+// answers, in form order. A field hidden by its rule also counts as
+// unanswered in the conditions of other fields.
+func Hidden(fields []models.Field, answers map[string]interface{}) []string {
+	e := evaluator{
+		fields:  make(map[string]models.Field, len(fields)),
+		answers: answers,
+		visible: make(map[string]bool, len(fields)),
+		seen:    make(map[string]bool, len(fields)),
+	}
+	for _, f := range fields {
+		e.fields[f.ID] = f
+	}
+
+	hidden := []string{}
+	for _, f := range fields {
+		if !e.isVisible(f.ID) {
+			hidden = append(hidden, f.ID)
+		}
+	}
+	return hidden
+}
+
+type evaluator struct {
+	fields  map[string]models.Field
+	answers map[string]interface{}
+	visible map[string]bool
+	seen    map[string]bool
+}
+
+func (e *evaluator) isVisible(id string) bool {
+	if v, ok := e.visible[id]; ok {
+		return v
+	}
+	if e.seen[id] {
+		// Cycle; Validate rejects these, but stay total on stored forms
+		return true
+	}
+	e.seen[id] = true
+
+	visible := true
+	if rule := e.fields[id].Visibility; rule != nil && len(rule.Conditions) > 0 {
+		matched := e.matches(rule)
+		if rule.Action == models.ActionHide {
+			visible = !matched
+		} else {
+			visible = matched
+		}
+	}
+	e.visible[id] = visible
+	return visible
+}
+
+func (e *evaluator) matches(rule *models.VisibilityRule) bool {
+	anyOf := rule.Logic == models.LogicOr
+	for _, cond := range rule.Conditions {
+		ok := e.test(cond)
+		if anyOf && ok {
+			return true
+		}
+		if !anyOf && !ok {
+			return false
+		}
+	}
+	return !anyOf
+}
+
+func (e *evaluator) test(cond models.Condition) bool {
+	field, known := e.fields[cond.FieldID]
+	var answer interface{}
+	if known && e.isVisible(cond.FieldID) {
+		answer = e.answers[cond.FieldID]
+	}
+	values := answerValues(field, answer)
+
+	switch cond.Operator {
+	case models.OpEquals:
+		return equals(values, cond.Value)
+	case models.OpNotEquals:
+		return !equals(values, cond.Value)
+	case models.OpContains:
+		want, ok := asString(cond.Value)
+		if !ok || len(values) == 0 {
+			return false
+		}
+		if field.Type == models.FieldTypeCheckbox {
+			return containsString(values, want)
+		}
+		return strings.Contains(strings.ToLower(values[0]), strings.ToLower(want))
+	case models.OpGreaterThan, models.OpLessThan:
+		if len(values) != 1 {
+			return false
+		}
+		got, ok1 := asFloat(values[0])
+		want, ok2 := asFloat(cond.Value)
+		if !ok1 || !ok2 {
+			return false
+		}
+		if cond.Operator == models.OpGreaterThan {
+			return got > want
+		}
+		return got < want
+	}
+	return false
+}
+
+// equals compares an answer with a condition value. Numbers compare by
+// value; a checkbox answer equals a single option only if that is the one
+// option selected, or a list of options if the same options are selected.
+func equals(values []string, want interface{}) bool {
+	wants := listValues(want)
+	if wants == nil {
+		s, ok := asString(want)
+		if !ok {
+			return len(values) == 0
+		}
+		wants = []string{s}
+	}
+	if len(values) != len(wants) {
+		return false
+	}
+	for _, v := range values {
+		found := false
+		for _, w := range wants {
+			if sameValue(v, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func sameValue(a, b string) bool {
+	if a == b {
+		return true
+	}
+	x, ok1 := asFloat(a)
+	y, ok2 := asFloat(b)
+	return ok1 && ok2 && x == y
+}
+
+// answerValues normalizes an answer to a list of strings. Checkbox answers
+// may arrive as lists or as the comma-joined strings stored with responses,
+// which are split around the field's options; yes/no answers become "true"
+// or "false".
+func answerValues(field models.Field, v interface{}) []string {
+	if field.Type == models.FieldTypeBoolean {
+		if a, ok := models.NewAnswer(field.Type, v); ok && a.Kind == models.AnswerBool {
+			return []string{a.String()}
+		}
+	}
+	if list := listValues(v); list != nil {
+		return list
+	}
+	s, ok := asString(v)
+	if !ok || strings.TrimSpace(s) == "" {
+		return nil
+	}
+	if field.Type != models.FieldTypeCheckbox {
+		return []string{s}
+	}
+	return field.SplitChoices(s)
+}
+
+func listValues(v interface{}) []string {
+	var items []interface{}
+	switch t := v.(type) {
+	case []interface{}:
+		items = t
+	case primitive.A:
+		items = t
+	case []string:
+		return append([]string{}, t...)
+	default:
+		return nil
+	}
+	out := make([]string, 0, len(items))
+	for _, x := range items {
+		if s, ok := asString(x); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func asString(v interface{}) (string, bool) {
+	switch t := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return t, true
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(t), true
+	default:
+		return fmt.Sprint(t), true
+	}
+}
+
+func asFloat(v interface{}) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case float32:
+		return float64(t), true
+	case int:
+		return float64(t), true
+	case int32:
+		return float64(t), true
+	case int64:
+		return float64(t), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func containsString(xs []string, s string) bool {
+	for _, x := range xs {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package conditions
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"custom-form-builder/models"
+)
+
+func rule(action models.RuleAction, logic models.RuleLogic, conds ...models.Condition) *models.VisibilityRule {
+	return &models.VisibilityRule{Action: action, Logic: logic, Conditions: conds}
+}
+
+func cond(fieldID string, op models.ConditionOperator, value interface{}) models.Condition {
+	return models.Condition{FieldID: fieldID, Operator: op, Value: value}
+}
+
+func TestHidden(t *testing.T) {
+	fields := []models.Field{
+		{ID: "pet", Type: models.FieldTypeMultipleChoice, Options: []string{"Cat", "Dog"}},
+		{ID: "age", Type: models.FieldTypeNumber},
+		{ID: "colors", Type: models.FieldTypeCheckbox, Options: []string{"Red, dark", "Blue"}},
+		{ID: "subscribe", Type: models.FieldTypeBoolean},
+		{ID: "dogName", Type: models.FieldTypeText, Visibility: rule("", "", cond("pet", models.OpEquals, "Dog"))},
+		{ID: "breed", Type: models.FieldTypeText, Visibility: rule("", "", cond("dogName", models.OpEquals, "Rex"))},
+		{ID: "senior", Type: models.FieldTypeText, Visibility: rule(models.ActionHide, "", cond("age", models.OpLessThan, 65))},
+		{ID: "dark", Type: models.FieldTypeText, Visibility: rule("", "", cond("colors", models.OpContains, "Red, dark"))},
+		{ID: "either", Type: models.FieldTypeText, Visibility: rule("", models.LogicOr,
+			cond("pet", models.OpEquals, "Cat"), cond("age", models.OpGreaterThan, "30"))},
+		{ID: "email", Type: models.FieldTypeEmail, Visibility: rule("", "", cond("subscribe", models.OpEquals, true))},
+	}
+
+	tests := []struct {
+		name    string
+		answers map[string]interface{}
+		want    []string
+	}{
+		{"nothing answered", map[string]interface{}{},
+			[]string{"dogName", "breed", "dark", "either", "email"}},
+		{"equals shows", map[string]interface{}{"pet": "Dog", "dogName": "Rex"},
+			[]string{"dark", "either", "email"}},
+		{"hidden field counts as unanswered", map[string]interface{}{"pet": "Cat", "dogName": "Rex"},
+			[]string{"dogName", "breed", "dark", "email"}},
+		{"hide action", map[string]interface{}{"age": 40},
+			[]string{"dogName", "breed", "senior", "dark", "email"}},
+		{"numbers given as strings", map[string]interface{}{"age": "70"},
+			[]string{"dogName", "breed", "dark", "email"}},
+		{"checkbox list contains option", map[string]interface{}{"colors": []interface{}{"Red, dark", "Blue"}},
+			[]string{"dogName", "breed", "either", "email"}},
+		{"comma-joined checkbox keeps options with commas", map[string]interface{}{"colors": "Red, dark, Blue"},
+			[]string{"dogName", "breed", "either", "email"}},
+		{"comma-joined checkbox without the option", map[string]interface{}{"colors": "Red, Blue"},
+			[]string{"dogName", "breed", "dark", "either", "email"}},
+		{"yes/no as string", map[string]interface{}{"subscribe": "yes"},
+			[]string{"dogName", "breed", "dark", "either"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Hidden(fields, tt.answers)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Hidden = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEqualsCheckbox(t *testing.T) {
+	field := models.Field{ID: "c", Type: models.FieldTypeCheckbox, Options: []string{"A", "B"}}
+	tests := []struct {
+		name   string
+		answer interface{}
+		value  interface{}
+		want   bool
+	}{
+		{"single pick equals option", []string{"A"}, "A", true},
+		{"two picks don't equal one option", []string{"A", "B"}, "A", false},
+		{"same picks in any order", []string{"B", "A"}, []interface{}{"A", "B"}, true},
+		{"empty equals null", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := []models.Field{field, {ID: "x", Visibility: rule("", "", cond("c", models.OpEquals, tt.value))}}
+			hidden := Hidden(fields, map[string]interface{}{"c": tt.answer})
+			if got := len(hidden) == 0; got != tt.want {
+				t.Errorf("matched = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	base := []models.Field{{ID: "a", Label: "A", Type: models.FieldTypeNumber}}
+	with := func(f models.Field) []models.Field { return append(append([]models.Field{}, base...), f) }
+
+	tests := []struct {
+		name   string
+		fields []models.Field
+		err    string
+	}{
+		{"valid", with(models.Field{ID: "b", Label: "B", Visibility: rule("", "", cond("a", models.OpGreaterThan, 3))}), ""},
+		{"unknown action", with(models.Field{ID: "b", Label: "B", Visibility: rule("toggle", "", cond("a", models.OpEquals, 1))}), "unknown visibility action"},
+		{"unknown logic", with(models.Field{ID: "b", Label: "B", Visibility: rule("", "xor", cond("a", models.OpEquals, 1))}), "unknown visibility logic"},
+		{"no conditions", with(models.Field{ID: "b", Label: "B", Visibility: rule("", "")}), "no conditions"},
+		{"own field", with(models.Field{ID: "b", Label: "B", Visibility: rule("", "", cond("b", models.OpEquals, 1))}), "its own field"},
+		{"unknown field", with(models.Field{ID: "b", Label: "B", Visibility: rule("", "", cond("zz", models.OpEquals, 1))}), "unknown field"},
+		{"unknown operator", with(models.Field{ID: "b", Label: "B", Visibility: rule("", "", cond("a", "like", 1))}), "unknown operator"},
+		{"non-numeric comparison", with(models.Field{ID: "b", Label: "B", Visibility: rule("", "", cond("a", models.OpLessThan, "many"))}), "numeric value"},
+		{"cycle", []models.Field{
+			{ID: "a", Label: "A", Visibility: rule("", "", cond("b", models.OpEquals, 1))},
+			{ID: "b", Label: "B", Visibility: rule("", "", cond("a", models.OpEquals, 1))},
+		}, "cycle"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.fields)
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error = %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
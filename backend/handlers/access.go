@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"custom-form-builder/auth"
+	"custom-form-builder/models"
+	"custom-form-builder/store"
+)
+
+// permissionScopes lists the API key scopes that grant each permission.
+// Keys can't be used for permissions not listed here.
+var permissionScopes = map[models.Permission][]models.Scope{
+	models.PermViewForm:      {models.ScopeFormsRead},
+	models.PermEditForm:      {models.ScopeFormsWrite},
+	models.PermViewAnalytics: {models.ScopeResponsesRead, models.ScopeFormsRead},
+	models.PermReadResponses: {models.ScopeResponsesRead},
+}
+
+// checkKeyScope checks that a request made with an API key was granted a
+// scope covering perm. Requests signed in with a token have every scope.
+func checkKeyScope(c *fiber.Ctx, perm models.Permission) error {
+	key, ok := auth.APIKey(c)
+	if !ok {
+		return nil
+	}
+	scopes := permissionScopes[perm]
+	for _, s := range scopes {
+		if key.HasScope(s) {
+			return nil
+		}
+	}
+	if len(scopes) == 0 {
+		return fiber.NewError(fiber.StatusForbidden, "API keys can't be used for this")
+	}
+	return fiber.NewError(fiber.StatusForbidden, "API key is missing the "+string(scopes[0])+" scope")
+}
+
+// authorizeForm loads the form with the given hex ID and checks that the
+// signed-in user's role on it, and the API key's scopes if one was used,
+// grant perm
+func authorizeForm(c *fiber.Ctx, db store.Store, id string, perm models.Permission) (*models.Form, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid form ID")
+	}
+
+	form, err := db.GetForm(context.Background(), objectID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Form not found")
+		}
+		log.Printf("Error fetching form: %v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch form")
+	}
+
+	userID, ok := auth.UserID(c)
+	if !ok {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Authentication required")
+	}
+	role, err := auth.FormRole(context.Background(), db, form, userID)
+	if err != nil {
+		log.Printf("Error resolving form role: %v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to check access")
+	}
+	if err := checkRole(role, perm, "form"); err != nil {
+		return nil, err
+	}
+	if err := checkKeyScope(c, perm); err != nil {
+		return nil, err
+	}
+	return form, nil
+}
+
+// canAccessForm reports whether the caller, if signed in, has perm on form,
+// with an API key scoped for it if one was used. Public routes use it to
+// show owners what respondents can't see.
+func canAccessForm(c *fiber.Ctx, db store.Store, form *models.Form, perm models.Permission) bool {
+	userID, ok := auth.UserID(c)
+	if !ok || checkKeyScope(c, perm) != nil {
+		return false
+	}
+	role, err := auth.FormRole(context.Background(), db, form, userID)
+	if err != nil {
+		log.Printf("Error resolving form role: %v", err)
+		return false
+	}
+	return checkRole(role, perm, "form") == nil
+}
+
+// authorizeWorkspace checks that the signed-in user's role in the workspace
+// with the given hex ID, and the API key's scopes if one was used, grant
+// perm, and returns that role
+func authorizeWorkspace(c *fiber.Ctx, db store.Store, id string, perm models.Permission) (primitive.ObjectID, models.Role, error) {
+	workspaceID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return workspaceID, "", fiber.NewError(fiber.StatusBadRequest, "Invalid workspace ID")
+	}
+
+	userID, ok := auth.UserID(c)
+	if !ok {
+		return workspaceID, "", fiber.NewError(fiber.StatusUnauthorized, "Authentication required")
+	}
+	role, err := auth.WorkspaceRole(context.Background(), db, workspaceID, userID)
+	if err != nil {
+		log.Printf("Error resolving workspace role: %v", err)
+		return workspaceID, "", fiber.NewError(fiber.StatusInternalServerError, "Failed to check access")
+	}
+	if err := checkRole(role, perm, "workspace"); err != nil {
+		return workspaceID, "", err
+	}
+	if err := checkKeyScope(c, perm); err != nil {
+		return workspaceID, "", err
+	}
+	return workspaceID, role, nil
+}
+
+func checkRole(role models.Role, perm models.Permission, what string) error {
+	if role == "" {
+		return fiber.NewError(fiber.StatusForbidden, "You don't have access to this "+what)
+	}
+	if !role.Can(perm) {
+		return fiber.NewError(fiber.StatusForbidden, "Your role ("+string(role)+") doesn't allow this")
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"custom-form-builder/auth"
+	"custom-form-builder/models"
+	"custom-form-builder/store"
+)
+
+// CreateAPIKey issues a key for the signed-in user. The key is only
+// returned in this response.
+func CreateAPIKey(db store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req models.CreateAPIKeyRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+		name := strings.TrimSpace(req.Name)
+		if name == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Name is required"})
+		}
+		if len(req.Scopes) == 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "At least one scope is required"})
+		}
+		scopes := make([]models.Scope, 0, len(req.Scopes))
+		for _, s := range req.Scopes {
+			if !s.Valid() {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Unknown scope " + string(s)})
+			}
+			if !containsScope(scopes, s) {
+				scopes = append(scopes, s)
+			}
+		}
+
+		plain, prefix, hash, err := auth.GenerateAPIKey()
+		if err != nil {
+			log.Printf("Error generating API key: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create API key"})
+		}
+		userID, _ := auth.UserID(c)
+		key := models.APIKey{
+			UserID:    userID,
+			Name:      name,
+			Prefix:    prefix,
+			KeyHash:   hash,
+			Scopes:    scopes,
+			CreatedAt: time.Now(),
+		}
+		if err := db.CreateAPIKey(context.Background(), &key); err != nil {
+			log.Printf("Error creating API key: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create API key"})
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"key": plain, "apiKey": key})
+	}
+}
+
+// GetAPIKeys lists the signed-in user's keys, without their secrets
+func GetAPIKeys(db store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, _ := auth.UserID(c)
+		keys, err := db.ListAPIKeys(context.Background(), userID)
+		if err != nil {
+			log.Printf("Error fetching API keys: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch API keys"})
+		}
+		return c.JSON(keys)
+	}
+}
+
+// RotateAPIKey replaces a key's secret, keeping its name and scopes. The
+// old secret stops working immediately.
+func RotateAPIKey(db store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key, err := ownAPIKey(c, db)
+		if err != nil {
+			return err
+		}
+		if key.RevokedAt != nil {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "API key has been revoked"})
+		}
+
+		plain, prefix, hash, err := auth.GenerateAPIKey()
+		if err != nil {
+			log.Printf("Error generating API key: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to rotate API key"})
+		}
+		now := time.Now()
+		key.Prefix, key.KeyHash, key.RotatedAt = prefix, hash, &now
+		if err := db.UpdateAPIKey(context.Background(), key); err != nil {
+			log.Printf("Error rotating API key: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to rotate API key"})
+		}
+
+		return c.JSON(fiber.Map{"key": plain, "apiKey": key})
+	}
+}
+
+// RevokeAPIKey permanently disables a key
+func RevokeAPIKey(db store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key, err := ownAPIKey(c, db)
+		if err != nil {
+			return err
+		}
+		if key.RevokedAt == nil {
+			now := time.Now()
+			key.RevokedAt = &now
+			if err := db.UpdateAPIKey(context.Background(), key); err != nil {
+				log.Printf("Error revoking API key: %v", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to revoke API key"})
+			}
+		}
+		return c.JSON(fiber.Map{"message": "API key revoked"})
+	}
+}
+
+// ownAPIKey loads the key named by the :id param if it belongs to the
+// signed-in user
+func ownAPIKey(c *fiber.Ctx, db store.Store) (*models.APIKey, error) {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid API key ID")
+	}
+	key, err := db.GetAPIKey(context.Background(), id)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return nil, fiber.NewError(fiber.StatusNotFound, "API key not found")
+		}
+		log.Printf("Error fetching API key: %v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch API key")
+	}
+	if userID, _ := auth.UserID(c); key.UserID != userID {
+		// Don't reveal other users' keys
+		return nil, fiber.NewError(fiber.StatusNotFound, "API key not found")
+	}
+	return key, nil
+}
+
+func containsScope(scopes []models.Scope, s models.Scope) bool {
+	for _, x := range scopes {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
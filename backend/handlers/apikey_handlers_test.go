@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"custom-form-builder/models"
+)
+
+// createKey issues a key for the test user and returns its secret and ID
+func (s *testServer) createKey(t *testing.T, scopes ...models.Scope) (string, string) {
+	t.Helper()
+	status, out := s.do(t, "POST", "/api/keys", map[string]interface{}{"name": "test", "scopes": scopes}, s.token)
+	if status != fiber.StatusCreated {
+		t.Fatalf("create key: %d %s", status, out)
+	}
+	var res struct {
+		Key    string        `json:"key"`
+		APIKey models.APIKey `json:"apiKey"`
+	}
+	if err := json.Unmarshal(out, &res); err != nil {
+		t.Fatal(err)
+	}
+	return res.Key, res.APIKey.ID.Hex()
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createForm(t, surveyForm())
+	reader, _ := s.createKey(t, models.ScopeResponsesRead)
+	formReader, _ := s.createKey(t, models.ScopeFormsRead)
+	writer, _ := s.createKey(t, models.ScopeFormsWrite)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     interface{}
+		key      string
+		status   int
+		fullView bool
+	}{
+		{"responses key reads the public view", "GET", "/api/forms/" + id, nil, reader, fiber.StatusOK, false},
+		{"forms:read key reads the full form", "GET", "/api/forms/" + id, nil, formReader, fiber.StatusOK, true},
+		{"responses key reads analytics", "GET", "/api/analytics/" + id, nil, reader, fiber.StatusOK, false},
+		{"forms:read key can't read analytics", "GET", "/api/analytics/" + id, nil, formReader, fiber.StatusForbidden, false},
+		{"responses key can't edit", "PUT", "/api/forms/" + id + "/strict-mode", map[string]bool{"lenient": true}, reader, fiber.StatusForbidden, false},
+		{"forms:write key edits", "PUT", "/api/forms/" + id + "/strict-mode", map[string]bool{"lenient": true}, writer, fiber.StatusOK, true},
+		{"keys can't manage keys", "POST", "/api/keys", map[string]interface{}{"name": "more", "scopes": []string{"forms:read"}}, writer, fiber.StatusForbidden, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, out := s.do(t, tt.method, tt.path, tt.body, tt.key)
+			if status != tt.status {
+				t.Fatalf("status = %d, want %d: %s", status, tt.status, out)
+			}
+			if status == fiber.StatusOK && strings.Contains(tt.path, "/forms/") {
+				if full := strings.Contains(string(out), `"id":"`+id); full != tt.fullView {
+					t.Errorf("full view = %v, want %v: %s", full, tt.fullView, out)
+				}
+			}
+		})
+	}
+}
+
+func TestRotateAPIKey(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createForm(t, surveyForm())
+	old, keyID := s.createKey(t, models.ScopeResponsesRead)
+
+	status, out := s.do(t, "POST", "/api/keys/"+keyID+"/rotate", nil, s.token)
+	if status != fiber.StatusOK {
+		t.Fatalf("rotate: %d %s", status, out)
+	}
+	var res struct {
+		Key string `json:"key"`
+	}
+	if err := json.Unmarshal(out, &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Key == "" || res.Key == old {
+		t.Fatalf("rotate returned %q", res.Key)
+	}
+	if status, _ := s.do(t, "GET", "/api/analytics/"+id, nil, old); status != fiber.StatusUnauthorized {
+		t.Errorf("old key: status = %d, want %d", status, fiber.StatusUnauthorized)
+	}
+	if status, _ := s.do(t, "GET", "/api/analytics/"+id, nil, res.Key); status != fiber.StatusOK {
+		t.Errorf("new key: status = %d, want %d", status, fiber.StatusOK)
+	}
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createForm(t, surveyForm())
+	key, keyID := s.createKey(t, models.ScopeResponsesRead)
+
+	if status, out := s.do(t, "DELETE", "/api/keys/"+keyID, nil, s.token); status != fiber.StatusOK {
+		t.Fatalf("revoke: %d %s", status, out)
+	}
+	if status, _ := s.do(t, "GET", "/api/analytics/"+id, nil, key); status != fiber.StatusUnauthorized {
+		t.Errorf("revoked key: status = %d, want %d", status, fiber.StatusUnauthorized)
+	}
+	if status, _ := s.do(t, "POST", "/api/keys/"+keyID+"/rotate", nil, s.token); status != fiber.StatusConflict {
+		t.Errorf("rotate revoked key: status = %d, want %d", status, fiber.StatusConflict)
+	}
+}
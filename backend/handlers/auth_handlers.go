@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"custom-form-builder/auth"
+	"custom-form-builder/models"
+	"custom-form-builder/store"
+	"custom-form-builder/validation"
+)
+
+const minPasswordLength = 8
+
+// Register creates an account and returns an access token for it
+func Register(db store.Store, issuer *auth.Issuer) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req models.RegisterRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+
+		email := normalizeEmail(req.Email)
+		if !validation.IsEmail(email) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "A valid email is required"})
+		}
+		if len(req.Password) < minPasswordLength {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Password must be at least 8 characters"})
+		}
+
+		hash, err := auth.HashPassword(req.Password)
+		if err != nil {
+			log.Printf("Register: hashing password: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create account"})
+		}
+
+		user := models.User{
+			Email:        email,
+			Name:         strings.TrimSpace(req.Name),
+			PasswordHash: hash,
+			CreatedAt:    time.Now(),
+		}
+		if err := db.CreateUser(context.Background(), &user); err != nil {
+			if err == store.ErrDuplicate {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "An account with this email already exists"})
+			}
+			log.Printf("Register: creating user: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create account"})
+		}
+
+		return issueToken(c, issuer, &user, fiber.StatusCreated)
+	}
+}
+
+// Login exchanges email and password for an access token
+func Login(db store.Store, issuer *auth.Issuer) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req models.LoginRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+
+		user, err := db.GetUserByEmail(context.Background(), normalizeEmail(req.Email))
+		if err != nil && err != store.ErrNotFound {
+			log.Printf("Login: fetching user: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to sign in"})
+		}
+		if user == nil || !auth.CheckPassword(user.PasswordHash, req.Password) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid email or password"})
+		}
+
+		return issueToken(c, issuer, user, fiber.StatusOK)
+	}
+}
+
+// Me returns the signed-in user
+func Me(db store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, _ := auth.UserID(c)
+		user, err := db.GetUser(context.Background(), userID)
+		if err != nil {
+			if err == store.ErrNotFound {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Account no longer exists"})
+			}
+			log.Printf("Me: fetching user: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch account"})
+		}
+		return c.JSON(user)
+	}
+}
+
+func issueToken(c *fiber.Ctx, issuer *auth.Issuer, user *models.User, status int) error {
+	token, expiresAt, err := issuer.Issue(user.ID)
+	if err != nil {
+		log.Printf("Error issuing token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to issue token"})
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"token":     token,
+		"expiresAt": expiresAt,
+		"user":      user,
+	})
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
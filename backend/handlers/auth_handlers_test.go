@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// register creates an account and returns its access token
+func (s *testServer) register(t *testing.T, email string) string {
+	t.Helper()
+	status, out := s.do(t, "POST", "/api/auth/register", map[string]string{"email": email, "password": "password123"}, "")
+	if status != fiber.StatusCreated {
+		t.Fatalf("register: %d %s", status, out)
+	}
+	var res struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(out, &res); err != nil {
+		t.Fatal(err)
+	}
+	return res.Token
+}
+
+func TestRegister(t *testing.T) {
+	s := newTestServer(t)
+	tests := []struct {
+		name   string
+		body   map[string]string
+		status int
+	}{
+		{"valid", map[string]string{"email": "Ada@Example.com ", "password": "password123", "name": "Ada"}, fiber.StatusCreated},
+		{"same email again", map[string]string{"email": "ada@example.com", "password": "password456"}, fiber.StatusConflict},
+		{"existing user", map[string]string{"email": "owner@example.com", "password": "password123"}, fiber.StatusConflict},
+		{"bad email", map[string]string{"email": "not-an-email", "password": "password123"}, fiber.StatusBadRequest},
+		{"short password", map[string]string{"email": "bob@example.com", "password": "short"}, fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, out := s.do(t, "POST", "/api/auth/register", tt.body, "")
+			if status != tt.status {
+				t.Fatalf("status = %d, want %d: %s", status, tt.status, out)
+			}
+			if status == fiber.StatusCreated && (!strings.Contains(string(out), `"token":"`) || strings.Contains(string(out), "password")) {
+				t.Errorf("unexpected body: %s", out)
+			}
+		})
+	}
+}
+
+func TestLogin(t *testing.T) {
+	s := newTestServer(t)
+	s.register(t, "ada@example.com")
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		status   int
+	}{
+		{"valid", "ada@example.com", "password123", fiber.StatusOK},
+		{"email in another case", " ADA@example.com", "password123", fiber.StatusOK},
+		{"wrong password", "ada@example.com", "password124", fiber.StatusUnauthorized},
+		{"unknown email", "bob@example.com", "password123", fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, out := s.do(t, "POST", "/api/auth/login", map[string]string{"email": tt.email, "password": tt.password}, "")
+			if status != tt.status {
+				t.Fatalf("status = %d, want %d: %s", status, tt.status, out)
+			}
+			if status != fiber.StatusOK {
+				return
+			}
+			var res struct {
+				Token string `json:"token"`
+			}
+			if err := json.Unmarshal(out, &res); err != nil {
+				t.Fatal(err)
+			}
+			status, out = s.do(t, "GET", "/api/auth/me", nil, res.Token)
+			if status != fiber.StatusOK || !strings.Contains(string(out), `"email":"ada@example.com"`) {
+				t.Errorf("me: %d %s", status, out)
+			}
+		})
+	}
+}
+
+func TestMe(t *testing.T) {
+	s := newTestServer(t)
+	tests := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{"signed in", s.token, fiber.StatusOK},
+		{"signed out", "", fiber.StatusUnauthorized},
+		{"bad token", "not.a.token", fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, out := s.do(t, "GET", "/api/auth/me", nil, tt.token); status != tt.status {
+				t.Errorf("status = %d, want %d: %s", status, tt.status, out)
+			}
+		})
+	}
+}
+
+func TestFormOwnership(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createForm(t, surveyForm())
+	s.submit(t, id, map[string]interface{}{"name": "Ada"})
+	other := s.register(t, "mallory@example.com")
+
+	tests := []struct {
+		name   string
+		path   string
+		token  string
+		status int
+	}{
+		{"owner reads responses", "/api/responses/" + id, s.token, fiber.StatusOK},
+		{"owner reads analytics", "/api/analytics/" + id, s.token, fiber.StatusOK},
+		{"owner exports CSV", "/api/responses/" + id + "/csv", s.token, fiber.StatusOK},
+		{"non-owner reads responses", "/api/responses/" + id, other, fiber.StatusForbidden},
+		{"non-owner reads analytics", "/api/analytics/" + id, other, fiber.StatusForbidden},
+		{"non-owner exports CSV", "/api/responses/" + id + "/csv", other, fiber.StatusForbidden},
+		{"anonymous reads responses", "/api/responses/" + id, "", fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, out := s.do(t, "GET", tt.path, nil, tt.token); status != tt.status {
+				t.Errorf("status = %d, want %d: %s", status, tt.status, out)
+			}
+		})
+	}
+
+	// Respondents don't need an account
+	status, out := s.do(t, "POST", "/api/responses", map[string]interface{}{"formId": id, "responses": map[string]interface{}{"name": "Bob"}}, "")
+	if status != fiber.StatusCreated {
+		t.Errorf("anonymous submit: %d %s", status, out)
+	}
+	if got := s.countResponses(t, id); got != 2 {
+		t.Errorf("%d responses stored, want 2", got)
+	}
+}
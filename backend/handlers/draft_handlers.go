@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"mime/multipart"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"custom-form-builder/analytics"
+	"custom-form-builder/auth"
+	"custom-form-builder/blob"
+	"custom-form-builder/models"
+	"custom-form-builder/store"
+	"custom-form-builder/validation"
+	"custom-form-builder/websocket"
+)
+
+// DraftTTL is how long an untouched draft can still be resumed
+const DraftTTL = 30 * 24 * time.Hour
+
+// CreateDraft saves the answers given so far and returns the resume token.
+// Required fields and formats aren't checked until the draft is submitted;
+// answers to unknown fields are refused unless the form is lenient.
+func CreateDraft(db store.Store, tracker *analytics.Tracker) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req models.CreateDraftRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+		var form *models.Form
+		var err error
+		switch {
+		case req.Link != "":
+			form, err = formByKey(db, req.Link)
+		case req.FormID != "":
+			form, err = formByID(c, db, req.FormID)
+		default:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "formId or link is required"})
+		}
+		if err != nil {
+			return err
+		}
+		if err := checkAccepting(db, form); err != nil {
+			return err
+		}
+		if req.PageID != "" && form.PageIndex(req.PageID) < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Unknown page"})
+		}
+		if err := checkDraftAnswers(form, req.Responses); err != nil {
+			return err
+		}
+
+		token, hash, err := auth.GenerateResumeToken()
+		if err != nil {
+			log.Printf("CreateDraft: generating token: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create draft"})
+		}
+		now := time.Now()
+		draft := models.Draft{
+			FormID:    form.ID,
+			TokenHash: hash,
+			Responses: mergeDraftAnswers(form, nil, req.Responses),
+			PageID:    req.PageID,
+			CreatedAt: now,
+			UpdatedAt: now,
+			ExpiresAt: now.Add(DraftTTL),
+		}
+		if err := db.CreateDraft(context.Background(), &draft); err != nil {
+			log.Printf("CreateDraft: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create draft"})
+		}
+		if err := tracker.RecordDraftStart(context.Background(), form); err != nil {
+			log.Printf("CreateDraft: recording start: %v", err)
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"token": token,
+			"draft": draft,
+		})
+	}
+}
+
+// GetDraft returns the draft a resume token belongs to
+func GetDraft(db store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		draft, err := findDraft(db, c.Params("token"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(draft)
+	}
+}
+
+// UpdateDraft merges more answers into a draft and extends its expiry.
+// Drafts of forms that have stopped accepting responses can't be changed.
+func UpdateDraft(db store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		draft, err := findDraft(db, c.Params("token"))
+		if err != nil {
+			return err
+		}
+		var req models.UpdateDraftRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+
+		form, err := draftForm(db, draft)
+		if err != nil {
+			return err
+		}
+		if err := checkAccepting(db, form); err != nil {
+			return err
+		}
+		if req.PageID != "" {
+			if form.PageIndex(req.PageID) < 0 {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Unknown page"})
+			}
+			draft.PageID = req.PageID
+		}
+		if err := checkDraftAnswers(form, req.Responses); err != nil {
+			return err
+		}
+		draft.Responses = mergeDraftAnswers(form, draft.Responses, req.Responses)
+		draft.UpdatedAt = time.Now()
+		draft.ExpiresAt = draft.UpdatedAt.Add(DraftTTL)
+
+		if err := db.UpdateDraft(context.Background(), draft); err != nil {
+			log.Printf("UpdateDraft: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save draft"})
+		}
+		return c.JSON(draft)
+	}
+}
+
+// SubmitDraft finalizes a draft: its answers, plus any sent with the
+// request, go through the same validation as a direct submission. Drafts
+// don't keep files, so uploads come with this request. The draft is
+// claimed before the response is stored, so a draft finalized twice at
+// once gives one response and a 404.
+func SubmitDraft(db store.Store, hub *websocket.Hub, tracker *analytics.Tracker, blobs blob.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		draft, err := findDraft(db, c.Params("token"))
+		if err != nil {
+			return err
+		}
+		var req models.UpdateDraftRequest
+		var files map[string][]*multipart.FileHeader
+		if len(c.Body()) > 0 {
+			if files, err = parseSubmission(c, &req); err != nil {
+				return err
+			}
+		}
+
+		form, err := draftForm(db, draft)
+		if err != nil {
+			return err
+		}
+		answers := mergeDraftAnswers(form, draft.Responses, req.Responses)
+		pending, err := attachFiles(form, answers, files)
+		if err != nil {
+			return err
+		}
+		doc, err := submit(hub, tracker, blobs, form, answers, pending, &draftClaim{db: db, draft: draft})
+		if err != nil {
+			return err
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"message": "Response submitted successfully",
+			"id":      doc.ID.Hex(),
+		})
+	}
+}
+
+// DeleteDraft discards a draft
+func DeleteDraft(db store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		draft, err := findDraft(db, c.Params("token"))
+		if err != nil {
+			return err
+		}
+		if err := db.DeleteDraft(context.Background(), draft.ID); err != nil && err != store.ErrNotFound {
+			log.Printf("DeleteDraft: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete draft"})
+		}
+		return c.JSON(fiber.Map{"message": "Draft deleted"})
+	}
+}
+
+// draftClaim is the draft a submission completes. Claiming removes it from
+// the store; only one request can do that, the others get a 404.
+type draftClaim struct {
+	db    store.DraftStore
+	draft *models.Draft
+}
+
+func (d *draftClaim) id() primitive.ObjectID {
+	if d == nil {
+		return primitive.NilObjectID
+	}
+	return d.draft.ID
+}
+
+func (d *draftClaim) claim() error {
+	if d == nil {
+		return nil
+	}
+	if err := d.db.DeleteDraft(context.Background(), d.draft.ID); err != nil {
+		if err == store.ErrNotFound {
+			return fiber.NewError(fiber.StatusNotFound, "Draft not found")
+		}
+		log.Printf("Error claiming draft: %v", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to submit draft")
+	}
+	return nil
+}
+
+// release puts a claimed draft back after its response couldn't be saved,
+// so the respondent can try again
+func (d *draftClaim) release() {
+	if d == nil {
+		return
+	}
+	if err := d.db.CreateDraft(context.Background(), d.draft); err != nil {
+		log.Printf("Error restoring draft: %v", err)
+	}
+}
+
+// findDraft looks up an unexpired draft by its resume token
+func findDraft(db store.Store, token string) (*models.Draft, error) {
+	draft, err := db.GetDraftByTokenHash(context.Background(), auth.HashResumeToken(token))
+	if err != nil {
+		if err == store.ErrNotFound {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Draft not found")
+		}
+		log.Printf("Error fetching draft: %v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch draft")
+	}
+	if time.Now().After(draft.ExpiresAt) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Draft not found")
+	}
+	return draft, nil
+}
+
+func draftForm(db store.Store, draft *models.Draft) (*models.Form, error) {
+	form, err := db.GetForm(context.Background(), draft.FormID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Form not found")
+		}
+		log.Printf("Error fetching form: %v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch form")
+	}
+	return form, nil
+}
+
+// checkDraftAnswers refuses answers to fields the form doesn't have, unless
+// the form is lenient
+func checkDraftAnswers(form *models.Form, answers map[string]interface{}) error {
+	if form.Lenient {
+		return nil
+	}
+	if errs := validation.Unknown(form.Fields, answers); len(errs) > 0 {
+		return validationError(errs)
+	}
+	return nil
+}
+
+// mergeDraftAnswers applies update on top of a copy of answers. A null
+// answer removes the field. Saved answers to fields the form no longer has
+// are dropped; unknown fields in update are dropped only for lenient
+// forms, so strict validation still reports them.
+func mergeDraftAnswers(form *models.Form, answers, update map[string]interface{}) map[string]interface{} {
+	known := make(map[string]bool, len(form.Fields))
+	for _, f := range form.Fields {
+		known[f.ID] = true
+	}
+	out := make(map[string]interface{}, len(answers)+len(update))
+	for k, v := range answers {
+		if known[k] {
+			out[k] = v
+		}
+	}
+	for k, v := range update {
+		switch {
+		case !known[k] && form.Lenient:
+		case v == nil:
+			delete(out, k)
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"custom-form-builder/models"
+)
+
+// createDraft starts a draft of formID with answers and returns its
+// resume token
+func (s *testServer) createDraft(t *testing.T, formID string, answers map[string]interface{}) string {
+	t.Helper()
+	status, out := s.do(t, "POST", "/api/drafts", map[string]interface{}{"formId": formID, "responses": answers}, "")
+	if status != fiber.StatusCreated {
+		t.Fatalf("create draft: %d %s", status, out)
+	}
+	var res struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(out, &res); err != nil {
+		t.Fatal(err)
+	}
+	return res.Token
+}
+
+func (s *testServer) countResponses(t *testing.T, formID string) int {
+	t.Helper()
+	id, err := primitive.ObjectIDFromHex(formID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responses, err := s.db.ListResponses(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(responses)
+}
+
+func TestDraftLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createForm(t, surveyForm())
+	token := s.createDraft(t, id, map[string]interface{}{"name": "Ada", "age": 30})
+
+	steps := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		status int
+		want   []string
+		absent []string
+	}{
+		{"fetch", "GET", "/api/drafts/" + token, nil, fiber.StatusOK, []string{`"name":"Ada"`, `"age":30`}, nil},
+		{"patch", "PATCH", "/api/drafts/" + token, map[string]interface{}{"responses": map[string]interface{}{"email": "ada@example.com", "age": nil}}, fiber.StatusOK,
+			[]string{`"name":"Ada"`, `"email":"ada@example.com"`}, []string{`"age"`}},
+		{"fetch patched", "GET", "/api/drafts/" + token, nil, fiber.StatusOK, []string{`"email":"ada@example.com"`}, []string{`"age"`}},
+		{"unknown token", "GET", "/api/drafts/nope", nil, fiber.StatusNotFound, nil, nil},
+		{"submit", "POST", "/api/drafts/" + token + "/submit", map[string]interface{}{"responses": map[string]interface{}{"score": 4}}, fiber.StatusCreated, nil, nil},
+		{"fetch submitted", "GET", "/api/drafts/" + token, nil, fiber.StatusNotFound, nil, nil},
+		{"submit again", "POST", "/api/drafts/" + token + "/submit", nil, fiber.StatusNotFound, nil, nil},
+	}
+	for _, st := range steps {
+		status, out := s.do(t, st.method, st.path, st.body, "")
+		if status != st.status {
+			t.Fatalf("%s: status = %d, want %d: %s", st.name, status, st.status, out)
+		}
+		for _, w := range st.want {
+			if !strings.Contains(string(out), w) {
+				t.Errorf("%s: missing %s in %s", st.name, w, out)
+			}
+		}
+		for _, a := range st.absent {
+			if strings.Contains(string(out), a) {
+				t.Errorf("%s: unexpected %s in %s", st.name, a, out)
+			}
+		}
+	}
+	if got := s.countResponses(t, id); got != 1 {
+		t.Errorf("%d responses stored, want 1", got)
+	}
+}
+
+func TestDraftUnknownFields(t *testing.T) {
+	s := newTestServer(t)
+	strict := s.createForm(t, surveyForm())
+	lenient := s.createForm(t, surveyForm())
+	if status, out := s.do(t, "PUT", "/api/forms/"+lenient+"/strict-mode", map[string]bool{"lenient": true}, s.token); status != fiber.StatusOK {
+		t.Fatalf("set lenient: %d %s", status, out)
+	}
+	unknown := map[string]interface{}{"name": "Ada", "nickname": "A"}
+
+	tests := []struct {
+		name   string
+		formID string
+		step   string
+		status int
+	}{
+		{"strict create", strict, "create", fiber.StatusBadRequest},
+		{"strict patch", strict, "patch", fiber.StatusBadRequest},
+		{"strict submit", strict, "submit", fiber.StatusBadRequest},
+		{"lenient create", lenient, "create", fiber.StatusCreated},
+		{"lenient patch", lenient, "patch", fiber.StatusOK},
+		{"lenient submit", lenient, "submit", fiber.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var status int
+			var out []byte
+			switch tt.step {
+			case "create":
+				status, out = s.do(t, "POST", "/api/drafts", map[string]interface{}{"formId": tt.formID, "responses": unknown}, "")
+			case "patch":
+				token := s.createDraft(t, tt.formID, nil)
+				status, out = s.do(t, "PATCH", "/api/drafts/"+token, map[string]interface{}{"responses": unknown}, "")
+			case "submit":
+				token := s.createDraft(t, tt.formID, nil)
+				status, out = s.do(t, "POST", "/api/drafts/"+token+"/submit", map[string]interface{}{"responses": unknown}, "")
+			}
+			if status != tt.status {
+				t.Fatalf("status = %d, want %d: %s", status, tt.status, out)
+			}
+			if tt.status == fiber.StatusBadRequest && !strings.Contains(string(out), `"nickname"`) {
+				t.Errorf("error doesn't name the unknown field: %s", out)
+			}
+		})
+	}
+}
+
+func TestUpdateDraftClosedForm(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createForm(t, surveyForm())
+	token := s.createDraft(t, id, map[string]interface{}{"name": "Ada"})
+	if status, out := s.do(t, "PUT", "/api/forms/"+id+"/status", map[string]interface{}{"status": "closed"}, s.token); status != fiber.StatusOK {
+		t.Fatalf("close form: %d %s", status, out)
+	}
+
+	status, out := s.do(t, "PATCH", "/api/drafts/"+token, map[string]interface{}{"responses": map[string]interface{}{"score": 5}}, "")
+	if status != fiber.StatusForbidden || !strings.Contains(string(out), models.CodeFormClosed) {
+		t.Errorf("got %d %s, want 403 %s", status, out, models.CodeFormClosed)
+	}
+}
+
+func TestSubmitDraftConcurrently(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createForm(t, surveyForm())
+	token := s.createDraft(t, id, map[string]interface{}{"name": "Ada"})
+
+	const n = 8
+	statuses := make([]int, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := s.app.Test(httptest.NewRequest("POST", "/api/drafts/"+token+"/submit", nil), -1)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+			statuses[i] = resp.StatusCode
+		}(i)
+	}
+	wg.Wait()
+
+	created := 0
+	for _, status := range statuses {
+		switch status {
+		case fiber.StatusCreated:
+			created++
+		case fiber.StatusNotFound:
+		default:
+			t.Errorf("status = %d", status)
+		}
+	}
+	if created != 1 {
+		t.Errorf("%d submissions succeeded, want 1", created)
+	}
+	if got := s.countResponses(t, id); got != 1 {
+		t.Errorf("%d responses stored, want 1", got)
+	}
+}
+
+func TestSubmitDraftKeepsInvalidDraft(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createForm(t, surveyForm())
+	token := s.createDraft(t, id, map[string]interface{}{"email": "ada@example.com"})
+
+	if status, out := s.do(t, "POST", "/api/drafts/"+token+"/submit", nil, ""); status != fiber.StatusBadRequest {
+		t.Fatalf("submit without the required name: %d %s", status, out)
+	}
+	if status, out := s.do(t, "POST", "/api/drafts/"+token+"/submit", map[string]interface{}{"responses": map[string]interface{}{"name": "Ada"}}, ""); status != fiber.StatusCreated {
+		t.Fatalf("submit with the name: %d %s", status, out)
+	}
+	if status, _ := s.do(t, "GET", "/api/drafts/"+token, nil, ""); status != fiber.StatusNotFound {
+		t.Errorf("submitted draft: status = %d, want %d", status, fiber.StatusNotFound)
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"custom-form-builder/models"
+)
+
+// CodedError is a client error with a machine-readable code. Handlers and
+// their helpers return it like a *fiber.Error; the app's error handler
+// renders it with Body.
+type CodedError struct {
+	Status  int
+	Code    string
+	Message string
+	// Errors lists every invalid answer when Code is
+	// models.CodeValidationFailed
+	Errors []models.FieldError
+}
+
+func (e *CodedError) Error() string { return e.Message }
+
+// Body is the JSON the error is rendered as: { "error", "code" }, plus
+// "errors" and the first invalid "field" for validation failures
+func (e *CodedError) Body() fiber.Map {
+	out := fiber.Map{"error": e.Message, "code": e.Code}
+	if len(e.Errors) > 0 {
+		out["errors"] = e.Errors
+		out["field"] = e.Errors[0].Field
+	}
+	return out
+}
+
+// ErrorHandler is the app's fiber error handler. It renders a CodedError
+// with its Body and any other error as { "error" }, with the status of a
+// *fiber.Error or 500.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	if e, ok := err.(*CodedError); ok {
+		return c.Status(e.Status).JSON(e.Body())
+	}
+	code := fiber.StatusInternalServerError
+	if e, ok := err.(*fiber.Error); ok {
+		code = e.Code
+	}
+	return c.Status(code).JSON(fiber.Map{"error": err.Error()})
+}
+
+// validationError reports errs, which must not be empty, as one
+// validation_failed error
+func validationError(errs []models.FieldError) error {
+	msg := errs[0].Message
+	if len(errs) > 1 {
+		msg = "Some answers are invalid"
+	}
+	return &CodedError{
+		Status:  fiber.StatusBadRequest,
+		Code:    models.CodeValidationFailed,
+		Message: msg,
+		Errors:  errs,
+	}
+}
@@ -4,99 +4,153 @@ import (
 	"context"
 	"encoding/csv"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 
 	"custom-form-builder/models"
+	"custom-form-builder/revisions"
+	"custom-form-builder/store"
 )
 
-func ExportResponsesCSV(client *mongo.Client) fiber.Handler {
+func ExportResponsesCSV(db store.Store) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		formID := c.Params("formId")
-		objectID, err := primitive.ObjectIDFromHex(formID)
-		if err != nil {
-			return c.Status(fiber.StatusBadRequest).SendString("Invalid form ID")
-		}
-
-		formsCol := client.Database("formbuilder").Collection("forms")
-		respCol := client.Database("formbuilder").Collection("responses")
-
 		// Load form for field metadata
-		var form models.Form
-		if err := formsCol.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&form); err != nil {
-			return c.Status(fiber.StatusNotFound).SendString("Form not found")
+		form, err := authorizeForm(c, db, c.Params("formId"), models.PermReadResponses)
+		if err != nil {
+			if e, ok := err.(*fiber.Error); ok {
+				return c.Status(e.Code).SendString(e.Message)
+			}
+			return err
 		}
+		objectID := form.ID
 
-		// Fetch responses
-		cur, err := respCol.Find(context.Background(), bson.M{"formId": objectID})
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).SendString("Failed to fetch responses")
+		// ?revision=N exports the responses to that revision with its
+		// fields; ?revision=all exports everything with the fields of all
+		// revisions. Both add a Revision column.
+		columns := form.Fields
+		include := func(models.FormResponse) bool { return true }
+		scope := c.Query("revision")
+		if scope != "" {
+			view, pick, err := revisionScope(db, form, scope)
+			if err != nil {
+				if e, ok := err.(*fiber.Error); ok {
+					return c.Status(e.Code).SendString(e.Message)
+				}
+				return err
+			}
+			columns = view.Fields
+			include = func(doc models.FormResponse) bool { return pick(doc) != nil }
 		}
-		defer cur.Close(context.Background())
 
 		// Build CSV in-memory
 		var b strings.Builder
 		w := csv.NewWriter(&b)
 
-		// Header: SubmittedAt + field labels in order
+		// Header: SubmittedAt + field labels in order. A field that
+		// allows "Other" gets a second column for the free-text answers,
+		// a matrix field a column per row, labelled "Field [Row]", and a
+		// ranking a column per option holding its rank.
 		header := []string{"SubmittedAt"}
-		for _, f := range form.Fields {
+		if scope != "" {
+			header = append(header, "Revision")
+		}
+		for _, f := range columns {
+			if f.Type == models.FieldTypeMatrix {
+				for _, r := range f.Rows {
+					header = append(header, fmt.Sprintf("%s [%s]", f.Label, r))
+				}
+				continue
+			}
+			if f.Type == models.FieldTypeRanking {
+				for _, o := range f.Options {
+					header = append(header, fmt.Sprintf("%s [%s]", f.Label, o))
+				}
+				continue
+			}
 			header = append(header, f.Label)
+			if f.TakesOther() {
+				header = append(header, f.Label+" (Other)")
+			}
 		}
 		if err := w.Write(header); err != nil {
 			return c.Status(fiber.StatusInternalServerError).SendString("Failed to write CSV header")
 		}
 
 		// Rows
-		for cur.Next(context.Background()) {
-			var doc models.FormResponse
-			if err := cur.Decode(&doc); err != nil {
-				continue
+		err = db.ForEachResponse(context.Background(), objectID, func(doc models.FormResponse) error {
+			if !include(doc) {
+				return nil
 			}
 			row := []string{doc.SubmittedAt.Format(time.RFC3339)}
-			for _, f := range form.Fields {
-				val := formatCSVValue(doc.Responses[f.ID])
-				row = append(row, val)
+			if scope != "" {
+				row = append(row, strconv.Itoa(revisions.Of(doc)))
 			}
-			if err := w.Write(row); err != nil {
-				return c.Status(fiber.StatusInternalServerError).SendString("Failed to write CSV row")
+			for _, f := range columns {
+				if f.Type == models.FieldTypeMatrix {
+					a := doc.Responses[f.ID]
+					for _, r := range f.Rows {
+						row = append(row, a.Row(r))
+					}
+					continue
+				}
+				if f.Type == models.FieldTypeRanking {
+					row = append(row, ranks(f, doc.Responses[f.ID])...)
+					continue
+				}
+				row = append(row, csvValue(f, doc.Responses[f.ID]))
+				if f.TakesOther() {
+					row = append(row, doc.Other[f.ID])
+				}
 			}
+			return w.Write(row)
+		})
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString("Failed to write CSV rows")
 		}
 		w.Flush()
 		if err := w.Error(); err != nil {
 			return c.Status(fiber.StatusInternalServerError).SendString("Failed to finalize CSV")
 		}
 
-		filename := fmt.Sprintf("form_%s_responses.csv", formID)
+		filename := fmt.Sprintf("form_%s_responses.csv", objectID.Hex())
 		c.Set("Content-Type", "text/csv")
 		c.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
 		return c.SendString(b.String())
 	}
 }
 
-func formatCSVValue(v interface{}) string {
-	if v == nil {
-		return ""
-	}
-	switch t := v.(type) {
-	case string:
-		return t
-	case []string:
-		return strings.Join(t, "; ")
-	case []interface{}:
-		out := make([]string, 0, len(t))
-		for _, x := range t {
-			if s, ok := x.(string); ok {
-				out = append(out, s)
+// ranks gives the rank of each of a ranking field's options in answer a,
+// 1 being first; blank for options left unranked
+func ranks(f models.Field, a models.Answer) []string {
+	out := make([]string, len(f.Options))
+	for i, o := range f.Options {
+		for r, picked := range a.Choices {
+			if picked == o {
+				out[i] = strconv.Itoa(r + 1)
+				break
 			}
 		}
-		return strings.Join(out, "; ")
-	default:
-		return fmt.Sprintf("%v", t)
 	}
+	return out
+}
+
+// csvValue formats an answer for the export. Datetimes are written in the
+// field's timezone, as "YYYY-MM-DD HH:MM:SS", which spreadsheets read as a
+// date and time, and yes/no answers as "Yes" or "No".
+func csvValue(f models.Field, a models.Answer) string {
+	if a.Kind == models.AnswerBool {
+		if a.Bool {
+			return "Yes"
+		}
+		return "No"
+	}
+	if f.Type == models.FieldTypeDateTime && a.Kind == models.AnswerText {
+		if t, err := f.ParseTemporal(a.Text); err == nil {
+			return t.In(f.Location()).Format("2006-01-02 15:04:05")
+		}
+	}
+	return a.String()
 }
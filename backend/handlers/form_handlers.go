@@ -2,20 +2,26 @@ package handlers
 
 import (
 	"context"
+	"fmt"
 	"log"
-		"time"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 
+	"custom-form-builder/auth"
+	"custom-form-builder/blob"
+	"custom-form-builder/conditions"
 	"custom-form-builder/models"
+	"custom-form-builder/revisions"
+	"custom-form-builder/store"
+	"custom-form-builder/validation"
 )
 
-// CreateForm creates a new form
-func CreateForm(client *mongo.Client) fiber.Handler {
+// CreateForm creates a new form owned by the signed-in user, inside the
+// requested workspace when one is given
+func CreateForm(db store.Store) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		var req models.CreateFormRequest
 		if err := c.BodyParser(&req); err != nil {
@@ -37,6 +43,15 @@ func CreateForm(client *mongo.Client) fiber.Handler {
 			})
 		}
 
+		var workspaceID primitive.ObjectID
+		if req.WorkspaceID != "" {
+			id, _, err := authorizeWorkspace(c, db, req.WorkspaceID, models.PermEditForm)
+			if err != nil {
+				return err
+			}
+			workspaceID = id
+		}
+
 		// Generate unique IDs for fields and set order
 		for i := range req.Fields {
 			if req.Fields[i].ID == "" {
@@ -45,123 +60,212 @@ func CreateForm(client *mongo.Client) fiber.Handler {
 			req.Fields[i].Order = i
 		}
 
+		if err := conditions.Validate(req.Fields); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		if err := validation.CheckRules(req.Fields); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		if err := preparePages(req.Pages, req.Fields); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
 		// Generate shareable link
 		shareableLink := uuid.New().String()
 
+		ownerID, _ := auth.UserID(c)
 		form := models.Form{
+			OwnerID:       ownerID,
+			WorkspaceID:   workspaceID,
 			Title:         req.Title,
 			Description:   req.Description,
 			Fields:        req.Fields,
+			Pages:         req.Pages,
+			Revision:      1,
 			ShareableLink: shareableLink,
 			CreatedAt:     time.Now(),
 			UpdatedAt:     time.Now(),
 		}
+		// New forms stay drafts until they're published
+		if req.Status == "" {
+			req.Status = models.StatusDraft
+		}
+		if err := applyStatus(&form, models.UpdateFormStatusRequest{
+			Status:        req.Status,
+			OpensAt:       req.OpensAt,
+			ClosesAt:      req.ClosesAt,
+			MaxResponses:  req.MaxResponses,
+			ClosedMessage: req.ClosedMessage,
+		}); err != nil {
+			return err
+		}
+		form.LinkOnly = req.LinkOnly
+		form.Lenient = req.Lenient
 
-		collection := client.Database("formbuilder").Collection("forms")
-		result, err := collection.InsertOne(context.Background(), form)
-		if err != nil {
+		if err := db.CreateForm(context.Background(), &form); err != nil {
 			log.Printf("Error creating form: %v", err)
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to create form",
 			})
 		}
-
-		form.ID = result.InsertedID.(primitive.ObjectID)
-		form.ShareableLink = shareableLink
+		// A missing snapshot is recreated from the form on first use
+		rev := revisions.Snapshot(&form, ownerID)
+		if err := db.CreateRevision(context.Background(), &rev); err != nil {
+			log.Printf("Error storing form revision: %v", err)
+		}
 
 		return c.Status(fiber.StatusCreated).JSON(form)
 	}
 }
 
-// GetForms retrieves all forms
-func GetForms(client *mongo.Client) fiber.Handler {
+// GetForms retrieves the forms of the workspace given by ?workspaceId=, or
+// otherwise the signed-in user's personal forms. Archived forms are only listed when
+// asked for with ?status=archived; ?status= limits the list to one status.
+func GetForms(db store.Store) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		collection := client.Database("formbuilder").Collection("forms")
-		
-		cursor, err := collection.Find(context.Background(), bson.M{})
+		ownerID, _ := auth.UserID(c)
+		filter := store.FormFilter{OwnerID: ownerID}
+		if id := c.Query("workspaceId"); id != "" {
+			workspaceID, _, err := authorizeWorkspace(c, db, id, models.PermViewForm)
+			if err != nil {
+				return err
+			}
+			filter = store.FormFilter{WorkspaceID: workspaceID}
+		}
+
+		forms, err := db.ListForms(context.Background(), filter)
 		if err != nil {
 			log.Printf("Error fetching forms: %v", err)
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to fetch forms",
 			})
 		}
-		defer cursor.Close(context.Background())
 
-		var forms []models.Form
-		if err := cursor.All(context.Background(), &forms); err != nil {
-			log.Printf("Error decoding forms: %v", err)
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to decode forms",
+		status := models.FormStatus(c.Query("status"))
+		if status != "" && !status.Valid() {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid status",
 			})
 		}
+		listed := []models.Form{}
+		for _, f := range forms {
+			if f.Status == status || (status == "" && f.Status != models.StatusArchived) {
+				listed = append(listed, f)
+			}
+		}
+
+		return c.JSON(listed)
+	}
+}
+
+// GetForm retrieves a specific form by ID. Respondents get its public view,
+// or the closed message while the form isn't accepting responses, and
+// nothing for link-only forms; callers who can view the form always get
+// all of it.
+func GetForm(db store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		form, err := formByID(c, db, c.Params("id"))
+		if err != nil {
+			return err
+		}
+
+		if !canAccessForm(c, db, form, models.PermViewForm) {
+			if err := checkAccepting(db, form); err != nil {
+				return closedResponse(c, form, err)
+			}
+			return c.JSON(form.Public())
+		}
 
-		return c.JSON(forms)
+		return c.JSON(form)
 	}
 }
 
-// GetForm retrieves a specific form by ID
-func GetForm(client *mongo.Client) fiber.Handler {
+// UpdateFormStatus publishes, closes or archives a form and sets when and
+// how many responses it accepts. It doesn't create a revision.
+func UpdateFormStatus(db store.Store) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		id := c.Params("id")
-		objectID, err := primitive.ObjectIDFromHex(id)
+		form, err := authorizeForm(c, db, c.Params("id"), models.PermEditForm)
 		if err != nil {
+			return err
+		}
+		var req models.UpdateFormStatusRequest
+		if err := c.BodyParser(&req); err != nil {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid form ID",
+				"error": "Invalid request body",
 			})
 		}
+		if err := applyStatus(form, req); err != nil {
+			return err
+		}
+		form.UpdatedAt = time.Now()
 
-		collection := client.Database("formbuilder").Collection("forms")
-		var form models.Form
-		err = collection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&form)
-		if err != nil {
-			if err == mongo.ErrNoDocuments {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "Form not found",
-				})
-			}
-			log.Printf("Error fetching form: %v", err)
+		if err := db.UpdateFormStatus(context.Background(), form); err != nil {
+			log.Printf("Error updating form status: %v", err)
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to fetch form",
+				"error": "Failed to update form",
 			})
 		}
-
 		return c.JSON(form)
 	}
 }
 
-// GetFormByShareableLink retrieves a form by shareable link
-func GetFormByShareableLink(client *mongo.Client) fiber.Handler {
+// SetStrictMode turns a form's strict mode off (lenient) or back on
+func SetStrictMode(db store.Store) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		shareableLink := c.Params("shareableLink")
-
-		collection := client.Database("formbuilder").Collection("forms")
-		var form models.Form
-		err := collection.FindOne(context.Background(), bson.M{"shareableLink": shareableLink}).Decode(&form)
+		form, err := authorizeForm(c, db, c.Params("id"), models.PermEditForm)
 		if err != nil {
-			if err == mongo.ErrNoDocuments {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-					"error": "Form not found",
-				})
-			}
-			log.Printf("Error fetching form: %v", err)
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to fetch form",
+			return err
+		}
+		var req models.UpdateStrictModeRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
 			})
 		}
+		form.Lenient = req.Lenient
+		form.UpdatedAt = time.Now()
 
+		if err := db.UpdateFormStrictMode(context.Background(), form); err != nil {
+			log.Printf("Error updating form strict mode: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update form",
+			})
+		}
 		return c.JSON(form)
 	}
 }
 
+// GetFormByShareableLink retrieves a form by its shareable link or custom
+// slug, as respondents see it. Forms that aren't accepting responses give
+// the closed message instead.
+func GetFormByShareableLink(db store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		form, err := formByKey(db, c.Params("key"))
+		if err != nil {
+			return err
+		}
+		if err := checkAccepting(db, form); err != nil {
+			return closedResponse(c, form, err)
+		}
+
+		return c.JSON(form.Public())
+	}
+}
+
 // UpdateForm updates an existing form
-func UpdateForm(client *mongo.Client) fiber.Handler {
+func UpdateForm(db store.Store) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		id := c.Params("id")
-		objectID, err := primitive.ObjectIDFromHex(id)
+		existing, err := authorizeForm(c, db, c.Params("id"), models.PermEditForm)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid form ID",
-			})
+			return err
 		}
 
 		var req models.UpdateFormRequest
@@ -192,71 +296,185 @@ func UpdateForm(client *mongo.Client) fiber.Handler {
 			req.Fields[i].Order = i
 		}
 
-		update := bson.M{
-			"$set": bson.M{
-				"title":       req.Title,
-				"description": req.Description,
-				"fields":      req.Fields,
-				"updatedAt":   time.Now(),
-			},
+		if err := conditions.Validate(req.Fields); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		if err := validation.CheckRules(req.Fields); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		if err := preparePages(req.Pages, req.Fields); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
 		}
 
-		collection := client.Database("formbuilder").Collection("forms")
-		result, err := collection.UpdateOne(context.Background(), bson.M{"_id": objectID}, update)
+		// Every change becomes a new immutable revision; saving the same
+		// definition again keeps the current one
+		if err := ensureRevision(db, existing); err != nil {
+			log.Printf("Error storing current revision: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update form",
+			})
+		}
+		revs, err := db.ListRevisions(context.Background(), existing.ID)
 		if err != nil {
-			log.Printf("Error updating form: %v", err)
+			log.Printf("Error fetching revisions: %v", err)
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to update form",
 			})
 		}
+		if err := revisions.CheckTypes(revs, req.Fields); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		form := models.Form{
+			ID:          existing.ID,
+			Title:       req.Title,
+			Description: req.Description,
+			Fields:      req.Fields,
+			Pages:       req.Pages,
+			Revision:    existing.Revision + 1,
+			UpdatedAt:   time.Now(),
+		}
+		current, next := revisions.Snapshot(existing, primitive.NilObjectID), revisions.Snapshot(&form, primitive.NilObjectID)
+		if revisions.SameDefinition(&current, &next) {
+			return c.JSON(fiber.Map{
+				"message":  "Form updated successfully",
+				"revision": existing.Revision,
+			})
+		}
 
-		if result.MatchedCount == 0 {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error": "Form not found",
+		if err := db.UpdateForm(context.Background(), &form, existing.Revision); err != nil {
+			if err == store.ErrNotFound {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Form not found",
+				})
+			}
+			if err == store.ErrConflict {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": "Form was changed by someone else; reload it and try again",
+				})
+			}
+			log.Printf("Error updating form: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update form",
 			})
 		}
 
+		userID, _ := auth.UserID(c)
+		rev := revisions.Snapshot(&form, userID)
+		if err := db.CreateRevision(context.Background(), &rev); err != nil {
+			log.Printf("Error storing form revision: %v", err)
+		}
+
 		return c.JSON(fiber.Map{
-			"message": "Form updated successfully",
+			"message":  "Form updated successfully",
+			"revision": form.Revision,
 		})
 	}
 }
 
-// DeleteForm deletes a form
-func DeleteForm(client *mongo.Client) fiber.Handler {
+// DeleteForm deletes a form, with its responses and their uploaded files
+func DeleteForm(db store.Store, blobs blob.Store) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		id := c.Params("id")
-		objectID, err := primitive.ObjectIDFromHex(id)
+		form, err := authorizeForm(c, db, c.Params("id"), models.PermEditForm)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid form ID",
-			})
+			return err
 		}
+		objectID := form.ID
 
-		collection := client.Database("formbuilder").Collection("forms")
-		result, err := collection.DeleteOne(context.Background(), bson.M{"_id": objectID})
+		// Found before the responses are deleted, removed once the form
+		// is gone
+		files, err := responseFileKeys(db, objectID)
 		if err != nil {
+			log.Printf("Error listing form uploads: %v", err)
+		}
+
+		if err := db.DeleteForm(context.Background(), objectID); err != nil {
+			if err == store.ErrNotFound {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Form not found",
+				})
+			}
 			log.Printf("Error deleting form: %v", err)
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to delete form",
 			})
 		}
 
-		if result.DeletedCount == 0 {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error": "Form not found",
-			})
-		}
-
-		// Also delete associated responses
-		responsesCollection := client.Database("formbuilder").Collection("responses")
-		_, err = responsesCollection.DeleteMany(context.Background(), bson.M{"formId": objectID})
-		if err != nil {
+		// Also delete associated responses and analytics
+		if err := db.DeleteResponses(context.Background(), objectID); err != nil {
 			log.Printf("Error deleting form responses: %v", err)
 		}
+		if err := db.DeleteAggregate(context.Background(), objectID); err != nil {
+			log.Printf("Error deleting form analytics: %v", err)
+		}
+		if err := db.DeleteDrafts(context.Background(), objectID); err != nil {
+			log.Printf("Error deleting form drafts: %v", err)
+		}
+		if err := db.DeleteRevisions(context.Background(), objectID); err != nil {
+			log.Printf("Error deleting form revisions: %v", err)
+		}
+		deleteFiles(blobs, files)
 
 		return c.JSON(fiber.Map{
 			"message": "Form deleted successfully",
 		})
 	}
-} 
\ No newline at end of file
+}
+
+// preparePages assigns IDs to new pages and checks that every field is on a
+// page of the form and that visibility rules only look at answers given on
+// the same or an earlier page
+func preparePages(pages []models.Page, fields []models.Field) error {
+	if len(pages) == 0 {
+		for _, f := range fields {
+			if f.PageID != "" {
+				return fmt.Errorf("field %q: the form has no pages", f.Label)
+			}
+		}
+		return nil
+	}
+
+	pageOf := make(map[string]int, len(pages))
+	for i := range pages {
+		if pages[i].ID == "" {
+			pages[i].ID = uuid.New().String()
+		}
+		if _, dup := pageOf[pages[i].ID]; dup {
+			return fmt.Errorf("duplicate page ID %q", pages[i].ID)
+		}
+		pageOf[pages[i].ID] = i
+	}
+
+	fieldPage := make(map[string]int, len(fields))
+	for _, f := range fields {
+		idx := 0
+		if f.PageID != "" {
+			i, ok := pageOf[f.PageID]
+			if !ok {
+				return fmt.Errorf("field %q: unknown page %q", f.Label, f.PageID)
+			}
+			idx = i
+		}
+		fieldPage[f.ID] = idx
+	}
+	for _, f := range fields {
+		if f.Visibility == nil {
+			continue
+		}
+		for _, cond := range f.Visibility.Conditions {
+			if fieldPage[cond.FieldID] > fieldPage[f.ID] {
+				return fmt.Errorf("field %q: a condition can't depend on a field from a later page", f.Label)
+			}
+		}
+	}
+	return nil
+}
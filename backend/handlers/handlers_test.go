@@ -0,0 +1,501 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"custom-form-builder/analytics"
+	"custom-form-builder/auth"
+	"custom-form-builder/blob"
+	"custom-form-builder/models"
+	"custom-form-builder/store"
+	"custom-form-builder/websocket"
+)
+
+// testServer wires the handlers under test to a memory store, the way
+// main does, with one signed-in user
+type testServer struct {
+	app   *fiber.App
+	db    *store.MemoryStore
+	token string
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	db := store.NewMemoryStore()
+	issuer, err := auth.NewIssuer("test-secret", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := models.User{Email: "owner@example.com", Name: "Owner", CreatedAt: time.Now()}
+	if err := db.CreateUser(context.Background(), &user); err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := issuer.Issue(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blobs, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := websocket.NewHub()
+	tracker := analytics.NewTracker(db)
+
+	formsRead := auth.RequireScope(models.ScopeFormsRead)
+	formsWrite := auth.RequireScope(models.ScopeFormsWrite)
+	responsesRead := auth.RequireScope(models.ScopeResponsesRead)
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	api := app.Group("/api", auth.Middleware(issuer, db))
+	api.Post("/auth/register", Register(db, issuer))
+	api.Post("/auth/login", Login(db, issuer))
+	api.Get("/auth/me", auth.RequireUser(), Me(db))
+	keys := api.Group("/keys", auth.RequireUser())
+	keys.Post("/", CreateAPIKey(db))
+	keys.Post("/:id/rotate", RotateAPIKey(db))
+	keys.Delete("/:id", RevokeAPIKey(db))
+	workspaces := api.Group("/workspaces", auth.RequireUser())
+	workspaces.Post("/", CreateWorkspace(db))
+	workspaces.Put("/:id/members", SetWorkspaceMember(db))
+	workspaces.Delete("/:id/members/:userId", RemoveWorkspaceMember(db))
+	api.Post("/forms", formsWrite, CreateForm(db))
+	api.Get("/forms/shareable/:key", GetFormByShareableLink(db))
+	api.Post("/forms/shareable/:key/responses", SubmitByLink(db, hub, tracker, blobs))
+	api.Get("/forms/:id", GetForm(db))
+	api.Post("/forms/:id/link", formsWrite, RegenerateLink(db))
+	api.Delete("/forms/:id/link", formsWrite, RevokeLink(db))
+	api.Put("/forms/:id/slug", formsWrite, SetSlug(db))
+	api.Put("/forms/:id/access", formsWrite, SetAccess(db))
+	api.Get("/forms/:id/revisions", formsRead, GetRevisions(db))
+	api.Get("/forms/:id/revisions/:revision", formsRead, GetRevision(db))
+	api.Get("/forms/:id/revisions/:revision/diff", formsRead, GetRevisionDiff(db))
+	api.Put("/forms/:id", formsWrite, UpdateForm(db))
+	api.Put("/forms/:id/status", formsWrite, UpdateFormStatus(db))
+	api.Put("/forms/:id/strict-mode", formsWrite, SetStrictMode(db))
+	api.Post("/forms/:id/pages/:pageId/validate", ValidatePage(db, tracker))
+	api.Post("/responses", SubmitResponse(db, hub, tracker, blobs))
+	api.Get("/responses/:formId", responsesRead, GetResponses(db))
+	api.Get("/responses/:formId/csv", responsesRead, ExportResponsesCSV(db))
+	api.Post("/drafts", CreateDraft(db, tracker))
+	api.Get("/drafts/:token", GetDraft(db))
+	api.Patch("/drafts/:token", UpdateDraft(db))
+	api.Post("/drafts/:token/submit", SubmitDraft(db, hub, tracker, blobs))
+	api.Get("/analytics/:formId", responsesRead, GetAnalytics(db, tracker))
+
+	return &testServer{app: app, db: db, token: token}
+}
+
+// do sends body as JSON, signed in unless token is "", and returns the
+// status and response body
+func (s *testServer) do(t *testing.T, method, path string, body interface{}, token string) (int, []byte) {
+	t.Helper()
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = bytes.NewReader(b)
+	}
+	req := httptest.NewRequest(method, path, r)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := s.app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	out, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, out
+}
+
+// createForm creates a published form with fields and returns its ID
+func (s *testServer) createForm(t *testing.T, body map[string]interface{}) string {
+	t.Helper()
+	if _, ok := body["status"]; !ok {
+		body["status"] = "published"
+	}
+	status, out := s.do(t, "POST", "/api/forms", body, s.token)
+	if status != fiber.StatusCreated {
+		t.Fatalf("create form: %d %s", status, out)
+	}
+	var form models.Form
+	if err := json.Unmarshal(out, &form); err != nil {
+		t.Fatal(err)
+	}
+	return form.ID.Hex()
+}
+
+func (s *testServer) submit(t *testing.T, formID string, answers map[string]interface{}) {
+	t.Helper()
+	status, out := s.do(t, "POST", "/api/responses", map[string]interface{}{"formId": formID, "responses": answers}, "")
+	if status != fiber.StatusCreated {
+		t.Fatalf("submit: %d %s", status, out)
+	}
+}
+
+// surveyForm has a required text field, an email field, a bounded number,
+// a checkbox with an option containing a comma and a rating
+func surveyForm() map[string]interface{} {
+	return map[string]interface{}{
+		"title": "Survey",
+		"fields": []map[string]interface{}{
+			{"id": "name", "type": "text", "label": "Name", "required": true},
+			{"id": "email", "type": "email", "label": "Email"},
+			{"id": "age", "type": "number", "label": "Age", "minValue": 18, "maxValue": 99},
+			{"id": "colors", "type": "checkbox", "label": "Colors", "options": []string{"Red, dark", "Blue"}},
+			{"id": "score", "type": "rating", "label": "Score"},
+		},
+	}
+}
+
+func TestCreateForm(t *testing.T) {
+	s := newTestServer(t)
+	tests := []struct {
+		name   string
+		body   map[string]interface{}
+		token  string
+		status int
+	}{
+		{"valid", surveyForm(), s.token, fiber.StatusCreated},
+		{"signed out", surveyForm(), "", fiber.StatusUnauthorized},
+		{"no title", map[string]interface{}{"fields": []map[string]interface{}{{"type": "text", "label": "A"}}}, s.token, fiber.StatusBadRequest},
+		{"no fields", map[string]interface{}{"title": "Empty"}, s.token, fiber.StatusBadRequest},
+		{"crossed bounds", map[string]interface{}{
+			"title":  "Bounds",
+			"fields": []map[string]interface{}{{"type": "number", "label": "N", "minValue": 5, "maxValue": 1}},
+		}, s.token, fiber.StatusBadRequest},
+		{"other on text", map[string]interface{}{
+			"title":  "Other",
+			"fields": []map[string]interface{}{{"type": "text", "label": "T", "allowOther": true}},
+		}, s.token, fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, out := s.do(t, "POST", "/api/forms", tt.body, tt.token)
+			if status != tt.status {
+				t.Fatalf("status = %d, want %d: %s", status, tt.status, out)
+			}
+		})
+	}
+}
+
+func TestGetFormPublicView(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createForm(t, surveyForm())
+
+	_, out := s.do(t, "GET", "/api/forms/"+id, nil, "")
+	if strings.Contains(string(out), `"ownerId"`) || strings.Contains(string(out), `"id":"`+id) {
+		t.Errorf("anonymous read got the full form: %s", out)
+	}
+	_, out = s.do(t, "GET", "/api/forms/"+id, nil, s.token)
+	if !strings.Contains(string(out), `"id":"`+id) {
+		t.Errorf("owner read got the public view: %s", out)
+	}
+}
+
+func TestSubmitResponse(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createForm(t, surveyForm())
+
+	tests := []struct {
+		name    string
+		formID  string
+		answers map[string]interface{}
+		status  int
+		code    string
+	}{
+		{"valid", id, map[string]interface{}{"name": "Ada", "email": "ada@example.com", "age": 36, "colors": []string{"Red, dark"}}, fiber.StatusCreated, ""},
+		{"missing required", id, map[string]interface{}{"email": "ada@example.com"}, fiber.StatusBadRequest, models.CodeRequired},
+		{"bad email", id, map[string]interface{}{"name": "Ada", "email": "not-an-email"}, fiber.StatusBadRequest, models.CodeInvalidEmail},
+		{"below min", id, map[string]interface{}{"name": "Ada", "age": 12}, fiber.StatusBadRequest, models.CodeBelowMin},
+		{"unlisted option", id, map[string]interface{}{"name": "Ada", "colors": []string{"Green"}}, fiber.StatusBadRequest, models.CodeInvalidOption},
+		{"unknown field", id, map[string]interface{}{"name": "Ada", "nope": "x"}, fiber.StatusBadRequest, models.CodeUnknownField},
+		{"unknown form", "64b000000000000000000000", map[string]interface{}{"name": "Ada"}, fiber.StatusNotFound, ""},
+		{"invalid form ID", "nope", map[string]interface{}{"name": "Ada"}, fiber.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, out := s.do(t, "POST", "/api/responses", map[string]interface{}{"formId": tt.formID, "responses": tt.answers}, "")
+			if status != tt.status {
+				t.Fatalf("status = %d, want %d: %s", status, tt.status, out)
+			}
+			if tt.code == "" {
+				return
+			}
+			var body struct {
+				Errors []models.FieldError `json:"errors"`
+			}
+			if err := json.Unmarshal(out, &body); err != nil {
+				t.Fatal(err)
+			}
+			if len(body.Errors) != 1 || body.Errors[0].Code != tt.code {
+				t.Errorf("errors = %+v, want one %s", body.Errors, tt.code)
+			}
+		})
+	}
+}
+
+func TestSubmitClosedForm(t *testing.T) {
+	s := newTestServer(t)
+	body := surveyForm()
+	body["status"] = "draft"
+	id := s.createForm(t, body)
+
+	status, out := s.do(t, "POST", "/api/responses", map[string]interface{}{"formId": id, "responses": map[string]interface{}{"name": "Ada"}}, "")
+	if status != fiber.StatusForbidden || !strings.Contains(string(out), models.CodeFormNotPublished) {
+		t.Errorf("got %d %s, want 403 %s", status, out, models.CodeFormNotPublished)
+	}
+}
+
+func TestValidatePage(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createForm(t, map[string]interface{}{
+		"title": "Pages",
+		"pages": []map[string]interface{}{{"id": "p1", "title": "One"}, {"id": "p2", "title": "Two"}},
+		"fields": []map[string]interface{}{
+			{"id": "a", "type": "text", "label": "A", "required": true, "pageId": "p1"},
+			{"id": "b", "type": "email", "label": "B", "required": true, "pageId": "p2"},
+		},
+	})
+
+	tests := []struct {
+		name    string
+		page    string
+		answers map[string]interface{}
+		status  int
+	}{
+		{"valid", "p1", map[string]interface{}{"a": "x"}, fiber.StatusOK},
+		{"later page not checked", "p1", map[string]interface{}{"a": "x", "b": "not an email"}, fiber.StatusOK},
+		{"missing required", "p1", map[string]interface{}{}, fiber.StatusBadRequest},
+		{"invalid on page", "p2", map[string]interface{}{"a": "x", "b": "nope"}, fiber.StatusBadRequest},
+		{"unknown page", "p9", map[string]interface{}{"a": "x"}, fiber.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, out := s.do(t, "POST", "/api/forms/"+id+"/pages/"+tt.page+"/validate", map[string]interface{}{"responses": tt.answers}, "")
+			if status != tt.status {
+				t.Fatalf("status = %d, want %d: %s", status, tt.status, out)
+			}
+		})
+	}
+}
+
+func TestValidatePageClosedForm(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createForm(t, map[string]interface{}{
+		"title":  "Pages",
+		"pages":  []map[string]interface{}{{"id": "p1", "title": "One"}},
+		"fields": []map[string]interface{}{{"id": "a", "type": "text", "label": "A", "pageId": "p1"}},
+	})
+	if status, out := s.do(t, "PUT", "/api/forms/"+id+"/status", map[string]interface{}{"status": "closed"}, s.token); status != fiber.StatusOK {
+		t.Fatalf("close form: %d %s", status, out)
+	}
+
+	status, out := s.do(t, "POST", "/api/forms/"+id+"/pages/p1/validate", map[string]interface{}{"responses": map[string]interface{}{"a": "x"}}, "")
+	if status != fiber.StatusForbidden || !strings.Contains(string(out), models.CodeFormClosed) {
+		t.Errorf("got %d %s, want 403 %s", status, out, models.CodeFormClosed)
+	}
+}
+
+func TestValidatePageProgress(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createForm(t, map[string]interface{}{
+		"title": "Pages",
+		"pages": []map[string]interface{}{{"id": "p1", "title": "One"}, {"id": "p2", "title": "Two"}},
+		"fields": []map[string]interface{}{
+			{"id": "a", "type": "text", "label": "A", "pageId": "p1"},
+			{"id": "b", "type": "text", "label": "B", "pageId": "p2"},
+		},
+	})
+	status, out := s.do(t, "POST", "/api/drafts", map[string]interface{}{"formId": id, "responses": map[string]interface{}{}}, "")
+	if status != fiber.StatusCreated {
+		t.Fatalf("create draft: %d %s", status, out)
+	}
+	var draft struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(out, &draft); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a token nothing counts; with one the page counts once
+	for _, token := range []string{"", draft.Token, draft.Token, "unknown"} {
+		s.do(t, "POST", "/api/forms/"+id+"/pages/p1/validate", map[string]interface{}{"responses": map[string]interface{}{"a": "x"}, "draftToken": token}, "")
+	}
+
+	_, out = s.do(t, "GET", "/api/analytics/"+id, nil, s.token)
+	var result models.Analytics
+	if err := json.Unmarshal(out, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.PageDropOff) == 0 || result.PageDropOff[0].Completed != 1 {
+		t.Errorf("page drop-off = %+v, want p1 completed once", result.PageDropOff)
+	}
+}
+
+func TestGetAnalytics(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createForm(t, surveyForm())
+	s.submit(t, id, map[string]interface{}{"name": "Ada", "colors": []string{"Red, dark", "Blue"}, "score": 4})
+	s.submit(t, id, map[string]interface{}{"name": "Bob", "colors": []string{"Blue"}, "score": 2})
+	s.submit(t, id, map[string]interface{}{"name": "Cy"})
+
+	status, out := s.do(t, "GET", "/api/analytics/"+id, nil, s.token)
+	if status != fiber.StatusOK {
+		t.Fatalf("status = %d: %s", status, out)
+	}
+	var result models.Analytics
+	if err := json.Unmarshal(out, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.TotalResponses != 3 {
+		t.Errorf("totalResponses = %d, want 3", result.TotalResponses)
+	}
+
+	colors := result.FieldAnalytics["colors"]
+	tests := []struct {
+		name      string
+		got, want int
+	}{
+		{"colors answered", colors.ResponseCount, 2},
+		{"colors skipped", colors.SkipCount, 1},
+		{"Red, dark picked", colors.OptionCounts["Red, dark"], 1},
+		{"Blue picked", colors.OptionCounts["Blue"], 2},
+		{"name answered", result.FieldAnalytics["name"].ResponseCount, 3},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %d, want %d", tt.name, tt.got, tt.want)
+		}
+	}
+	if avg := result.FieldAnalytics["score"].AverageRating; avg == nil || *avg != 3 {
+		t.Errorf("average rating = %v, want 3", avg)
+	}
+
+	status, _ = s.do(t, "GET", "/api/analytics/"+id, nil, "")
+	if status != fiber.StatusUnauthorized {
+		t.Errorf("signed-out status = %d, want 401", status)
+	}
+}
+
+func TestExportResponsesCSV(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createForm(t, surveyForm())
+	s.submit(t, id, map[string]interface{}{"name": "Ada", "email": "ada@example.com", "age": 36, "colors": []string{"Red, dark", "Blue"}, "score": 5})
+	s.submit(t, id, map[string]interface{}{"name": "Bob, Jr."})
+
+	status, out := s.do(t, "GET", "/api/responses/"+id+"/csv", nil, s.token)
+	if status != fiber.StatusOK {
+		t.Fatalf("status = %d: %s", status, out)
+	}
+	rows, err := csv.NewReader(bytes.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want a header and 2 responses:\n%s", len(rows), out)
+	}
+	wantHeader := []string{"SubmittedAt", "Name", "Email", "Age", "Colors", "Score"}
+	if strings.Join(rows[0], "|") != strings.Join(wantHeader, "|") {
+		t.Errorf("header = %q, want %q", rows[0], wantHeader)
+	}
+
+	byName := map[string][]string{}
+	for _, row := range rows[1:] {
+		byName[row[1]] = row
+	}
+	tests := []struct {
+		name   string
+		row    string
+		column int
+		want   string
+	}{
+		{"email", "Ada", 2, "ada@example.com"},
+		{"number", "Ada", 3, "36"},
+		{"checkbox joined with semicolons", "Ada", 4, "Red, dark; Blue"},
+		{"rating", "Ada", 5, "5"},
+		{"comma in text", "Bob, Jr.", 1, "Bob, Jr."},
+		{"skipped answer", "Bob, Jr.", 3, ""},
+	}
+	for _, tt := range tests {
+		row, ok := byName[tt.row]
+		if !ok {
+			t.Fatalf("no row for %q", tt.row)
+		}
+		if row[tt.column] != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, row[tt.column], tt.want)
+		}
+	}
+
+	status, _ = s.do(t, "GET", "/api/responses/"+id+"/csv", nil, "")
+	if status != fiber.StatusUnauthorized {
+		t.Errorf("signed-out status = %d, want 401", status)
+	}
+}
+
+func TestRankingField(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createForm(t, map[string]interface{}{
+		"title": "Lunch",
+		"fields": []map[string]interface{}{
+			{"id": "food", "type": "ranking", "label": "Food", "options": []string{"Pizza", "Soup", "Salad"}, "minSelections": 2},
+		},
+	})
+	s.submit(t, id, map[string]interface{}{"food": []string{"Soup", "Pizza", "Salad"}})
+	s.submit(t, id, map[string]interface{}{"food": []string{"Soup", "Salad"}})
+
+	status, out := s.do(t, "POST", "/api/responses", map[string]interface{}{"formId": id, "responses": map[string]interface{}{"food": []string{"Soup", "Soup"}}}, "")
+	if status != fiber.StatusBadRequest || !strings.Contains(string(out), models.CodeDuplicate) {
+		t.Errorf("duplicate ranking: %d %s", status, out)
+	}
+
+	status, out = s.do(t, "GET", "/api/analytics/"+id, nil, s.token)
+	if status != fiber.StatusOK {
+		t.Fatalf("analytics status = %d: %s", status, out)
+	}
+	var result models.Analytics
+	if err := json.Unmarshal(out, &result); err != nil {
+		t.Fatal(err)
+	}
+	ranking := result.FieldAnalytics["food"].Ranking
+	if len(ranking) != 3 || ranking[0].Option != "Soup" || ranking[0].Borda != 4 || ranking[0].FirstChoices != 2 {
+		t.Errorf("ranking = %+v, want Soup first with 4 points", ranking)
+	}
+
+	status, out = s.do(t, "GET", "/api/responses/"+id+"/csv", nil, s.token)
+	if status != fiber.StatusOK {
+		t.Fatalf("export status = %d: %s", status, out)
+	}
+	rows, err := csv.NewReader(bytes.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"Food [Pizza]", "Food [Soup]", "Food [Salad]"},
+		{"2", "1", "3"},
+		{"", "1", "2"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d:\n%s", len(rows), len(want), out)
+	}
+	for i, w := range want {
+		if got := strings.Join(rows[i][1:], "|"); got != strings.Join(w, "|") {
+			t.Errorf("row %d = %q, want %q", i, rows[i][1:], w)
+		}
+	}
+}
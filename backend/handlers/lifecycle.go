@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"custom-form-builder/models"
+	"custom-form-builder/store"
+)
+
+// applyStatus validates a status and schedule and sets them on form
+func applyStatus(form *models.Form, req models.UpdateFormStatusRequest) error {
+	if !req.Status.Valid() {
+		return fiber.NewError(fiber.StatusBadRequest, "status must be draft, published, closed or archived")
+	}
+	if req.OpensAt != nil && req.ClosesAt != nil && !req.ClosesAt.After(*req.OpensAt) {
+		return fiber.NewError(fiber.StatusBadRequest, "closesAt must be after opensAt")
+	}
+	if req.MaxResponses < 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "maxResponses can't be negative")
+	}
+	form.Status = req.Status
+	form.OpensAt = req.OpensAt
+	form.ClosesAt = req.ClosesAt
+	form.MaxResponses = req.MaxResponses
+	form.ClosedMessage = strings.TrimSpace(req.ClosedMessage)
+	return nil
+}
+
+// checkAccepting returns a *CodedError when form isn't accepting responses
+// right now
+func checkAccepting(db store.ResponseStore, form *models.Form) error {
+	responses := 0
+	if form.MaxResponses > 0 {
+		n, err := db.CountResponsesSince(context.Background(), form.ID, time.Time{})
+		if err != nil {
+			log.Printf("Error counting responses: %v", err)
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch form")
+		}
+		responses = int(n)
+	}
+	return closedError(form, form.Closure(time.Now(), responses))
+}
+
+// closedResponse renders a checkAccepting error for a public form lookup,
+// adding the form's title so the page can still show it
+func closedResponse(c *fiber.Ctx, form *models.Form, err error) error {
+	if e, ok := err.(*CodedError); ok {
+		body := e.Body()
+		body["title"] = form.Title
+		return c.Status(e.Status).JSON(body)
+	}
+	return err
+}
+
+// closedError turns a Closure code into the error shown to respondents, or
+// nil for ""
+func closedError(form *models.Form, code string) error {
+	if code == "" {
+		return nil
+	}
+	return &CodedError{Status: fiber.StatusForbidden, Code: code, Message: form.ClosedText(code)}
+}
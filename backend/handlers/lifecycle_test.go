@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"custom-form-builder/models"
+)
+
+func TestUpdateFormStatus(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createForm(t, surveyForm())
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		body   map[string]interface{}
+		status int
+	}{
+		{"close", map[string]interface{}{"status": "closed"}, fiber.StatusOK},
+		{"reopen", map[string]interface{}{"status": "published"}, fiber.StatusOK},
+		{"back to draft", map[string]interface{}{"status": "draft"}, fiber.StatusOK},
+		{"archive", map[string]interface{}{"status": "archived"}, fiber.StatusOK},
+		{"schedule", map[string]interface{}{"status": "published", "opensAt": now, "closesAt": now.Add(time.Hour), "maxResponses": 10}, fiber.StatusOK},
+		{"unknown status", map[string]interface{}{"status": "paused"}, fiber.StatusBadRequest},
+		{"no status", map[string]interface{}{}, fiber.StatusBadRequest},
+		{"closes before opening", map[string]interface{}{"status": "published", "opensAt": now, "closesAt": now.Add(-time.Hour)}, fiber.StatusBadRequest},
+		{"closes when opening", map[string]interface{}{"status": "published", "opensAt": now, "closesAt": now}, fiber.StatusBadRequest},
+		{"negative limit", map[string]interface{}{"status": "published", "maxResponses": -1}, fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, out := s.do(t, "PUT", "/api/forms/"+id+"/status", tt.body, s.token); status != tt.status {
+				t.Errorf("status = %d, want %d: %s", status, tt.status, out)
+			}
+		})
+	}
+	if status, _ := s.do(t, "PUT", "/api/forms/"+id+"/status", map[string]interface{}{"status": "closed"}, ""); status != fiber.StatusUnauthorized {
+		t.Errorf("anonymous status change: %d", status)
+	}
+}
+
+func TestSubmitBySchedule(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		schedule map[string]interface{}
+		earlier  int
+		code     string
+		message  string
+	}{
+		{"open", map[string]interface{}{"status": "published", "opensAt": now.Add(-time.Hour), "closesAt": now.Add(time.Hour)}, 0, "", ""},
+		{"not open yet", map[string]interface{}{"status": "published", "opensAt": now.Add(time.Hour)}, 0, models.CodeFormNotOpenYet, ""},
+		{"past closing", map[string]interface{}{"status": "published", "closesAt": now.Add(-time.Minute)}, 0, models.CodeFormClosed, ""},
+		{"closed with a message", map[string]interface{}{"status": "closed", "closedMessage": "  Thanks, we're full  "}, 0, models.CodeFormClosed, "Thanks, we're full"},
+		{"archived", map[string]interface{}{"status": "archived"}, 0, models.CodeFormArchived, ""},
+		{"draft", map[string]interface{}{"status": "draft"}, 0, models.CodeFormNotPublished, ""},
+		{"limit reached", map[string]interface{}{"status": "published", "maxResponses": 2}, 2, models.CodeResponseLimit, ""},
+		{"under the limit", map[string]interface{}{"status": "published", "maxResponses": 2}, 1, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			id := s.createForm(t, surveyForm())
+			for i := 0; i < tt.earlier; i++ {
+				s.submit(t, id, map[string]interface{}{"name": "Earlier"})
+			}
+			if status, out := s.do(t, "PUT", "/api/forms/"+id+"/status", tt.schedule, s.token); status != fiber.StatusOK {
+				t.Fatalf("set status: %d %s", status, out)
+			}
+			link := s.getForm(t, id).ShareableLink
+
+			status, out := s.do(t, "POST", "/api/responses", map[string]interface{}{"formId": id, "responses": map[string]interface{}{"name": "Ada"}}, "")
+			if tt.code == "" {
+				if status != fiber.StatusCreated {
+					t.Errorf("submit: %d %s", status, out)
+				}
+				return
+			}
+			if status != fiber.StatusForbidden || !strings.Contains(string(out), tt.code) {
+				t.Errorf("submit: got %d %s, want 403 %s", status, out, tt.code)
+			}
+			if tt.message != "" && !strings.Contains(string(out), tt.message) {
+				t.Errorf("submit: message missing from %s", out)
+			}
+
+			// The public lookup explains why, with the title
+			status, out = s.do(t, "GET", "/api/forms/shareable/"+link, nil, "")
+			if status != fiber.StatusForbidden || !strings.Contains(string(out), tt.code) || !strings.Contains(string(out), `"title":"Survey"`) {
+				t.Errorf("lookup: got %d %s", status, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"custom-form-builder/models"
+	"custom-form-builder/store"
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// RegenerateLink gives a form a new shareable link. The old link stops
+// working; a revoked link is restored this way too.
+func RegenerateLink(db store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		form, err := authorizeForm(c, db, c.Params("id"), models.PermEditForm)
+		if err != nil {
+			return err
+		}
+		form.ShareableLink = uuid.New().String()
+		return saveLink(c, db, form)
+	}
+}
+
+// RevokeLink disables a form's shareable link. Its slug, if any, keeps
+// working.
+func RevokeLink(db store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		form, err := authorizeForm(c, db, c.Params("id"), models.PermEditForm)
+		if err != nil {
+			return err
+		}
+		form.ShareableLink = ""
+		return saveLink(c, db, form)
+	}
+}
+
+// SetSlug gives a form a custom slug to be shared under, replacing the
+// previous one, or removes it when the slug is empty
+func SetSlug(db store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		form, err := authorizeForm(c, db, c.Params("id"), models.PermEditForm)
+		if err != nil {
+			return err
+		}
+		var req models.UpdateFormSlugRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+
+		slug := strings.ToLower(strings.TrimSpace(req.Slug))
+		if slug != "" {
+			if len(slug) < 3 || len(slug) > 64 || !slugPattern.MatchString(slug) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Slug must be 3-64 lowercase letters, digits or single hyphens",
+				})
+			}
+			// Links are looked up before slugs, so a slug shaped like one
+			// could be shadowed
+			if _, err := uuid.Parse(slug); err == nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Slug can't look like a shareable link"})
+			}
+		}
+		form.Slug = slug
+		return saveLink(c, db, form)
+	}
+}
+
+// SetAccess turns ID-based access for respondents off (linkOnly) or on
+func SetAccess(db store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		form, err := authorizeForm(c, db, c.Params("id"), models.PermEditForm)
+		if err != nil {
+			return err
+		}
+		var req models.UpdateFormAccessRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+		form.LinkOnly = req.LinkOnly
+		return saveLink(c, db, form)
+	}
+}
+
+func saveLink(c *fiber.Ctx, db store.Store, form *models.Form) error {
+	form.UpdatedAt = time.Now()
+	if err := db.UpdateFormLink(context.Background(), form); err != nil {
+		if err == store.ErrDuplicate {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Slug is already taken"})
+		}
+		log.Printf("Error updating form link: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update form"})
+	}
+	return c.JSON(form)
+}
+
+// formByID loads a form for a public route keyed by form ID. Link-only
+// forms are reported as missing unless the caller can view them.
+func formByID(c *fiber.Ctx, db store.Store, id string) (*models.Form, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid form ID")
+	}
+	form, err := db.GetForm(context.Background(), objectID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Form not found")
+		}
+		log.Printf("Error fetching form: %v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch form")
+	}
+	if form.LinkOnly && !canAccessForm(c, db, form, models.PermViewForm) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Form not found")
+	}
+	return form, nil
+}
+
+// formByKey resolves a shareable link or, failing that, a custom slug
+func formByKey(db store.Store, key string) (*models.Form, error) {
+	ctx := context.Background()
+	form, err := db.GetFormByShareableLink(ctx, key)
+	if err == store.ErrNotFound {
+		form, err = db.GetFormBySlug(ctx, strings.ToLower(key))
+	}
+	if err != nil {
+		if err == store.ErrNotFound {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Form not found")
+		}
+		log.Printf("Error fetching form: %v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch form")
+	}
+	return form, nil
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"custom-form-builder/models"
+)
+
+// getForm reads a form as its owner
+func (s *testServer) getForm(t *testing.T, id string) models.Form {
+	t.Helper()
+	status, out := s.do(t, "GET", "/api/forms/"+id, nil, s.token)
+	if status != fiber.StatusOK {
+		t.Fatalf("get form: %d %s", status, out)
+	}
+	var form models.Form
+	if err := json.Unmarshal(out, &form); err != nil {
+		t.Fatal(err)
+	}
+	return form
+}
+
+func TestShareableLinks(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createForm(t, surveyForm())
+	other := s.createForm(t, surveyForm())
+	first := s.getForm(t, id).ShareableLink
+
+	if status, out := s.do(t, "POST", "/api/forms/"+id+"/link", nil, s.token); status != fiber.StatusOK {
+		t.Fatalf("regenerate: %d %s", status, out)
+	}
+	second := s.getForm(t, id).ShareableLink
+	if status, out := s.do(t, "PUT", "/api/forms/"+id+"/slug", map[string]string{"slug": "Team-Survey"}, s.token); status != fiber.StatusOK {
+		t.Fatalf("set slug: %d %s", status, out)
+	}
+
+	tests := []struct {
+		name   string
+		key    string
+		status int
+	}{
+		{"regenerated link", second, fiber.StatusOK},
+		{"replaced link", first, fiber.StatusNotFound},
+		{"slug", "team-survey", fiber.StatusOK},
+		{"slug in another case", "Team-Survey", fiber.StatusOK},
+		{"unknown", "nope", fiber.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, out := s.do(t, "GET", "/api/forms/shareable/"+tt.key, nil, ""); status != tt.status {
+				t.Errorf("status = %d, want %d: %s", status, tt.status, out)
+			}
+		})
+	}
+
+	if status, out := s.do(t, "DELETE", "/api/forms/"+id+"/link", nil, s.token); status != fiber.StatusOK {
+		t.Fatalf("revoke: %d %s", status, out)
+	}
+	if status, _ := s.do(t, "GET", "/api/forms/shareable/"+second, nil, ""); status != fiber.StatusNotFound {
+		t.Errorf("revoked link: status = %d, want %d", status, fiber.StatusNotFound)
+	}
+	if status, _ := s.do(t, "GET", "/api/forms/shareable/team-survey", nil, ""); status != fiber.StatusOK {
+		t.Errorf("slug after revoking the link: status = %d, want %d", status, fiber.StatusOK)
+	}
+	if status, _ := s.do(t, "POST", "/api/forms/"+id+"/link", nil, s.token); status != fiber.StatusOK {
+		t.Fatal("restore link failed")
+	}
+	if status, _ := s.do(t, "GET", "/api/forms/shareable/"+s.getForm(t, id).ShareableLink, nil, ""); status != fiber.StatusOK {
+		t.Errorf("restored link: status = %d, want %d", status, fiber.StatusOK)
+	}
+
+	slugs := []struct {
+		name   string
+		slug   string
+		status int
+	}{
+		{"taken", "team-survey", fiber.StatusConflict},
+		{"taken in another case", "TEAM-SURVEY", fiber.StatusConflict},
+		{"too short", "ab", fiber.StatusBadRequest},
+		{"double hyphen", "team--survey", fiber.StatusBadRequest},
+		{"shaped like a link", second, fiber.StatusBadRequest},
+		{"free", "other-survey", fiber.StatusOK},
+	}
+	for _, tt := range slugs {
+		t.Run("slug "+tt.name, func(t *testing.T) {
+			if status, out := s.do(t, "PUT", "/api/forms/"+other+"/slug", map[string]string{"slug": tt.slug}, s.token); status != tt.status {
+				t.Errorf("status = %d, want %d: %s", status, tt.status, out)
+			}
+		})
+	}
+}
+
+func TestSubmitByLink(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createForm(t, surveyForm())
+	link := s.getForm(t, id).ShareableLink
+	if status, out := s.do(t, "PUT", "/api/forms/"+id+"/slug", map[string]string{"slug": "team-survey"}, s.token); status != fiber.StatusOK {
+		t.Fatalf("set slug: %d %s", status, out)
+	}
+	answers := map[string]interface{}{"responses": map[string]interface{}{"name": "Ada"}}
+
+	tests := []struct {
+		name   string
+		key    string
+		body   interface{}
+		status int
+	}{
+		{"by link", link, answers, fiber.StatusCreated},
+		{"by slug", "team-survey", answers, fiber.StatusCreated},
+		{"invalid answers", link, map[string]interface{}{"responses": map[string]interface{}{"age": 5}}, fiber.StatusBadRequest},
+		{"no answers", link, map[string]interface{}{}, fiber.StatusBadRequest},
+		{"unknown key", "nope", answers, fiber.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, out := s.do(t, "POST", "/api/forms/shareable/"+tt.key+"/responses", tt.body, ""); status != tt.status {
+				t.Errorf("status = %d, want %d: %s", status, tt.status, out)
+			}
+		})
+	}
+	if got := s.countResponses(t, id); got != 2 {
+		t.Errorf("%d responses stored, want 2", got)
+	}
+}
+
+func TestLinkOnlyForm(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createForm(t, surveyForm())
+	link := s.getForm(t, id).ShareableLink
+	if status, out := s.do(t, "PUT", "/api/forms/"+id+"/access", map[string]bool{"linkOnly": true}, s.token); status != fiber.StatusOK {
+		t.Fatalf("set link only: %d %s", status, out)
+	}
+	answers := map[string]interface{}{"name": "Ada"}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		token  string
+		status int
+	}{
+		{"anonymous read by ID", "GET", "/api/forms/" + id, nil, "", fiber.StatusNotFound},
+		{"anonymous submit by ID", "POST", "/api/responses", map[string]interface{}{"formId": id, "responses": answers}, "", fiber.StatusNotFound},
+		{"anonymous draft by ID", "POST", "/api/drafts", map[string]interface{}{"formId": id}, "", fiber.StatusNotFound},
+		{"owner read by ID", "GET", "/api/forms/" + id, nil, s.token, fiber.StatusOK},
+		{"anonymous read by link", "GET", "/api/forms/shareable/" + link, nil, "", fiber.StatusOK},
+		{"anonymous submit by link", "POST", "/api/forms/shareable/" + link + "/responses", map[string]interface{}{"responses": answers}, "", fiber.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, out := s.do(t, tt.method, tt.path, tt.body, tt.token); status != tt.status {
+				t.Errorf("status = %d, want %d: %s", status, tt.status, out)
+			}
+		})
+	}
+	if got := s.countResponses(t, id); got != 1 {
+		t.Errorf("%d responses stored, want 1", got)
+	}
+}
@@ -2,82 +2,50 @@ package handlers
 
 import (
 	"context"
-	"fmt"
 	"log"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 
+	"custom-form-builder/analytics"
+	"custom-form-builder/blob"
+	"custom-form-builder/conditions"
 	"custom-form-builder/models"
+	"custom-form-builder/store"
+	"custom-form-builder/validation"
 	"custom-form-builder/websocket"
 )
 
-// SubmitResponse expects: { "formId": "...", "responses": { "<fieldId>": "value", ... } }
-func SubmitResponse(client *mongo.Client, hub *websocket.Hub) fiber.Handler {
+// SubmitResponse expects: { "formId": "...", "responses": { "<fieldId>": "value", ... } },
+// or the same as multipart/form-data with files (see parseSubmission)
+func SubmitResponse(db store.Store, hub *websocket.Hub, tracker *analytics.Tracker, blobs blob.Store) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		var req models.SubmitResponseRequest
-		if err := c.BodyParser(&req); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		files, err := parseSubmission(c, &req)
+		if err != nil {
+			return err
 		}
-		if req.FormID == "" || len(req.Responses) == 0 {
+		if req.FormID == "" || (len(req.Responses) == 0 && len(files) == 0) {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "formId and responses are required"})
 		}
 
-		formID, err := primitive.ObjectIDFromHex(req.FormID)
-		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid form ID"})
-		}
-
 		// Fetch form
-		var form models.Form
-		if err := client.Database("formbuilder").
-			Collection("forms").
-			FindOne(context.Background(), bson.M{"_id": formID}).
-			Decode(&form); err != nil {
-
-			if err == mongo.ErrNoDocuments {
-				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Form not found"})
-			}
-			log.Printf("Error fetching form: %v", err)
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch form"})
-		}
-
-		// Normalize values (checkbox arrays -> "a,b,c")
-		req.Responses = coerceValues(req.Responses)
-
-		// Validate
-		if err := validateResponses(form.Fields, req.Responses); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		form, err := formByID(c, db, req.FormID)
+		if err != nil {
+			return err
 		}
-
-		// Save
-		doc := models.FormResponse{
-			FormID:      formID,
-			Responses:   req.Responses,
-			SubmittedAt: time.Now(),
+		if req.Responses == nil {
+			req.Responses = map[string]interface{}{}
 		}
-		res, err := client.Database("formbuilder").
-			Collection("responses").
-			InsertOne(context.Background(), doc)
+		pending, err := attachFiles(form, req.Responses, files)
 		if err != nil {
-			log.Printf("Error saving response: %v", err)
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save response"})
+			return err
 		}
-		doc.ID = res.InsertedID.(primitive.ObjectID)
 
-		// Notify
-		if hub != nil {
-			hub.Broadcast <- websocket.Message{
-				Type: "new_response",
-				Data: map[string]interface{}{"formId": req.FormID, "response": doc},
-			}
+		doc, err := submit(hub, tracker, blobs, form, req.Responses, pending, nil)
+		if err != nil {
+			return err
 		}
-
 		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 			"message": "Response submitted successfully",
 			"id":      doc.ID.Hex(),
@@ -85,167 +53,248 @@ func SubmitResponse(client *mongo.Client, hub *websocket.Hub) fiber.Handler {
 	}
 }
 
-func GetResponses(client *mongo.Client) fiber.Handler {
+// SubmitByLink expects { "responses": { ... } } and submits them to the
+// form behind the shareable link or slug in the URL, so respondents never
+// need the form's ID. Files are uploaded as with SubmitResponse.
+func SubmitByLink(db store.Store, hub *websocket.Hub, tracker *analytics.Tracker, blobs blob.Store) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		formID := c.Params("formId")
-		id, err := primitive.ObjectIDFromHex(formID)
+		var req models.SubmitByLinkRequest
+		files, err := parseSubmission(c, &req)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid form ID"})
+			return err
+		}
+		if len(req.Responses) == 0 && len(files) == 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "responses are required"})
 		}
 
-		cur, err := client.Database("formbuilder").
-			Collection("responses").
-			Find(context.Background(), bson.M{"formId": id})
+		form, err := formByKey(db, c.Params("key"))
 		if err != nil {
-			log.Printf("Error fetching responses: %v", err)
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch responses"})
+			return err
+		}
+		if req.Responses == nil {
+			req.Responses = map[string]interface{}{}
+		}
+		pending, err := attachFiles(form, req.Responses, files)
+		if err != nil {
+			return err
 		}
-		defer cur.Close(context.Background())
 
-		var out []models.FormResponse
-		if err := cur.All(context.Background(), &out); err != nil {
-			log.Printf("Error decoding responses: %v", err)
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to decode responses"})
+		doc, err := submit(hub, tracker, blobs, form, req.Responses, pending, nil)
+		if err != nil {
+			return err
 		}
-		return c.JSON(out)
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"message": "Response submitted successfully",
+			"id":      doc.ID.Hex(),
+		})
 	}
 }
 
-// ---- validation & helpers ----
+// submit validates answers against form, stores them as a response and
+// notifies live dashboards. Uploads answering visible file fields are
+// stored once the answers are valid. draft is the draft being completed,
+// if any; it's claimed once the answers are valid and put back if the
+// response can't be saved. Errors are *fiber.Error or *CodedError values
+// ready to be returned from a handler.
+func submit(hub *websocket.Hub, tracker *analytics.Tracker, blobs blob.Store, form *models.Form, answers map[string]interface{}, pending uploads, draft *draftClaim) (*models.FormResponse, error) {
+	// The response limit is enforced by the tracker, which counts under
+	// the form's lock
+	if err := closedError(form, form.Closure(time.Now(), 0)); err != nil {
+		return nil, err
+	}
+
+	// Fields hidden by conditional rules are neither validated nor
+	// stored
+	hidden := conditions.Hidden(form.Fields, answers)
+	for _, id := range hidden {
+		delete(answers, id)
+	}
+
+	// Validate
+	visible := visibleFields(form.Fields, hidden)
+	if err := checkAnswers(form, visible, answers); err != nil {
+		return nil, err
+	}
+	other := validation.SplitOther(visible, answers)
+	if err := draft.claim(); err != nil {
+		return nil, err
+	}
+	keys, err := storeFiles(blobs, form, answers, pending)
+	if err != nil {
+		draft.release()
+		log.Printf("Error storing uploads: %v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to store uploads")
+	}
+
+	// Save
+	doc := models.FormResponse{
+		FormID:      form.ID,
+		Responses:   models.TypedAnswers(form.Fields, answers),
+		Hidden:      hidden,
+		Other:       other,
+		DraftID:     draft.id(),
+		Revision:    form.Revision,
+		SubmittedAt: time.Now(),
+	}
+	delta, err := tracker.Record(context.Background(), form, &doc)
+	if err != nil {
+		deleteFiles(blobs, keys)
+		draft.release()
+	}
+	if err == analytics.ErrResponseLimit {
+		return nil, closedError(form, models.CodeResponseLimit)
+	}
+	if err != nil {
+		log.Printf("Error saving response: %v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to save response")
+	}
 
-func validateResponses(fields []models.Field, responses map[string]interface{}) error {
-	for _, field := range fields {
-		val, exists := responses[field.ID]
+	// Notify. Dashboards that may not read responses only get the
+	// analytics update, without its text answers.
+	if hub != nil {
+		formID := form.ID.Hex()
+		hub.BroadcastToForm(formID, websocket.Message{
+			Type: "new_response",
+			Data: map[string]interface{}{"formId": formID, "response": doc},
+		}, nil)
+		hub.BroadcastToForm(formID, websocket.Message{
+			Type: "analytics_update",
+			Data: delta,
+		}, &websocket.Message{
+			Type: "analytics_update",
+			Data: delta.Redacted(),
+		})
+	}
+	return &doc, nil
+}
 
-		if field.Required && (!exists || isEmpty(val)) {
-			return &ValidationError{Field: field.ID, Message: "This field is required"}
+// ValidatePage checks the answers to one page of a multi-page form so the
+// client can validate it before moving on. Visibility rules see all the
+// answers given so far. The form is named by ID (:id) or by shareable link
+// or slug (:key), depending on the route. Files for the page's file fields
+// are sent as with SubmitResponse; they're checked but not kept. Forms that
+// aren't accepting responses give the closed error.
+func ValidatePage(db store.Store, tracker *analytics.Tracker) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req models.ValidatePageRequest
+		files, err := parseSubmission(c, &req)
+		if err != nil {
+			return err
 		}
-		if !exists || isEmpty(val) {
-			continue
+		if req.Responses == nil {
+			req.Responses = map[string]interface{}{}
 		}
 
-		switch field.Type {
-		case models.FieldTypeEmail:
-			s, _ := toString(val)
-			if !isValidEmail(s) {
-				return &ValidationError{Field: field.ID, Message: "Invalid email format"}
-			}
-		case models.FieldTypeNumber:
-			num, ok := toFloat(val)
-			if !ok {
-				return &ValidationError{Field: field.ID, Message: "Invalid number format"}
-			}
-			if field.MinValue != nil && num < float64(*field.MinValue) {
-				return &ValidationError{Field: field.ID, Message: "Value is below minimum"}
-			}
-			if field.MaxValue != nil && num > float64(*field.MaxValue) {
-				return &ValidationError{Field: field.ID, Message: "Value is above maximum"}
-			}
-		case models.FieldTypeRating:
-			r, ok := toFloat(val)
-			if !ok || r < 1 || r > 5 {
-				return &ValidationError{Field: field.ID, Message: "Rating must be between 1 and 5"}
-			}
+		var form *models.Form
+		if key := c.Params("key"); key != "" {
+			form, err = formByKey(db, key)
+		} else {
+			form, err = formByID(c, db, c.Params("id"))
+		}
+		if err != nil {
+			return err
+		}
+		if err := checkAccepting(db, form); err != nil {
+			return err
+		}
+		pageID := c.Params("pageId")
+		fields := form.PageFields(pageID)
+		if fields == nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Page not found"})
+		}
+		if _, err := attachFiles(form, req.Responses, files); err != nil {
+			return err
 		}
-	}
-	return nil
-}
 
-type ValidationError struct{ Field, Message string }
+		hidden := conditions.Hidden(form.Fields, req.Responses)
+		for _, id := range hidden {
+			delete(req.Responses, id)
+		}
+		if err := checkAnswers(form, visibleFields(fields, hidden), req.Responses); err != nil {
+			return err
+		}
 
-func (e *ValidationError) Error() string { return e.Message }
+		if req.DraftToken != "" {
+			recordPageProgress(db, tracker, form, pageID, req.DraftToken)
+		}
 
-func isValidEmail(s string) bool {
-	return len(s) > 2 && len(s) < 254 && strings.Contains(s, "@")
+		return c.JSON(fiber.Map{
+			"valid":  true,
+			"pageId": pageID,
+			"hidden": hidden,
+		})
+	}
 }
 
-func coerceValues(in map[string]interface{}) map[string]interface{} {
-	out := make(map[string]interface{}, len(in))
-	for k, v := range in {
-		switch vv := v.(type) {
-		case []interface{}:
-			out[k] = joinInterfaceList(vv)
-		default:
-			out[k] = vv
+// recordPageProgress counts pageID as completed if the draft with the given
+// resume token belongs to form and hasn't passed the page before. Failures
+// are logged, not returned, since the page itself validated.
+func recordPageProgress(db store.Store, tracker *analytics.Tracker, form *models.Form, pageID, token string) {
+	draft, err := findDraft(db, token)
+	if err != nil || draft.FormID != form.ID {
+		return
+	}
+	first, err := db.PassDraftPage(context.Background(), draft.ID, pageID)
+	if err != nil {
+		log.Printf("ValidatePage: recording progress: %v", err)
+		return
+	}
+	if first {
+		if err := tracker.RecordPage(context.Background(), form, pageID); err != nil {
+			log.Printf("ValidatePage: recording progress: %v", err)
 		}
 	}
-	return out
 }
 
-func joinInterfaceList(xs []interface{}) string {
-	if len(xs) == 0 {
-		return ""
-	}
-	var b strings.Builder
-	for i, x := range xs {
-		if i > 0 {
-			b.WriteByte(',')
+func GetResponses(db store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		form, err := authorizeForm(c, db, c.Params("formId"), models.PermReadResponses)
+		if err != nil {
+			return err
 		}
-		switch t := x.(type) {
-		case string:
-			b.WriteString(t)
-		default:
-			b.WriteString(fmt.Sprint(t))
+
+		out, err := db.ListResponses(context.Background(), form.ID)
+		if err != nil {
+			log.Printf("Error fetching responses: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch responses"})
 		}
+		return c.JSON(out)
 	}
-	return b.String()
 }
 
-func isEmpty(v interface{}) bool {
-	if v == nil {
-		return true
+// ---- validation & helpers ----
+
+// checkAnswers validates answers to fields, some or all of form's fields.
+// In strict mode answers to fields the form doesn't have are rejected too.
+func checkAnswers(form *models.Form, fields []models.Field, answers map[string]interface{}) error {
+	strict := !form.Lenient
+	errs := validation.Validate(fields, answers, strict)
+	if strict {
+		errs = append(errs, validation.Unknown(form.Fields, answers)...)
 	}
-	switch t := v.(type) {
-	case string:
-		return t == ""
-	case []byte:
-		return len(t) == 0
+	if len(errs) > 0 {
+		return validationError(errs)
 	}
-	return false
+	return nil
 }
 
-func toString(v interface{}) (string, bool) {
-	switch t := v.(type) {
-	case string:
-		return t, true
-	case fmt.Stringer:
-		return t.String(), true
-	default:
-		return fmt.Sprint(t), true
+// visibleFields returns fields without the ones listed in hidden
+func visibleFields(fields []models.Field, hidden []string) []models.Field {
+	if len(hidden) == 0 {
+		return fields
 	}
-}
-
-func toFloat(v interface{}) (float64, bool) {
-	switch t := v.(type) {
-	case float64:
-		return t, true
-	case float32:
-		return float64(t), true
-	case int:
-		return float64(t), true
-	case int8:
-		return float64(t), true
-	case int16:
-		return float64(t), true
-	case int32:
-		return float64(t), true
-	case int64:
-		return float64(t), true
-	case uint:
-		return float64(t), true
-	case uint8:
-		return float64(t), true
-	case uint16:
-		return float64(t), true
-	case uint32:
-		return float64(t), true
-	case uint64:
-		return float64(t), true
-	case string:
-		f, err := strconv.ParseFloat(t, 64)
-		return f, err == nil
-	default:
-		f, err := strconv.ParseFloat(fmt.Sprint(t), 64)
-		return f, err == nil
+	out := make([]models.Field, 0, len(fields))
+	for _, f := range fields {
+		isHidden := false
+		for _, id := range hidden {
+			if f.ID == id {
+				isHidden = true
+				break
+			}
+		}
+		if !isHidden {
+			out = append(out, f)
+		}
 	}
+	return out
 }
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"custom-form-builder/models"
+	"custom-form-builder/revisions"
+	"custom-form-builder/store"
+)
+
+// GetRevisions lists every revision of a form, oldest first
+func GetRevisions(db store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		form, err := authorizeForm(c, db, c.Params("id"), models.PermViewForm)
+		if err != nil {
+			return err
+		}
+		if err := ensureRevision(db, form); err != nil {
+			log.Printf("GetRevisions: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch revisions"})
+		}
+
+		revs, err := db.ListRevisions(context.Background(), form.ID)
+		if err != nil {
+			log.Printf("GetRevisions: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch revisions"})
+		}
+		return c.JSON(revs)
+	}
+}
+
+// GetRevision returns one revision of a form
+func GetRevision(db store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		form, err := authorizeForm(c, db, c.Params("id"), models.PermViewForm)
+		if err != nil {
+			return err
+		}
+		rev, err := findRevision(db, form, c.Params("revision"))
+		if err != nil {
+			return err
+		}
+		return c.JSON(rev)
+	}
+}
+
+// GetRevisionDiff compares a revision with an earlier one, given by
+// ?against= and defaulting to the revision just before it
+func GetRevisionDiff(db store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		form, err := authorizeForm(c, db, c.Params("id"), models.PermViewForm)
+		if err != nil {
+			return err
+		}
+		to, err := findRevision(db, form, c.Params("revision"))
+		if err != nil {
+			return err
+		}
+
+		against := c.Query("against")
+		if against == "" {
+			if to.Revision == 1 {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Revision 1 has no earlier revision to compare with"})
+			}
+			against = strconv.Itoa(to.Revision - 1)
+		}
+		from, err := findRevision(db, form, against)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(revisions.Diff(from, to))
+	}
+}
+
+// ensureRevision makes sure the form's current definition is stored as a
+// revision. Forms created before revisions existed become revision 1.
+func ensureRevision(db store.Store, form *models.Form) error {
+	ctx := context.Background()
+	if form.Revision == 0 {
+		form.Revision = 1
+		// A conflict means another request numbered it first
+		if err := db.UpdateForm(ctx, form, 0); err != nil && err != store.ErrConflict {
+			return err
+		}
+	}
+	if _, err := db.GetRevision(ctx, form.ID, form.Revision); err != store.ErrNotFound {
+		return err
+	}
+	rev := revisions.Snapshot(form, primitive.NilObjectID)
+	if err := db.CreateRevision(ctx, &rev); err != nil && err != store.ErrDuplicate {
+		return err
+	}
+	return nil
+}
+
+// findRevision loads a revision of form given its number as text
+func findRevision(db store.Store, form *models.Form, number string) (*models.FormRevision, error) {
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 1 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid revision")
+	}
+	if err := ensureRevision(db, form); err != nil {
+		log.Printf("Error storing current revision: %v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch revision")
+	}
+	rev, err := db.GetRevision(context.Background(), form.ID, n)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Revision not found")
+		}
+		log.Printf("Error fetching revision: %v", err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch revision")
+	}
+	return rev, nil
+}
+
+// revisionScope interprets a ?revision= parameter for analytics and
+// export. A number selects the responses submitted against that revision,
+// described with its fields; "all" covers every response, with the fields
+// of all revisions merged by ID. It returns the form the results describe
+// and, for each response, the form view to read it with (nil to skip it).
+func revisionScope(db store.Store, form *models.Form, scope string) (*models.Form, func(models.FormResponse) *models.Form, error) {
+	if scope != "all" {
+		rev, err := findRevision(db, form, scope)
+		if err != nil {
+			return nil, nil, err
+		}
+		view := *form
+		view.Title, view.Description = rev.Title, rev.Description
+		view.Fields, view.Pages = rev.Fields, rev.Pages
+		return &view, func(resp models.FormResponse) *models.Form {
+			if revisions.Of(resp) != rev.Revision {
+				return nil
+			}
+			return &view
+		}, nil
+	}
+
+	if err := ensureRevision(db, form); err != nil {
+		log.Printf("Error storing current revision: %v", err)
+		return nil, nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch revisions")
+	}
+	revs, err := db.ListRevisions(context.Background(), form.ID)
+	if err != nil {
+		log.Printf("Error fetching revisions: %v", err)
+		return nil, nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to fetch revisions")
+	}
+	view := *form
+	view.Fields = revisions.Merge(revs)
+
+	// A response only counts towards the fields its revision had, so a
+	// field added later isn't reported as skipped by older responses
+	views := map[int]*models.Form{}
+	for n, ids := range revisions.FieldSets(revs) {
+		v := view
+		v.Fields = nil
+		for _, f := range view.Fields {
+			if ids[f.ID] {
+				v.Fields = append(v.Fields, f)
+			}
+		}
+		views[n] = &v
+	}
+	return &view, func(resp models.FormResponse) *models.Form {
+		if v, ok := views[revisions.Of(resp)]; ok {
+			return v
+		}
+		return &view
+	}, nil
+}
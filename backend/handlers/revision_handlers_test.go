@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"custom-form-builder/models"
+)
+
+func TestRevisions(t *testing.T) {
+	s := newTestServer(t)
+	id := s.createForm(t, surveyForm())
+	other := s.register(t, "mallory@example.com")
+
+	edit := surveyForm()
+	edit["title"] = "Survey, revised"
+	fields := edit["fields"].([]map[string]interface{})
+	fields[0]["label"] = "Full name"
+	edit["fields"] = append(fields[:4], map[string]interface{}{"id": "note", "type": "text", "label": "Note"})
+
+	retyped := surveyForm()
+	retyped["fields"].([]map[string]interface{})[2]["type"] = "text"
+
+	steps := []struct {
+		name     string
+		method   string
+		path     string
+		body     interface{}
+		token    string
+		status   int
+		revision float64
+	}{
+		{"edit", "PUT", "/api/forms/" + id, edit, s.token, fiber.StatusOK, 2},
+		{"same definition again", "PUT", "/api/forms/" + id, edit, s.token, fiber.StatusOK, 2},
+		{"retyped field", "PUT", "/api/forms/" + id, retyped, s.token, fiber.StatusBadRequest, 0},
+		{"non-owner edit", "PUT", "/api/forms/" + id, surveyForm(), other, fiber.StatusForbidden, 0},
+		{"revision 1", "GET", "/api/forms/" + id + "/revisions/1", nil, s.token, fiber.StatusOK, 1},
+		{"revision 2", "GET", "/api/forms/" + id + "/revisions/2", nil, s.token, fiber.StatusOK, 2},
+		{"unknown revision", "GET", "/api/forms/" + id + "/revisions/3", nil, s.token, fiber.StatusNotFound, 0},
+		{"non-owner revision", "GET", "/api/forms/" + id + "/revisions/1", nil, other, fiber.StatusForbidden, 0},
+		{"diff of revision 1", "GET", "/api/forms/" + id + "/revisions/1/diff", nil, s.token, fiber.StatusBadRequest, 0},
+		{"diff against unknown", "GET", "/api/forms/" + id + "/revisions/2/diff?against=7", nil, s.token, fiber.StatusNotFound, 0},
+	}
+	for _, st := range steps {
+		status, out := s.do(t, st.method, st.path, st.body, st.token)
+		if status != st.status {
+			t.Fatalf("%s: status = %d, want %d: %s", st.name, status, st.status, out)
+		}
+		if st.revision == 0 {
+			continue
+		}
+		var res map[string]interface{}
+		if err := json.Unmarshal(out, &res); err != nil {
+			t.Fatal(err)
+		}
+		if res["revision"] != st.revision {
+			t.Errorf("%s: revision = %v, want %v", st.name, res["revision"], st.revision)
+		}
+	}
+
+	status, out := s.do(t, "GET", "/api/forms/"+id+"/revisions", nil, s.token)
+	var revs []models.FormRevision
+	if status != fiber.StatusOK || json.Unmarshal(out, &revs) != nil || len(revs) != 2 || revs[0].Revision != 1 || revs[0].Title != "Survey" {
+		t.Fatalf("revisions: %d %s", status, out)
+	}
+
+	for _, path := range []string{"/revisions/2/diff", "/revisions/2/diff?against=1"} {
+		status, out := s.do(t, "GET", "/api/forms/"+id+path, nil, s.token)
+		var d models.RevisionDiff
+		if status != fiber.StatusOK || json.Unmarshal(out, &d) != nil {
+			t.Fatalf("%s: %d %s", path, status, out)
+		}
+		if d.From != 1 || d.To != 2 || d.Title == nil || len(d.Added) != 1 || d.Added[0].ID != "note" ||
+			len(d.Removed) != 1 || d.Removed[0].ID != "score" || len(d.Changed) != 1 || d.Changed[0].FieldID != "name" {
+			t.Errorf("%s: diff = %s", path, out)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"custom-form-builder/blob"
+	"custom-form-builder/models"
+	"custom-form-builder/store"
+)
+
+// parseSubmission decodes a submission into req. The body is either JSON
+// or multipart/form-data, which forms with file fields need: its
+// "responses" part holds the answers as JSON, files are uploaded in parts
+// named by field ID and any other part is a plain value ("true" and
+// "false" are read as booleans). The uploads are returned by field ID.
+func parseSubmission(c *fiber.Ctx, req interface{}) (map[string][]*multipart.FileHeader, error) {
+	if !strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		if err := c.BodyParser(req); err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+		}
+		return nil, nil
+	}
+
+	mf, err := c.MultipartForm()
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid multipart body")
+	}
+	body := map[string]interface{}{}
+	for k, vs := range mf.Value {
+		if len(vs) == 0 {
+			continue
+		}
+		if k == "responses" {
+			var answers map[string]interface{}
+			if err := json.Unmarshal([]byte(vs[0]), &answers); err != nil {
+				return nil, fiber.NewError(fiber.StatusBadRequest, "responses must be a JSON object")
+			}
+			body[k] = answers
+		} else if b, err := strconv.ParseBool(vs[0]); err == nil {
+			body[k] = b
+		} else {
+			body[k] = vs[0]
+		}
+	}
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, req); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	return mf.File, nil
+}
+
+// uploads are the files of a submission waiting to be stored, by the ID
+// given to each
+type uploads map[string]*multipart.FileHeader
+
+// attachFiles makes the files uploaded to form's file fields their
+// answers. Files can only be given as uploads, so any other answer to a
+// file field is dropped, and uploads for anything but a file field are
+// rejected.
+func attachFiles(form *models.Form, answers map[string]interface{}, files map[string][]*multipart.FileHeader) (uploads, error) {
+	fileFields := map[string]bool{}
+	for _, f := range form.Fields {
+		if f.Type == models.FieldTypeFile {
+			fileFields[f.ID] = true
+			delete(answers, f.ID)
+		}
+	}
+
+	pending := uploads{}
+	for id, headers := range files {
+		if !fileFields[id] {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("%q is not a file field", id))
+		}
+		refs := make([]models.FileRef, 0, len(headers))
+		for _, h := range headers {
+			ct, err := uploadType(h)
+			if err != nil {
+				return nil, fiber.NewError(fiber.StatusBadRequest, "Failed to read upload")
+			}
+			ref := models.FileRef{
+				ID:          primitive.NewObjectID().Hex(),
+				Name:        filepath.Base(h.Filename),
+				ContentType: ct,
+				Size:        h.Size,
+			}
+			pending[ref.ID] = h
+			refs = append(refs, ref)
+		}
+		answers[id] = refs
+	}
+	return pending, nil
+}
+
+// uploadType picks the MIME type of an upload by its file extension, or
+// by sniffing its first bytes. The type the client sent isn't trusted.
+func uploadType(h *multipart.FileHeader) (string, error) {
+	if t := mime.TypeByExtension(strings.ToLower(filepath.Ext(h.Filename))); t != "" {
+		return t, nil
+	}
+	f, err := h.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	return http.DetectContentType(buf[:n]), nil
+}
+
+// storeFiles saves the uploads that are still answers, once they have
+// been validated; uploads to hidden fields were dropped with their
+// answers. It returns the keys written so they can be removed if the
+// response isn't saved.
+func storeFiles(blobs blob.Store, form *models.Form, answers map[string]interface{}, pending uploads) ([]string, error) {
+	var keys []string
+	for _, v := range answers {
+		refs, ok := v.([]models.FileRef)
+		if !ok {
+			continue
+		}
+		for _, ref := range refs {
+			h := pending[ref.ID]
+			if h == nil {
+				continue
+			}
+			key := fileKey(form.ID, ref.ID)
+			if err := putUpload(blobs, key, h, ref); err != nil {
+				deleteFiles(blobs, keys)
+				return nil, err
+			}
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func putUpload(blobs blob.Store, key string, h *multipart.FileHeader, ref models.FileRef) error {
+	f, err := h.Open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return blobs.Put(context.Background(), key, f, ref.Size, ref.ContentType)
+}
+
+// deleteFiles removes blobs, logging failures
+func deleteFiles(blobs blob.Store, keys []string) {
+	for _, key := range keys {
+		if err := blobs.Delete(context.Background(), key); err != nil {
+			log.Printf("Error deleting upload %s: %v", key, err)
+		}
+	}
+}
+
+// fileKey is where an upload to a form is kept in blob storage
+func fileKey(formID primitive.ObjectID, fileID string) string {
+	return "forms/" + formID.Hex() + "/" + fileID
+}
+
+// responseFileKeys lists the blobs of every file uploaded to a form
+func responseFileKeys(db store.Store, formID primitive.ObjectID) ([]string, error) {
+	var keys []string
+	err := db.ForEachResponse(context.Background(), formID, func(resp models.FormResponse) error {
+		for _, a := range resp.Responses {
+			for _, ref := range a.Files {
+				keys = append(keys, fileKey(formID, ref.ID))
+			}
+		}
+		return nil
+	})
+	return keys, err
+}
+
+// DownloadFile sends a file uploaded with a response, to users who may
+// read the form's responses
+func DownloadFile(db store.Store, blobs blob.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		form, err := authorizeForm(c, db, c.Params("formId"), models.PermReadResponses)
+		if err != nil {
+			return err
+		}
+		respID, err := primitive.ObjectIDFromHex(c.Params("responseId"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid response ID"})
+		}
+		resp, err := db.GetResponse(context.Background(), form.ID, respID)
+		if err != nil {
+			if err == store.ErrNotFound {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Response not found"})
+			}
+			log.Printf("Error fetching response: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch response"})
+		}
+
+		var ref *models.FileRef
+		for _, a := range resp.Responses {
+			for i := range a.Files {
+				if a.Files[i].ID == c.Params("fileId") {
+					ref = &a.Files[i]
+				}
+			}
+		}
+		if ref == nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "File not found"})
+		}
+
+		r, err := blobs.Open(context.Background(), fileKey(form.ID, ref.ID))
+		if err != nil {
+			if err == blob.ErrNotFound {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "File not found"})
+			}
+			log.Printf("Error opening upload: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch file"})
+		}
+		// Always a download, so an uploaded page can't run in the app's
+		// origin
+		c.Set(fiber.HeaderContentType, ref.ContentType)
+		c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": ref.Name}))
+		c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+		return c.SendStream(r, int(ref.Size))
+	}
+}
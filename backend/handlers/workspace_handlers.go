@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"custom-form-builder/auth"
+	"custom-form-builder/models"
+	"custom-form-builder/store"
+)
+
+// CreateWorkspace creates a workspace with the signed-in user as its owner
+func CreateWorkspace(db store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var req models.CreateWorkspaceRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+		name := strings.TrimSpace(req.Name)
+		if name == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Name is required"})
+		}
+
+		userID, _ := auth.UserID(c)
+		now := time.Now()
+		ws := models.Workspace{Name: name, CreatedBy: userID, CreatedAt: now}
+		if err := db.CreateWorkspace(context.Background(), &ws); err != nil {
+			log.Printf("Error creating workspace: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create workspace"})
+		}
+		owner := models.WorkspaceMember{WorkspaceID: ws.ID, UserID: userID, Role: models.RoleOwner, AddedAt: now}
+		if err := db.SetMember(context.Background(), &owner); err != nil {
+			log.Printf("Error adding workspace owner: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create workspace"})
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"workspace": ws, "role": owner.Role})
+	}
+}
+
+// GetWorkspaces lists the workspaces the signed-in user belongs to, with
+// their role in each
+func GetWorkspaces(db store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, _ := auth.UserID(c)
+		memberships, err := db.ListMemberships(context.Background(), userID)
+		if err != nil {
+			log.Printf("Error fetching memberships: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch workspaces"})
+		}
+
+		out := make([]fiber.Map, 0, len(memberships))
+		for _, m := range memberships {
+			ws, err := db.GetWorkspace(context.Background(), m.WorkspaceID)
+			if err != nil {
+				if err == store.ErrNotFound {
+					continue
+				}
+				log.Printf("Error fetching workspace: %v", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch workspaces"})
+			}
+			out = append(out, fiber.Map{"workspace": ws, "role": m.Role})
+		}
+		return c.JSON(out)
+	}
+}
+
+// GetWorkspace returns a workspace and its members; any member may view it
+func GetWorkspace(db store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		workspaceID, role, err := authorizeWorkspace(c, db, c.Params("id"), models.PermViewForm)
+		if err != nil {
+			return err
+		}
+
+		ws, err := db.GetWorkspace(context.Background(), workspaceID)
+		if err != nil {
+			if err == store.ErrNotFound {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Workspace not found"})
+			}
+			log.Printf("Error fetching workspace: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch workspace"})
+		}
+		members, err := db.ListMembers(context.Background(), workspaceID)
+		if err != nil {
+			log.Printf("Error fetching workspace members: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch workspace"})
+		}
+
+		out := make([]fiber.Map, 0, len(members))
+		for _, m := range members {
+			entry := fiber.Map{"userId": m.UserID, "role": m.Role, "addedAt": m.AddedAt}
+			if u, err := db.GetUser(context.Background(), m.UserID); err == nil {
+				entry["email"] = u.Email
+				entry["name"] = u.Name
+			}
+			out = append(out, entry)
+		}
+		return c.JSON(fiber.Map{"workspace": ws, "role": role, "members": out})
+	}
+}
+
+// DeleteWorkspace deletes an empty workspace; only owners may do this
+func DeleteWorkspace(db store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		workspaceID, _, err := authorizeWorkspace(c, db, c.Params("id"), models.PermManageMembers)
+		if err != nil {
+			return err
+		}
+
+		forms, err := db.ListForms(context.Background(), store.FormFilter{WorkspaceID: workspaceID})
+		if err != nil {
+			log.Printf("Error fetching workspace forms: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete workspace"})
+		}
+		if len(forms) > 0 {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Delete the workspace's forms first"})
+		}
+
+		if err := db.DeleteWorkspace(context.Background(), workspaceID); err != nil {
+			if err == store.ErrNotFound {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Workspace not found"})
+			}
+			log.Printf("Error deleting workspace: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete workspace"})
+		}
+		return c.JSON(fiber.Map{"message": "Workspace deleted successfully"})
+	}
+}
+
+// SetWorkspaceMember adds a registered user to the workspace by email, or
+// changes their role; only owners may do this
+func SetWorkspaceMember(db store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		workspaceID, _, err := authorizeWorkspace(c, db, c.Params("id"), models.PermManageMembers)
+		if err != nil {
+			return err
+		}
+
+		var req models.SetMemberRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+		if !req.Role.Valid() {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Role must be one of owner, editor, viewer, responder-data-reader"})
+		}
+
+		user, err := db.GetUserByEmail(context.Background(), normalizeEmail(req.Email))
+		if err != nil {
+			if err == store.ErrNotFound {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "No account with this email"})
+			}
+			log.Printf("Error fetching user: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update member"})
+		}
+
+		if req.Role != models.RoleOwner {
+			if err := keepAnOwner(db, workspaceID, user.ID); err != nil {
+				return err
+			}
+		}
+
+		member := models.WorkspaceMember{WorkspaceID: workspaceID, UserID: user.ID, Role: req.Role, AddedAt: time.Now()}
+		if err := db.SetMember(context.Background(), &member); err != nil {
+			log.Printf("Error setting workspace member: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update member"})
+		}
+		return c.JSON(fiber.Map{"userId": user.ID, "email": user.Email, "name": user.Name, "role": member.Role})
+	}
+}
+
+// RemoveWorkspaceMember removes a member. Owners may remove anyone; other
+// members may only remove themselves.
+func RemoveWorkspaceMember(db store.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		workspaceID, role, err := authorizeWorkspace(c, db, c.Params("id"), models.PermViewForm)
+		if err != nil {
+			return err
+		}
+		memberID, err := primitive.ObjectIDFromHex(c.Params("userId"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
+		}
+
+		userID, _ := auth.UserID(c)
+		if memberID != userID && !role.Can(models.PermManageMembers) {
+			return fiber.NewError(fiber.StatusForbidden, "Your role ("+string(role)+") doesn't allow this")
+		}
+		if err := keepAnOwner(db, workspaceID, memberID); err != nil {
+			return err
+		}
+
+		if err := db.RemoveMember(context.Background(), workspaceID, memberID); err != nil {
+			if err == store.ErrNotFound {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Member not found"})
+			}
+			log.Printf("Error removing workspace member: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to remove member"})
+		}
+		return c.JSON(fiber.Map{"message": "Member removed successfully"})
+	}
+}
+
+// keepAnOwner refuses to demote or remove userID if they are the
+// workspace's last owner
+func keepAnOwner(db store.Store, workspaceID, userID primitive.ObjectID) error {
+	members, err := db.ListMembers(context.Background(), workspaceID)
+	if err != nil {
+		log.Printf("Error fetching workspace members: %v", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to update member")
+	}
+	owners, isOwner := 0, false
+	for _, m := range members {
+		if m.Role == models.RoleOwner {
+			owners++
+			if m.UserID == userID {
+				isOwner = true
+			}
+		}
+	}
+	if isOwner && owners == 1 {
+		return fiber.NewError(fiber.StatusConflict, "A workspace needs at least one owner")
+	}
+	return nil
+}
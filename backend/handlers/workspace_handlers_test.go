@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+
+	"custom-form-builder/models"
+)
+
+// workspace is a workspace owned by the test user with one member of each
+// other role
+type workspace struct {
+	id     string
+	tokens map[models.Role]string
+	users  map[models.Role]string
+}
+
+func (s *testServer) createWorkspace(t *testing.T) *workspace {
+	t.Helper()
+	status, out := s.do(t, "POST", "/api/workspaces", map[string]string{"name": "Team"}, s.token)
+	if status != fiber.StatusCreated {
+		t.Fatalf("create workspace: %d %s", status, out)
+	}
+	var res struct {
+		Workspace models.Workspace `json:"workspace"`
+	}
+	if err := json.Unmarshal(out, &res); err != nil {
+		t.Fatal(err)
+	}
+	ws := &workspace{
+		id:     res.Workspace.ID.Hex(),
+		tokens: map[models.Role]string{models.RoleOwner: s.token},
+		users:  map[models.Role]string{},
+	}
+	for _, role := range []models.Role{models.RoleEditor, models.RoleViewer, models.RoleResponseReader} {
+		email := string(role) + "@example.com"
+		ws.tokens[role] = s.register(t, email)
+		ws.users[role] = s.setMember(t, ws.id, email, role, fiber.StatusOK)
+	}
+	return ws
+}
+
+// setMember gives email role in workspaceID, expecting status, and
+// returns the member's user ID
+func (s *testServer) setMember(t *testing.T, workspaceID, email string, role models.Role, status int) string {
+	t.Helper()
+	got, out := s.do(t, "PUT", "/api/workspaces/"+workspaceID+"/members", map[string]interface{}{"email": email, "role": role}, s.token)
+	if got != status {
+		t.Fatalf("set member %s: %d %s", email, got, out)
+	}
+	var res struct {
+		UserID string `json:"userId"`
+	}
+	if err := json.Unmarshal(out, &res); err != nil {
+		t.Fatal(err)
+	}
+	return res.UserID
+}
+
+func TestWorkspaceRoles(t *testing.T) {
+	s := newTestServer(t)
+	ws := s.createWorkspace(t)
+	body := surveyForm()
+	body["workspaceId"] = ws.id
+	id := s.createForm(t, body)
+	s.submit(t, id, map[string]interface{}{"name": "Ada Lovelace"})
+
+	lenient := map[string]bool{"lenient": true}
+	member := map[string]interface{}{"email": "owner@example.com", "role": "viewer"}
+	tests := []struct {
+		name   string
+		role   models.Role
+		method string
+		path   string
+		body   interface{}
+		status int
+	}{
+		{"viewer reads the form", models.RoleViewer, "GET", "/api/forms/" + id, nil, fiber.StatusOK},
+		{"viewer can't read responses", models.RoleViewer, "GET", "/api/responses/" + id, nil, fiber.StatusForbidden},
+		{"viewer can't export CSV", models.RoleViewer, "GET", "/api/responses/" + id + "/csv", nil, fiber.StatusForbidden},
+		{"viewer can't edit", models.RoleViewer, "PUT", "/api/forms/" + id + "/strict-mode", lenient, fiber.StatusForbidden},
+		{"reader reads responses", models.RoleResponseReader, "GET", "/api/responses/" + id, nil, fiber.StatusOK},
+		{"reader exports CSV", models.RoleResponseReader, "GET", "/api/responses/" + id + "/csv", nil, fiber.StatusOK},
+		{"reader can't edit", models.RoleResponseReader, "PUT", "/api/forms/" + id + "/strict-mode", lenient, fiber.StatusForbidden},
+		{"editor edits", models.RoleEditor, "PUT", "/api/forms/" + id + "/strict-mode", lenient, fiber.StatusOK},
+		{"editor can't set members", models.RoleEditor, "PUT", "/api/workspaces/" + ws.id + "/members", member, fiber.StatusForbidden},
+		{"editor can't remove others", models.RoleEditor, "DELETE", "/api/workspaces/" + ws.id + "/members/" + ws.users[models.RoleViewer], nil, fiber.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, out := s.do(t, tt.method, tt.path, tt.body, ws.tokens[tt.role]); status != tt.status {
+				t.Errorf("status = %d, want %d: %s", status, tt.status, out)
+			}
+		})
+	}
+}
+
+func TestAnalyticsRedactedForViewers(t *testing.T) {
+	s := newTestServer(t)
+	ws := s.createWorkspace(t)
+	body := surveyForm()
+	body["workspaceId"] = ws.id
+	id := s.createForm(t, body)
+	s.submit(t, id, map[string]interface{}{"name": "Ada Lovelace"})
+
+	tests := []struct {
+		role     models.Role
+		seesText bool
+	}{
+		{models.RoleOwner, true},
+		{models.RoleEditor, true},
+		{models.RoleResponseReader, true},
+		{models.RoleViewer, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.role), func(t *testing.T) {
+			status, out := s.do(t, "GET", "/api/analytics/"+id, nil, ws.tokens[tt.role])
+			if status != fiber.StatusOK {
+				t.Fatalf("status = %d: %s", status, out)
+			}
+			if got := strings.Contains(string(out), "Ada Lovelace"); got != tt.seesText {
+				t.Errorf("text answers shown = %v, want %v: %s", got, tt.seesText, out)
+			}
+		})
+	}
+}
+
+func TestKeepAnOwner(t *testing.T) {
+	s := newTestServer(t)
+	ws := s.createWorkspace(t)
+	owner := "owner@example.com"
+
+	s.setMember(t, ws.id, owner, models.RoleEditor, fiber.StatusConflict)
+	ownerID := s.setMember(t, ws.id, owner, models.RoleOwner, fiber.StatusOK)
+	if status, out := s.do(t, "DELETE", "/api/workspaces/"+ws.id+"/members/"+ownerID, nil, s.token); status != fiber.StatusConflict {
+		t.Fatalf("remove last owner: %d %s", status, out)
+	}
+
+	// With a second owner the first can step down
+	s.setMember(t, ws.id, string(models.RoleEditor)+"@example.com", models.RoleOwner, fiber.StatusOK)
+	s.setMember(t, ws.id, owner, models.RoleViewer, fiber.StatusOK)
+	if status, out := s.do(t, "DELETE", "/api/workspaces/"+ws.id+"/members/"+ownerID, nil, s.token); status != fiber.StatusOK {
+		t.Fatalf("leave as viewer: %d %s", status, out)
+	}
+}
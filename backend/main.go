@@ -2,8 +2,11 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -12,10 +15,16 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	fws "github.com/gofiber/websocket/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"custom-form-builder/analytics"
+	"custom-form-builder/auth"
+	"custom-form-builder/blob"
 	"custom-form-builder/handlers"
+	"custom-form-builder/models"
+	"custom-form-builder/store"
 	appws "custom-form-builder/websocket"
 )
 
@@ -25,39 +34,299 @@ func init() {
 	_ = godotenv.Load()
 }
 
-func main() {
-	// Connect to MongoDB
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// openStore picks the storage engine from STORAGE_DRIVER:
+// "mongo" (default, uses MONGO_URI), "sqlite" or "postgres" (use DATABASE_URL)
+// and "memory" (non-persistent, for local runs). Connecting is bounded by
+// a 10 second timeout; migrations run under ctx, since rewriting stored
+// data can take much longer.
+func openStore(ctx context.Context) (store.Store, error) {
+	connectCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	mongoURI := os.Getenv("MONGO_URI")
-	if mongoURI == "" {
-		mongoURI = "mongodb://127.0.0.1:27017"
+	driver := os.Getenv("STORAGE_DRIVER")
+	if driver == "" {
+		driver = "mongo"
+	}
+
+	switch driver {
+	case "mongo":
+		mongoURI := os.Getenv("MONGO_URI")
+		if mongoURI == "" {
+			mongoURI = "mongodb://127.0.0.1:27017"
+		}
+
+		var err error
+		client, err = mongo.Connect(connectCtx, options.Client().ApplyURI(mongoURI))
+		if err != nil {
+			return nil, err
+		}
+		if err := client.Ping(connectCtx, nil); err != nil {
+			return nil, err
+		}
+		log.Println("Connected to MongoDB!")
+		s := store.NewMongoStore(client, "formbuilder")
+		if err := s.EnsureIndexes(ctx); err != nil {
+			return nil, err
+		}
+		if err := s.Migrate(ctx); err != nil {
+			return nil, err
+		}
+		return s, nil
+
+	case "sqlite":
+		dsn := os.Getenv("DATABASE_URL")
+		if dsn == "" {
+			dsn = "file:formbuilder.db?_busy_timeout=5000"
+		}
+		s, err := store.OpenSQLStore(connectCtx, store.DialectSQLite, dsn)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.Migrate(ctx); err != nil {
+			s.Close()
+			return nil, fmt.Errorf("migrate: %w", err)
+		}
+		log.Println("Using SQLite storage")
+		return s, nil
+
+	case "postgres":
+		dsn := os.Getenv("DATABASE_URL")
+		if dsn == "" {
+			return nil, fmt.Errorf("DATABASE_URL is required for the postgres driver")
+		}
+		s, err := store.OpenSQLStore(connectCtx, store.DialectPostgres, dsn)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.Migrate(ctx); err != nil {
+			s.Close()
+			return nil, fmt.Errorf("migrate: %w", err)
+		}
+		log.Println("Connected to Postgres!")
+		return s, nil
+
+	case "memory":
+		log.Println("Using in-memory storage; data is lost on restart")
+		return store.NewMemoryStore(), nil
+	}
+	return nil, fmt.Errorf("unknown STORAGE_DRIVER %q", driver)
+}
+
+// openBlobStore picks where uploaded files are kept from BLOB_DRIVER:
+// "local" (default, a directory given by UPLOAD_DIR) or "s3" (any
+// S3-compatible service, configured by the S3_* variables).
+func openBlobStore() (blob.Store, error) {
+	driver := os.Getenv("BLOB_DRIVER")
+	if driver == "" {
+		driver = "local"
+	}
+
+	switch driver {
+	case "local":
+		dir := os.Getenv("UPLOAD_DIR")
+		if dir == "" {
+			dir = "uploads"
+		}
+		log.Printf("Storing uploads in %s", dir)
+		return blob.NewLocalStore(dir)
+
+	case "s3":
+		pathStyle, _ := strconv.ParseBool(os.Getenv("S3_PATH_STYLE"))
+		s, err := blob.NewS3Store(blob.S3Config{
+			Endpoint:     os.Getenv("S3_ENDPOINT"),
+			Region:       os.Getenv("S3_REGION"),
+			Bucket:       os.Getenv("S3_BUCKET"),
+			AccessKey:    os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretKey:    os.Getenv("S3_SECRET_ACCESS_KEY"),
+			SessionToken: os.Getenv("S3_SESSION_TOKEN"),
+			PathStyle:    pathStyle,
+		})
+		if err != nil {
+			return nil, err
+		}
+		log.Println("Storing uploads in S3")
+		return s, nil
+	}
+	return nil, fmt.Errorf("unknown BLOB_DRIVER %q", driver)
+}
+
+// rebuildAnalytics recomputes the stored analytics aggregates of the given
+// forms, or of every form when no IDs are given.
+//
+//	go run . rebuild-analytics [formId ...]
+func rebuildAnalytics(db store.Store, formIDs []string) error {
+	ctx := context.Background()
+	tracker := analytics.NewTracker(db)
+
+	var forms []models.Form
+	if len(formIDs) == 0 {
+		all, err := db.ListForms(ctx, store.FormFilter{})
+		if err != nil {
+			return err
+		}
+		forms = all
+	}
+	for _, id := range formIDs {
+		objectID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return fmt.Errorf("invalid form ID %q", id)
+		}
+		form, err := db.GetForm(ctx, objectID)
+		if err != nil {
+			return fmt.Errorf("form %s: %w", id, err)
+		}
+		forms = append(forms, *form)
+	}
+
+	for i := range forms {
+		agg, err := tracker.Rebuild(ctx, &forms[i])
+		if err != nil {
+			return fmt.Errorf("form %s: %w", forms[i].ID.Hex(), err)
+		}
+		log.Printf("Rebuilt analytics for form %s (%d responses)", forms[i].ID.Hex(), agg.TotalResponses)
+	}
+	return nil
+}
+
+// claimForms gives the forms created before accounts existed, which have
+// no owner and can't be opened by anyone, to the user registered with
+// email.
+//
+//	go run . claim-forms admin@example.com
+func claimForms(db store.Store, email string) error {
+	ctx := context.Background()
+	user, err := db.GetUserByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		if err == store.ErrNotFound {
+			return fmt.Errorf("no user registered as %s", email)
+		}
+		return err
+	}
+	n, err := db.ClaimUnownedForms(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	log.Printf("Gave %d unowned forms to %s", n, user.Email)
+	return nil
+}
+
+// limitBody refuses request bodies over limit, or over uploadLimit for
+// multipart submissions: responses, drafts being submitted and pages being
+// validated, which may carry files
+func limitBody(limit, uploadLimit int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		n := limit
+		if isUpload(c) {
+			n = uploadLimit
+		}
+		if len(c.Body()) > n {
+			return fiber.ErrRequestEntityTooLarge
+		}
+		return c.Next()
+	}
+}
+
+func isUpload(c *fiber.Ctx) bool {
+	if c.Method() != fiber.MethodPost || !strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		return false
+	}
+	path := strings.TrimSuffix(c.Path(), "/")
+	for _, suffix := range []string{"/responses", "/submit", "/validate"} {
+		if strings.HasSuffix(path, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func main() {
+	// Connect to storage
+	db, err := openStore(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "rebuild-analytics" {
+		if err := rebuildAnalytics(db, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "claim-forms" {
+		if len(os.Args) != 3 {
+			log.Fatal("usage: claim-forms <email>")
+		}
+		if err := claimForms(db, os.Args[2]); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
 
-	var err error
-	client, err = mongo.Connect(ctx, options.Client().ApplyURI(mongoURI))
+	blobs, err := openBlobStore()
 	if err != nil {
 		log.Fatal(err)
 	}
-	if err := client.Ping(ctx, nil); err != nil {
+
+	// Access tokens
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if jwtSecret == "" {
+		log.Println("JWT_SECRET is not set; using a random secret, tokens will not survive a restart")
+	}
+	issuer, err := auth.NewIssuer(jwtSecret, auth.DefaultTTL)
+	if err != nil {
 		log.Fatal(err)
 	}
-	log.Println("Connected to MongoDB!")
+	requireUser := auth.RequireUser()
+	formsRead := auth.RequireScope(models.ScopeFormsRead)
+	formsWrite := auth.RequireScope(models.ScopeFormsWrite)
+	responsesRead := auth.RequireScope(models.ScopeResponsesRead)
 
-	// WebSocket hub
+	// WebSocket hub; dashboards may only follow forms their user can see,
+	// and only roles that may read responses are sent them
 	hub := appws.NewHub()
+	hub.Authorize = func(userID, formID string) appws.Access {
+		uid, err := primitive.ObjectIDFromHex(userID)
+		if err != nil {
+			return appws.AccessNone
+		}
+		fid, err := primitive.ObjectIDFromHex(formID)
+		if err != nil {
+			return appws.AccessNone
+		}
+		form, err := db.GetForm(context.Background(), fid)
+		if err != nil {
+			return appws.AccessNone
+		}
+		role, err := auth.FormRole(context.Background(), db, form, uid)
+		switch {
+		case err != nil || !role.Can(models.PermViewAnalytics):
+			return appws.AccessNone
+		case role.Can(models.PermReadResponses):
+			return appws.AccessResponses
+		}
+		return appws.AccessAnalytics
+	}
 	go hub.Run()
 
+	// Per-form analytics aggregates, updated on each submission
+	tracker := analytics.NewTracker(db)
+
+	// Submissions carry file uploads, so they may be larger than fiber's
+	// 4 MB; limitBody keeps that limit for every other request
+	uploadLimit := 50 << 20
+	if n, err := strconv.Atoi(os.Getenv("MAX_REQUEST_BYTES")); err == nil && n > 0 {
+		uploadLimit = n
+	}
+	bodyLimit := fiber.DefaultBodyLimit
+	if uploadLimit > bodyLimit {
+		bodyLimit = uploadLimit
+	}
+
 	// Fiber app with JSON error handler
 	app := fiber.New(fiber.Config{
-		ErrorHandler: func(c *fiber.Ctx, err error) error {
-			code := fiber.StatusInternalServerError
-			if e, ok := err.(*fiber.Error); ok {
-				code = e.Code
-			}
-			return c.Status(code).JSON(fiber.Map{"error": err.Error()})
-		},
+		BodyLimit:    bodyLimit,
+		ErrorHandler: handlers.ErrorHandler,
 	})
 
 	// CORS
@@ -70,38 +339,92 @@ func main() {
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     origins,
 		AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
-		AllowMethods:     "GET, POST, PUT, DELETE, OPTIONS",
+		AllowMethods:     "GET, POST, PUT, PATCH, DELETE, OPTIONS",
 		AllowCredentials: true,
 	}))
+	app.Use(limitBody(fiber.DefaultBodyLimit, uploadLimit))
 
 	// WebSocket upgrade gate
 	app.Use("/ws", func(c *fiber.Ctx) error {
-		if fws.IsWebSocketUpgrade(c) {
-			c.Locals("allowed", true)
-			return c.Next()
+		if !fws.IsWebSocketUpgrade(c) {
+			return fiber.ErrUpgradeRequired
 		}
-		return fiber.ErrUpgradeRequired
+		userID, err := issuer.Authenticate(c)
+		if err != nil {
+			return fiber.NewError(fiber.StatusUnauthorized, "Authentication required")
+		}
+		c.Locals("allowed", true)
+		c.Locals(auth.LocalsUserID, userID.Hex())
+		return c.Next()
 	})
 	app.Get("/ws", fws.New(func(c *fws.Conn) {
-		appws.HandleWebSocket(c, hub)
+		userID, _ := c.Locals(auth.LocalsUserID).(string)
+		appws.HandleWebSocket(c, hub, userID)
 	}))
 
-	// API routes
-	api := app.Group("/api")
+	// API routes. Callers are identified by an access token or an API key;
+	// routes guarded by a scope accept both, requireUser routes only
+	// signed-in users.
+	api := app.Group("/api", auth.Middleware(issuer, db))
+	authGroup := api.Group("/auth")
+	authGroup.Post("/register", handlers.Register(db, issuer))
+	authGroup.Post("/login", handlers.Login(db, issuer))
+	authGroup.Get("/me", requireUser, handlers.Me(db))
+
+	keys := api.Group("/keys", requireUser)
+	keys.Post("/", handlers.CreateAPIKey(db))
+	keys.Get("/", handlers.GetAPIKeys(db))
+	keys.Post("/:id/rotate", handlers.RotateAPIKey(db))
+	keys.Delete("/:id", handlers.RevokeAPIKey(db))
+
+	workspaces := api.Group("/workspaces")
+	workspaces.Post("/", requireUser, handlers.CreateWorkspace(db))
+	workspaces.Get("/", formsRead, handlers.GetWorkspaces(db))
+	workspaces.Get("/:id", formsRead, handlers.GetWorkspace(db))
+	workspaces.Delete("/:id", requireUser, handlers.DeleteWorkspace(db))
+	workspaces.Put("/:id/members", requireUser, handlers.SetWorkspaceMember(db))
+	workspaces.Delete("/:id/members/:userId", requireUser, handlers.RemoveWorkspaceMember(db))
+
+	// Reading a form and submitting a response stay public so forms can be
+	// filled in without an account.
 	forms := api.Group("/forms")
-	forms.Post("/", handlers.CreateForm(client))
-	forms.Get("/", handlers.GetForms(client))
-	forms.Get("/:id", handlers.GetForm(client))
-	forms.Put("/:id", handlers.UpdateForm(client))
-	forms.Delete("/:id", handlers.DeleteForm(client))
+	forms.Post("/", formsWrite, handlers.CreateForm(db))
+	forms.Get("/", formsRead, handlers.GetForms(db))
+	forms.Get("/shareable/:key", handlers.GetFormByShareableLink(db))
+	forms.Post("/shareable/:key/responses", handlers.SubmitByLink(db, hub, tracker, blobs))
+	forms.Post("/shareable/:key/pages/:pageId/validate", handlers.ValidatePage(db, tracker))
+	forms.Get("/:id", handlers.GetForm(db))
+	forms.Put("/:id", formsWrite, handlers.UpdateForm(db))
+	forms.Put("/:id/status", formsWrite, handlers.UpdateFormStatus(db))
+	forms.Post("/:id/link", formsWrite, handlers.RegenerateLink(db))
+	forms.Delete("/:id/link", formsWrite, handlers.RevokeLink(db))
+	forms.Put("/:id/slug", formsWrite, handlers.SetSlug(db))
+	forms.Put("/:id/access", formsWrite, handlers.SetAccess(db))
+	forms.Put("/:id/strict-mode", formsWrite, handlers.SetStrictMode(db))
+	forms.Delete("/:id", formsWrite, handlers.DeleteForm(db, blobs))
+	forms.Post("/:id/pages/:pageId/validate", handlers.ValidatePage(db, tracker))
+	forms.Get("/:id/revisions", formsRead, handlers.GetRevisions(db))
+	forms.Get("/:id/revisions/:revision", formsRead, handlers.GetRevision(db))
+	forms.Get("/:id/revisions/:revision/diff", formsRead, handlers.GetRevisionDiff(db))
 
 	responses := api.Group("/responses")
-	responses.Post("/", handlers.SubmitResponse(client, hub))
-	responses.Get("/:formId", handlers.GetResponses(client))
-	responses.Get("/:formId/csv", handlers.ExportResponsesCSV(client))
+	responses.Post("/", handlers.SubmitResponse(db, hub, tracker, blobs))
+	responses.Get("/:formId", responsesRead, handlers.GetResponses(db))
+	responses.Get("/:formId/csv", responsesRead, handlers.ExportResponsesCSV(db))
+	responses.Get("/:formId/:responseId/files/:fileId", responsesRead, handlers.DownloadFile(db, blobs))
+
+	// Drafts are public too; the resume token is the only credential
+	drafts := api.Group("/drafts")
+	drafts.Post("/", handlers.CreateDraft(db, tracker))
+	drafts.Get("/:token", handlers.GetDraft(db))
+	drafts.Patch("/:token", handlers.UpdateDraft(db))
+	drafts.Delete("/:token", handlers.DeleteDraft(db))
+	drafts.Post("/:token/submit", handlers.SubmitDraft(db, hub, tracker, blobs))
 
 	analytics := api.Group("/analytics")
-	analytics.Get("/:formId", handlers.GetAnalytics(client))
+	analytics.Get("/:formId", responsesRead, handlers.GetAnalytics(db, tracker))
+	analytics.Post("/:formId/rebuild", formsWrite, handlers.RebuildAnalytics(db, tracker))
+	analytics.Get("/:formId/subscribers", formsRead, handlers.GetSubscriberCount(db, hub))
 
 	// Health
 	app.Get("/health", func(c *fiber.Ctx) error {
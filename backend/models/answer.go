@@ -0,0 +1,479 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AnswerKind is the type of value an Answer holds
+type AnswerKind string
+
+const (
+	AnswerText    AnswerKind = "text"
+	AnswerNumber  AnswerKind = "number"
+	AnswerChoices AnswerKind = "choices"
+	AnswerFiles   AnswerKind = "files"
+	AnswerMatrix  AnswerKind = "matrix"
+	AnswerBool    AnswerKind = "bool"
+)
+
+// FileRef is a file uploaded as the answer to a file field. The file
+// itself is kept in blob storage.
+type FileRef struct {
+	ID          string `json:"id" bson:"id"`
+	Name        string `json:"name" bson:"name"`
+	ContentType string `json:"contentType" bson:"contentType"`
+	Size        int64  `json:"size" bson:"size"`
+}
+
+// Answer is one stored answer, typed by its field: text for text, email,
+// URL, multiple choice and dropdown fields (and dates, times and phone
+// numbers, in their stored layouts), a number for number, rating, NPS and
+// slider fields, a boolean for yes/no fields, the picked options of a
+// checkbox field (or a ranking's options, best first), the uploads of a
+// file field and the columns picked in each row of a matrix field. It's
+// encoded in JSON and BSON as the bare value: a string, a number, a
+// boolean, a list of strings, a list of FileRefs or an object mapping rows
+// to lists of columns.
+type Answer struct {
+	Kind    AnswerKind
+	Text    string
+	Number  float64
+	Bool    bool
+	Choices []string
+	Files   []FileRef
+	Matrix  map[string][]string
+}
+
+// TextAnswer returns a text answer
+func TextAnswer(s string) Answer { return Answer{Kind: AnswerText, Text: s} }
+
+// NumberAnswer returns a numeric answer
+func NumberAnswer(n float64) Answer { return Answer{Kind: AnswerNumber, Number: n} }
+
+// BoolAnswer returns the answer to a yes/no field
+func BoolAnswer(b bool) Answer { return Answer{Kind: AnswerBool, Bool: b} }
+
+// ChoicesAnswer returns the options picked in a checkbox field
+func ChoicesAnswer(picked []string) Answer { return Answer{Kind: AnswerChoices, Choices: picked} }
+
+// FilesAnswer returns the files uploaded to a file field
+func FilesAnswer(files []FileRef) Answer { return Answer{Kind: AnswerFiles, Files: files} }
+
+// MatrixAnswer returns the columns picked in each row of a matrix field
+func MatrixAnswer(rows map[string][]string) Answer { return Answer{Kind: AnswerMatrix, Matrix: rows} }
+
+// NewAnswer converts a submitted value to the answer type of a field of
+// type t. Checkbox answers may be lists or comma-joined strings, as they
+// were stored before answers were typed; strings are split on every comma,
+// so use TypedAnswers or Field.SplitChoices when the field's options are
+// known. Rankings must be lists. A matrix
+// row may be answered with a single column or a list of them. Yes/no
+// answers may be booleans or the strings "true", "false", "yes" and "no",
+// in any case. A value that
+// doesn't fit the field, or a field type of "", is typed by its own shape.
+// ok is false for empty values.
+func NewAnswer(t FieldType, v interface{}) (a Answer, ok bool) {
+	switch t {
+	case FieldTypeNumber, FieldTypeRating, FieldTypeNPS, FieldTypeSlider:
+		if n, ok := answerFloat(v); ok {
+			return NumberAnswer(n), true
+		}
+	case FieldTypeBoolean:
+		if b, ok := answerBool(v); ok {
+			return BoolAnswer(b), true
+		}
+	case FieldTypeCheckbox:
+		var picked []string
+		if list, ok := answerList(v); ok {
+			picked = list
+		} else if s, ok := v.(string); ok {
+			picked = strings.Split(s, ",")
+		}
+		var out []string
+		for _, p := range picked {
+			if p = strings.TrimSpace(p); p != "" {
+				out = append(out, p)
+			}
+		}
+		if picked != nil {
+			return ChoicesAnswer(out), len(out) > 0
+		}
+	case FieldTypeFile:
+		if files, ok := answerFiles(v); ok {
+			return FilesAnswer(files), len(files) > 0
+		}
+	case FieldTypeRanking:
+		if list, ok := answerList(v); ok {
+			var out []string
+			for _, p := range list {
+				if p = strings.TrimSpace(p); p != "" {
+					out = append(out, p)
+				}
+			}
+			return ChoicesAnswer(out), len(out) > 0
+		}
+	case FieldTypeMatrix:
+		if rows, ok := answerMatrix(v); ok {
+			return MatrixAnswer(rows), len(rows) > 0
+		}
+	}
+
+	switch x := v.(type) {
+	case nil:
+		return Answer{}, false
+	case string:
+		return TextAnswer(x), x != ""
+	case bool:
+		return BoolAnswer(x), true
+	case float64, float32, int, int32, int64:
+		n, _ := answerFloat(x)
+		return NumberAnswer(n), true
+	}
+	if files, ok := answerFiles(v); ok {
+		return FilesAnswer(files), len(files) > 0
+	}
+	if rows, ok := answerMatrix(v); ok {
+		return MatrixAnswer(rows), len(rows) > 0
+	}
+	if list, ok := answerList(v); ok {
+		return ChoicesAnswer(list), len(list) > 0
+	}
+	return TextAnswer(fmt.Sprint(v)), true
+}
+
+// IsEmpty reports whether the answer holds nothing
+func (a Answer) IsEmpty() bool {
+	switch a.Kind {
+	case AnswerText:
+		return strings.TrimSpace(a.Text) == ""
+	case AnswerNumber, AnswerBool:
+		return false
+	case AnswerChoices:
+		return len(a.Choices) == 0
+	case AnswerFiles:
+		return len(a.Files) == 0
+	case AnswerMatrix:
+		return len(a.Matrix) == 0
+	}
+	return true
+}
+
+// Value returns the answer as a plain Go value: a string, a float64, a
+// bool, a []string, a []FileRef or a map[string][]string; nil when empty
+func (a Answer) Value() interface{} {
+	switch a.Kind {
+	case AnswerText:
+		return a.Text
+	case AnswerNumber:
+		return a.Number
+	case AnswerBool:
+		return a.Bool
+	case AnswerChoices:
+		return append([]string{}, a.Choices...)
+	case AnswerFiles:
+		return append([]FileRef{}, a.Files...)
+	case AnswerMatrix:
+		rows := make(map[string][]string, len(a.Matrix))
+		for row, cols := range a.Matrix {
+			rows[row] = append([]string{}, cols...)
+		}
+		return rows
+	}
+	return nil
+}
+
+// String formats the answer for display and export. Picked options, and
+// the names of uploaded files, are joined with "; " since they may contain
+// commas. Matrix rows are listed as "row: column", in row order.
+func (a Answer) String() string {
+	switch a.Kind {
+	case AnswerText:
+		return a.Text
+	case AnswerNumber:
+		return strconv.FormatFloat(a.Number, 'f', -1, 64)
+	case AnswerBool:
+		return strconv.FormatBool(a.Bool)
+	case AnswerChoices:
+		return strings.Join(a.Choices, "; ")
+	case AnswerFiles:
+		names := make([]string, len(a.Files))
+		for i, f := range a.Files {
+			names[i] = f.Name
+		}
+		return strings.Join(names, "; ")
+	case AnswerMatrix:
+		rows := make([]string, 0, len(a.Matrix))
+		for row := range a.Matrix {
+			rows = append(rows, row)
+		}
+		sort.Strings(rows)
+		for i, row := range rows {
+			rows[i] = row + ": " + a.Row(row)
+		}
+		return strings.Join(rows, "; ")
+	}
+	return ""
+}
+
+// Row formats the columns picked in one row of a matrix answer, joined
+// with "; "
+func (a Answer) Row(row string) string {
+	return strings.Join(a.Matrix[row], "; ")
+}
+
+func (a Answer) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.Value())
+}
+
+func (a *Answer) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	*a, _ = NewAnswer("", v)
+	return nil
+}
+
+func (a Answer) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bson.MarshalValue(a.Value())
+}
+
+func (a *Answer) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	var v interface{}
+	if err := (bson.RawValue{Type: t, Value: data}).Unmarshal(&v); err != nil {
+		return err
+	}
+	*a, _ = NewAnswer("", v)
+	return nil
+}
+
+// TypedAnswers converts submitted answers to stored ones, typed by the
+// fields they answer. Comma-joined checkbox answers are split with
+// Field.SplitChoices so options containing commas survive. Dates and times are rewritten in their stored
+// layouts and phone numbers in E.164. Answers to fields not in fields are typed by their shape; empty
+// answers are left out.
+func TypedAnswers(fields []Field, answers map[string]interface{}) map[string]Answer {
+	byID := make(map[string]Field, len(fields))
+	for _, f := range fields {
+		byID[f.ID] = f
+	}
+	out := make(map[string]Answer, len(answers))
+	for id, v := range answers {
+		f := byID[id]
+		if s, ok := v.(string); ok && f.Type == FieldTypeCheckbox {
+			v = f.SplitChoices(s)
+		}
+		a, ok := NewAnswer(f.Type, v)
+		if !ok {
+			continue
+		}
+		if f.Type.IsTemporal() && a.Kind == AnswerText {
+			if t, err := f.ParseTemporal(a.Text); err == nil {
+				a.Text = f.FormatTemporal(t)
+			}
+		}
+		if f.Type == FieldTypePhone && a.Kind == AnswerText {
+			if phone, err := f.ParsePhone(a.Text); err == nil {
+				a.Text = phone
+			}
+		}
+		out[id] = a
+	}
+	return out
+}
+
+// SplitChoices splits a comma-joined checkbox answer into the options it
+// picks. At each position the longest of the field's options that runs up
+// to the next comma, or the end, is taken whole, so options containing
+// commas stay intact; anything else is split on commas.
+func (f Field) SplitChoices(s string) []string {
+	options := make([]string, 0, len(f.Options))
+	for _, o := range f.Options {
+		if o = strings.TrimSpace(o); o != "" {
+			options = append(options, o)
+		}
+	}
+	sort.SliceStable(options, func(i, j int) bool { return len(options[i]) > len(options[j]) })
+
+	var out []string
+	for rest := s; ; {
+		rest = strings.TrimLeft(rest, " \t")
+		part := ""
+		for _, o := range options {
+			if tail := strings.TrimPrefix(rest, o); len(tail) < len(rest) {
+				if tail = strings.TrimLeft(tail, " \t"); tail == "" || tail[0] == ',' {
+					part, rest = o, tail
+					break
+				}
+			}
+		}
+		if part == "" {
+			i := strings.IndexByte(rest, ',')
+			if i < 0 {
+				i = len(rest)
+			}
+			part, rest = strings.TrimSpace(rest[:i]), rest[i:]
+		}
+		if part != "" {
+			out = append(out, part)
+		}
+		if rest == "" {
+			return out
+		}
+		rest = rest[1:]
+	}
+}
+
+func answerList(v interface{}) ([]string, bool) {
+	var items []interface{}
+	switch x := v.(type) {
+	case []string:
+		return x, true
+	case []interface{}:
+		items = x
+	case primitive.A:
+		items = x
+	default:
+		return nil, false
+	}
+	out := make([]string, 0, len(items))
+	for _, it := range items {
+		if s, ok := it.(string); ok {
+			out = append(out, s)
+		} else {
+			out = append(out, fmt.Sprint(it))
+		}
+	}
+	return out, true
+}
+
+// answerFiles reads a list of FileRefs, as given by the upload handler or
+// decoded from JSON or BSON. Lists of anything but file references don't
+// match.
+func answerFiles(v interface{}) ([]FileRef, bool) {
+	var items []interface{}
+	switch x := v.(type) {
+	case []FileRef:
+		return x, true
+	case []interface{}:
+		items = x
+	case primitive.A:
+		items = x
+	default:
+		return nil, false
+	}
+	if len(items) == 0 {
+		return nil, false
+	}
+	out := make([]FileRef, 0, len(items))
+	for _, it := range items {
+		var m map[string]interface{}
+		switch x := it.(type) {
+		case map[string]interface{}:
+			m = x
+		case primitive.M:
+			m = x
+		case primitive.D:
+			m = x.Map()
+		default:
+			return nil, false
+		}
+		id, _ := m["id"].(string)
+		if id == "" {
+			return nil, false
+		}
+		ref := FileRef{ID: id}
+		ref.Name, _ = m["name"].(string)
+		ref.ContentType, _ = m["contentType"].(string)
+		if n, ok := answerFloat(m["size"]); ok {
+			ref.Size = int64(n)
+		}
+		out = append(out, ref)
+	}
+	return out, true
+}
+
+// answerMatrix reads an object mapping rows to a column or a list of
+// columns. Rows left blank are dropped.
+func answerMatrix(v interface{}) (map[string][]string, bool) {
+	var m map[string]interface{}
+	switch x := v.(type) {
+	case map[string][]string:
+		m = make(map[string]interface{}, len(x))
+		for k, cols := range x {
+			m[k] = cols
+		}
+	case map[string]interface{}:
+		m = x
+	case primitive.M:
+		m = x
+	case primitive.D:
+		m = x.Map()
+	default:
+		return nil, false
+	}
+	out := make(map[string][]string, len(m))
+	for row, val := range m {
+		var cols []string
+		if s, ok := val.(string); ok {
+			cols = []string{s}
+		} else if list, ok := answerList(val); ok {
+			cols = list
+		} else if val != nil {
+			cols = []string{fmt.Sprint(val)}
+		}
+		var picked []string
+		for _, c := range cols {
+			if c = strings.TrimSpace(c); c != "" {
+				picked = append(picked, c)
+			}
+		}
+		if len(picked) > 0 {
+			out[row] = picked
+		}
+	}
+	return out, true
+}
+
+// answerBool reads a yes/no answer
+func answerBool(v interface{}) (bool, bool) {
+	switch x := v.(type) {
+	case bool:
+		return x, true
+	case string:
+		switch strings.ToLower(strings.TrimSpace(x)) {
+		case "true", "yes":
+			return true, true
+		case "false", "no":
+			return false, true
+		}
+	}
+	return false, false
+}
+
+func answerFloat(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case float32:
+		return float64(x), true
+	case int:
+		return float64(x), true
+	case int32:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestSplitChoices(t *testing.T) {
+	field := Field{Type: FieldTypeCheckbox, Options: []string{"Red, dark", "Red", "Blue"}}
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"Red, dark, Blue", []string{"Red, dark", "Blue"}},
+		{"Red,Blue", []string{"Red", "Blue"}},
+		{" Red, dark ", []string{"Red, dark"}},
+		{"Green, Red, dark", []string{"Green", "Red, dark"}},
+		{"Reddish, Blue", []string{"Reddish", "Blue"}},
+		{"Blue,,", []string{"Blue"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := field.SplitChoices(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitChoices(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTypedAnswers(t *testing.T) {
+	fields := []Field{
+		{ID: "colors", Type: FieldTypeCheckbox, Options: []string{"Red, dark", "Blue"}},
+		{ID: "age", Type: FieldTypeNumber},
+		{ID: "score", Type: FieldTypeRating},
+		{ID: "ok", Type: FieldTypeBoolean},
+		{ID: "day", Type: FieldTypeDate},
+		{ID: "name", Type: FieldTypeText},
+		{ID: "grid", Type: FieldTypeMatrix},
+	}
+	tests := []struct {
+		name string
+		id   string
+		in   interface{}
+		want Answer
+	}{
+		{"comma-joined checkbox", "colors", "Red, dark, Blue", ChoicesAnswer([]string{"Red, dark", "Blue"})},
+		{"checkbox list", "colors", []interface{}{"Blue", " Red, dark "}, ChoicesAnswer([]string{"Blue", "Red, dark"})},
+		{"number from string", "age", "42", NumberAnswer(42)},
+		{"rating from string", "score", " 4 ", NumberAnswer(4)},
+		{"unparseable number kept as text", "age", "lots", TextAnswer("lots")},
+		{"yes/no from string", "ok", "Yes", BoolAnswer(true)},
+		{"date in stored layout", "day", "2024-03-05", TextAnswer("2024-03-05")},
+		{"numeric text stays text", "name", "12", TextAnswer("12")},
+		{"matrix row as one column", "grid", map[string]interface{}{"Food": "Good", "Blank": ""}, MatrixAnswer(map[string][]string{"Food": {"Good"}})},
+		{"unknown field typed by shape", "extra", 3.5, NumberAnswer(3.5)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := TypedAnswers(fields, map[string]interface{}{tt.id: tt.in})[tt.id]
+			if !ok {
+				t.Fatal("answer dropped")
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	empty := TypedAnswers(fields, map[string]interface{}{"colors": " , ", "name": "", "age": nil})
+	if len(empty) != 0 {
+		t.Errorf("empty answers kept: %+v", empty)
+	}
+}
+
+func TestAnswerEncoding(t *testing.T) {
+	answers := map[string]Answer{
+		"text":    TextAnswer("hi"),
+		"number":  NumberAnswer(2.5),
+		"bool":    BoolAnswer(false),
+		"choices": ChoicesAnswer([]string{"a, b", "c"}),
+		"files":   FilesAnswer([]FileRef{{ID: "f1", Name: "cv.pdf", ContentType: "application/pdf", Size: 10}}),
+		"matrix":  MatrixAnswer(map[string][]string{"row": {"x", "y"}}),
+	}
+
+	b, err := json.Marshal(answers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromJSON map[string]Answer
+	if err := json.Unmarshal(b, &fromJSON); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromJSON, answers) {
+		t.Errorf("JSON round trip = %+v, want %+v", fromJSON, answers)
+	}
+
+	raw, err := bson.Marshal(bson.M{"responses": answers})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Responses map[string]Answer `bson:"responses"`
+	}
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(doc.Responses, answers) {
+		t.Errorf("BSON round trip = %+v, want %+v", doc.Responses, answers)
+	}
+}
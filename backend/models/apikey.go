@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Scope limits what an API key may do
+type Scope string
+
+const (
+	ScopeFormsRead     Scope = "forms:read"
+	ScopeFormsWrite    Scope = "forms:write"
+	ScopeResponsesRead Scope = "responses:read"
+)
+
+// Valid reports whether s is a known scope
+func (s Scope) Valid() bool {
+	switch s {
+	case ScopeFormsRead, ScopeFormsWrite, ScopeResponsesRead:
+		return true
+	}
+	return false
+}
+
+// APIKey lets scripts call the API on behalf of a user. Only a hash of the
+// key is stored; the key itself is shown once, when created or rotated.
+type APIKey struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID     primitive.ObjectID `json:"userId" bson:"userId"`
+	Name       string             `json:"name" bson:"name"`
+	Prefix     string             `json:"prefix" bson:"prefix"`
+	KeyHash    string             `json:"-" bson:"keyHash"`
+	Scopes     []Scope            `json:"scopes" bson:"scopes"`
+	CreatedAt  time.Time          `json:"createdAt" bson:"createdAt"`
+	RotatedAt  *time.Time         `json:"rotatedAt,omitempty" bson:"rotatedAt,omitempty"`
+	LastUsedAt *time.Time         `json:"lastUsedAt,omitempty" bson:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time         `json:"revokedAt,omitempty" bson:"revokedAt,omitempty"`
+}
+
+// HasScope reports whether the key was granted s
+func (k *APIKey) HasScope(s Scope) bool {
+	for _, granted := range k.Scopes {
+		if granted == s {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateAPIKeyRequest is the body of POST /api/keys
+type CreateAPIKeyRequest struct {
+	Name   string  `json:"name" validate:"required"`
+	Scopes []Scope `json:"scopes" validate:"required,min=1"`
+}
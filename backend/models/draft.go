@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Draft is a partially filled response a respondent can come back to with
+// its resume token. Required fields aren't enforced until it's submitted.
+// Only a hash of the token is stored.
+type Draft struct {
+	ID        primitive.ObjectID     `json:"id" bson:"_id,omitempty"`
+	FormID    primitive.ObjectID     `json:"formId" bson:"formId"`
+	TokenHash string                 `json:"-" bson:"tokenHash"`
+	Responses map[string]interface{} `json:"responses" bson:"responses"`
+	// PageID is the page of a multi-page form the respondent was on
+	PageID    string    `json:"pageId,omitempty" bson:"pageId,omitempty"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
+	ExpiresAt time.Time `json:"expiresAt" bson:"expiresAt"`
+}
+
+// CreateDraftRequest starts a draft with the answers given so far
+type CreateDraftRequest struct {
+	// Either FormID or Link (a shareable link or slug) names the form
+	FormID    string                 `json:"formId"`
+	Link      string                 `json:"link"`
+	Responses map[string]interface{} `json:"responses"`
+	PageID    string                 `json:"pageId"`
+}
+
+// UpdateDraftRequest merges answers into a draft. A null answer removes
+// it; fields left out are kept.
+type UpdateDraftRequest struct {
+	Responses map[string]interface{} `json:"responses"`
+	PageID    string                 `json:"pageId"`
+}
@@ -17,6 +17,18 @@ const (
 	FieldTypeMultipleChoice FieldType = "multiple_choice"
 	FieldTypeCheckbox       FieldType = "checkbox"
 	FieldTypeRating         FieldType = "rating"
+	FieldTypeDate           FieldType = "date"
+	FieldTypeTime           FieldType = "time"
+	FieldTypeDateTime       FieldType = "datetime"
+	FieldTypeFile           FieldType = "file"
+	FieldTypeMatrix         FieldType = "matrix"
+	FieldTypeNPS            FieldType = "nps"
+	FieldTypeRanking        FieldType = "ranking"
+	FieldTypeDropdown       FieldType = "dropdown"
+	FieldTypeBoolean        FieldType = "boolean"
+	FieldTypeSlider         FieldType = "slider"
+	FieldTypePhone          FieldType = "phone"
+	FieldTypeURL            FieldType = "url"
 )
 
 // Field defines a single field in a form
@@ -32,24 +44,285 @@ type Field struct {
 	MaxValue    *int      `json:"maxValue,omitempty" bson:"maxValue,omitempty"`
 	Min         *int      `json:"min,omitempty" bson:"min,omitempty"`
 	Max         *int      `json:"max,omitempty" bson:"max,omitempty"`
+	// Visibility shows or hides the field depending on other answers
+	Visibility *VisibilityRule `json:"visibility,omitempty" bson:"visibility,omitempty"`
+	// PageID places the field on one of the form's pages; empty means the
+	// first page
+	PageID string `json:"pageId,omitempty" bson:"pageId,omitempty"`
+
+	// Validation rules. Lengths and Pattern apply to text, textarea and
+	// email answers (and URLs); Pattern must match the whole answer. Min
+	// and Max set a rating field's scale, 1 to 5 by default. MinValue,
+	// MaxValue and Step set a slider's range, 0 to 100 in steps of 1 by
+	// default. MinSelections and
+	// MaxSelections also bound how many options a ranking ranks; it must
+	// rank them all unless MinSelections is set.
+	MinLength     *int     `json:"minLength,omitempty" bson:"minLength,omitempty"`
+	MaxLength     *int     `json:"maxLength,omitempty" bson:"maxLength,omitempty"`
+	Pattern       string   `json:"pattern,omitempty" bson:"pattern,omitempty"`
+	MinSelections *int     `json:"minSelections,omitempty" bson:"minSelections,omitempty"`
+	MaxSelections *int     `json:"maxSelections,omitempty" bson:"maxSelections,omitempty"`
+	IntegerOnly   bool     `json:"integerOnly,omitempty" bson:"integerOnly,omitempty"`
+	Step          *float64 `json:"step,omitempty" bson:"step,omitempty"`
+	// Earliest and Latest bound the answers of a date, time or datetime
+	// field and are written like them. Timezone is the IANA zone a
+	// datetime answer or bound without a UTC offset is read in, and its
+	// analytics are bucketed by; UTC by default.
+	Earliest string `json:"earliest,omitempty" bson:"earliest,omitempty"`
+	Latest   string `json:"latest,omitempty" bson:"latest,omitempty"`
+	Timezone string `json:"timezone,omitempty" bson:"timezone,omitempty"`
+	// MaxFiles (1 by default) and MaxFileSize (in bytes, 10 MB by default)
+	// limit the uploads to a file field. Accept lists the MIME types it
+	// takes, such as "application/pdf" or "image/*"; empty means any.
+	MaxFiles    *int     `json:"maxFiles,omitempty" bson:"maxFiles,omitempty"`
+	MaxFileSize *int64   `json:"maxFileSize,omitempty" bson:"maxFileSize,omitempty"`
+	Accept      []string `json:"accept,omitempty" bson:"accept,omitempty"`
+	// Rows and Columns make up a matrix field: each row is answered with
+	// one of the columns, or several when MultiplePerRow is set
+	Rows           []string `json:"rows,omitempty" bson:"rows,omitempty"`
+	Columns        []string `json:"columns,omitempty" bson:"columns,omitempty"`
+	MultiplePerRow bool     `json:"multiplePerRow,omitempty" bson:"multiplePerRow,omitempty"`
+	// CountryCode is the calling code (such as "44") of a phone field's
+	// numbers written without one; their leading 0 is dropped. Without it
+	// numbers must start with + or 00.
+	CountryCode string `json:"countryCode,omitempty" bson:"countryCode,omitempty"`
+	// AllowOther lets a multiple choice, dropdown or checkbox field take a
+	// free-text answer besides its options; it's stored in
+	// FormResponse.Other
+	AllowOther bool `json:"allowOther,omitempty" bson:"allowOther,omitempty"`
+	// Messages replaces the default message of a rule, keyed by its error
+	// code (for example "required" or "too_short")
+	Messages map[string]string `json:"messages,omitempty" bson:"messages,omitempty"`
+}
+
+// TakesOther reports whether the field accepts a free-text "Other" answer:
+// it allows one and is a multiple choice, dropdown or checkbox field
+func (f Field) TakesOther() bool {
+	switch f.Type {
+	case FieldTypeMultipleChoice, FieldTypeDropdown, FieldTypeCheckbox:
+		return f.AllowOther
+	}
+	return false
+}
+
+// Page is a titled section of a multi-page form. Its fields are the form
+// fields carrying its ID, in form order.
+type Page struct {
+	ID          string `json:"id" bson:"id"`
+	Title       string `json:"title" bson:"title"`
+	Description string `json:"description,omitempty" bson:"description,omitempty"`
+}
+
+// ConditionOperator compares an answer with a condition's value
+type ConditionOperator string
+
+const (
+	OpEquals      ConditionOperator = "equals"
+	OpNotEquals   ConditionOperator = "not_equals"
+	OpContains    ConditionOperator = "contains"
+	OpGreaterThan ConditionOperator = "greater_than"
+	OpLessThan    ConditionOperator = "less_than"
+)
+
+// RuleAction is what a visibility rule does when its conditions match
+type RuleAction string
+
+const (
+	ActionShow RuleAction = "show"
+	ActionHide RuleAction = "hide"
+)
+
+// RuleLogic combines a rule's conditions
+type RuleLogic string
+
+const (
+	LogicAnd RuleLogic = "and"
+	LogicOr  RuleLogic = "or"
+)
+
+// Condition tests the answer given to another field
+type Condition struct {
+	FieldID  string            `json:"fieldId" bson:"fieldId"`
+	Operator ConditionOperator `json:"operator" bson:"operator"`
+	Value    interface{}       `json:"value" bson:"value"`
+}
+
+// VisibilityRule shows (or hides) a field only when its conditions match.
+// Action defaults to "show" and Logic to "and".
+type VisibilityRule struct {
+	Action     RuleAction  `json:"action,omitempty" bson:"action,omitempty"`
+	Logic      RuleLogic   `json:"logic,omitempty" bson:"logic,omitempty"`
+	Conditions []Condition `json:"conditions" bson:"conditions"`
+}
+
+// FormStatus is a form's place in its lifecycle. Only published forms
+// accept responses; a form without a status predates lifecycles and counts
+// as published.
+type FormStatus string
+
+const (
+	StatusDraft     FormStatus = "draft"
+	StatusPublished FormStatus = "published"
+	StatusClosed    FormStatus = "closed"
+	StatusArchived  FormStatus = "archived"
+)
+
+// Valid reports whether s is a known status
+func (s FormStatus) Valid() bool {
+	switch s {
+	case StatusDraft, StatusPublished, StatusClosed, StatusArchived:
+		return true
+	}
+	return false
 }
 
+// Codes returned with the error when a form isn't accepting responses
+const (
+	CodeFormNotPublished = "form_not_published"
+	CodeFormNotOpenYet   = "form_not_open_yet"
+	CodeFormClosed       = "form_closed"
+	CodeFormArchived     = "form_archived"
+	CodeResponseLimit    = "response_limit_reached"
+)
+
 // Form is the top-level entity users create
 type Form struct {
 	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	Title         string             `json:"title" bson:"title"`
 	Description   string             `json:"description" bson:"description"`
 	Fields        []Field            `json:"fields" bson:"fields"`
-	ShareableLink string             `json:"shareableLink" bson:"shareableLink"`
+	Pages         []Page             `json:"pages,omitempty" bson:"pages,omitempty"`
+	Revision      int                `json:"revision" bson:"revision"`
+	Status        FormStatus         `json:"status" bson:"status,omitempty"`
+	ShareableLink string             `json:"shareableLink" bson:"shareableLink"` // empty once revoked
+	Slug          string             `json:"slug,omitempty" bson:"slug,omitempty"`
+	OwnerID       primitive.ObjectID `json:"ownerId" bson:"ownerId,omitempty"`
+	WorkspaceID   primitive.ObjectID `json:"workspaceId" bson:"workspaceId,omitempty"`
 	CreatedAt     time.Time          `json:"createdAt" bson:"createdAt"`
 	UpdatedAt     time.Time          `json:"updatedAt" bson:"updatedAt"`
+
+	// OpensAt and ClosesAt optionally limit when a published form accepts
+	// responses, and MaxResponses how many it accepts (0 for no limit)
+	OpensAt      *time.Time `json:"opensAt,omitempty" bson:"opensAt,omitempty"`
+	ClosesAt     *time.Time `json:"closesAt,omitempty" bson:"closesAt,omitempty"`
+	MaxResponses int        `json:"maxResponses,omitempty" bson:"maxResponses,omitempty"`
+	// ClosedMessage replaces the default message shown to respondents
+	// while the form isn't accepting responses
+	ClosedMessage string `json:"closedMessage,omitempty" bson:"closedMessage,omitempty"`
+	// LinkOnly hides the form's ID-based routes from respondents: it can
+	// only be read and answered through its shareable link or slug
+	LinkOnly bool `json:"linkOnly,omitempty" bson:"linkOnly,omitempty"`
+	// Lenient turns strict mode off: answers to fields the form doesn't
+	// have are stored as sent and choice answers needn't be listed options
+	Lenient bool `json:"lenient,omitempty" bson:"lenient,omitempty"`
+}
+
+// PublicForm is what respondents see of a form: its definition and the
+// handles it's shared under, without its ID, ownership, limits or
+// timestamps
+type PublicForm struct {
+	Title         string     `json:"title"`
+	Description   string     `json:"description"`
+	Fields        []Field    `json:"fields"`
+	Pages         []Page     `json:"pages,omitempty"`
+	ShareableLink string     `json:"shareableLink,omitempty"`
+	Slug          string     `json:"slug,omitempty"`
+	ClosesAt      *time.Time `json:"closesAt,omitempty"`
+}
+
+// Public returns the respondent-facing view of the form
+func (f *Form) Public() PublicForm {
+	return PublicForm{
+		Title:         f.Title,
+		Description:   f.Description,
+		Fields:        f.Fields,
+		Pages:         f.Pages,
+		ShareableLink: f.ShareableLink,
+		Slug:          f.Slug,
+		ClosesAt:      f.ClosesAt,
+	}
+}
+
+// Closure reports why the form isn't accepting responses at now, as one of
+// the Code constants, or "" if it is. responses is the number already
+// submitted and only matters when MaxResponses is set.
+func (f *Form) Closure(now time.Time, responses int) string {
+	switch f.Status {
+	case StatusDraft:
+		return CodeFormNotPublished
+	case StatusClosed:
+		return CodeFormClosed
+	case StatusArchived:
+		return CodeFormArchived
+	}
+	switch {
+	case f.OpensAt != nil && now.Before(*f.OpensAt):
+		return CodeFormNotOpenYet
+	case f.ClosesAt != nil && !now.Before(*f.ClosesAt):
+		return CodeFormClosed
+	case f.MaxResponses > 0 && responses >= f.MaxResponses:
+		return CodeResponseLimit
+	}
+	return ""
+}
+
+// ClosedText is the message shown to respondents for a Closure code
+func (f *Form) ClosedText(code string) string {
+	if f.ClosedMessage != "" && code != CodeFormNotPublished {
+		return f.ClosedMessage
+	}
+	switch code {
+	case CodeFormNotPublished:
+		return "This form hasn't been published yet"
+	case CodeFormNotOpenYet:
+		return "This form isn't open for responses yet"
+	case CodeResponseLimit:
+		return "This form has reached its maximum number of responses"
+	}
+	return "This form is no longer accepting responses"
+}
+
+// PageFields returns the fields shown on the given page, or nil if the form
+// has no such page
+func (f *Form) PageFields(pageID string) []Field {
+	idx := f.PageIndex(pageID)
+	if idx < 0 {
+		return nil
+	}
+	out := []Field{}
+	for _, field := range f.Fields {
+		if f.PageIndex(field.PageID) == idx || (field.PageID == "" && idx == 0) {
+			out = append(out, field)
+		}
+	}
+	return out
+}
+
+// PageIndex returns the position of a page in the form, or -1
+func (f *Form) PageIndex(pageID string) int {
+	for i, p := range f.Pages {
+		if p.ID == pageID {
+			return i
+		}
+	}
+	return -1
 }
 
 // FormResponse represents a submitted response
 type FormResponse struct {
 	ID          primitive.ObjectID     `json:"id" bson:"_id,omitempty"`
 	FormID      primitive.ObjectID     `json:"formId" bson:"formId"`
-	Responses   map[string]interface{} `json:"responses" bson:"responses"`
+	Responses   map[string]Answer      `json:"responses" bson:"responses"`
+	// Hidden lists the fields conditional rules hid from the respondent
+	Hidden      []string               `json:"hidden,omitempty" bson:"hidden,omitempty"`
+	// DraftID is set when the response was completed from a saved draft
+	DraftID     primitive.ObjectID     `json:"draftId,omitempty" bson:"draftId,omitempty"`
+	// Revision is the form revision the response was submitted against;
+	// 0 for responses older than revisions, which belong to revision 1
+	Revision    int                    `json:"revision" bson:"revision"`
+	// Other holds the free-text "Other" answers of fields that allow them,
+	// kept out of Responses so they don't count as options
+	Other       map[string]string      `json:"other,omitempty" bson:"other,omitempty"`
 	SubmittedAt time.Time              `json:"submittedAt" bson:"submittedAt"`
 }
 
@@ -66,11 +339,92 @@ type FieldStats struct {
 	FieldLabel         string            `json:"fieldLabel" bson:"fieldLabel"`
 	FieldType          FieldType         `json:"fieldType" bson:"fieldType"`
 	ResponseCount      int               `json:"responseCount" bson:"responseCount"`
+	SkipCount          int               `json:"skipCount" bson:"skipCount"`
+	HiddenCount        int               `json:"hiddenCount" bson:"hiddenCount"`
 	AverageRating      *float64          `json:"averageRating,omitempty" bson:"averageRating,omitempty"`
 	RatingDistribution map[string]int    `json:"ratingDistribution,omitempty" bson:"ratingDistribution,omitempty"`
 	OptionCounts       map[string]int    `json:"optionCounts,omitempty" bson:"optionCounts,omitempty"`
 	TextResponses      []string          `json:"textResponses,omitempty" bson:"textResponses,omitempty"`
 	NumberSummary      *NumberSummary    `json:"numberSummary,omitempty" bson:"numberSummary,omitempty"`
+	// OtherCount and OtherResponses (the latest ones) cover the free-text
+	// answers of a field that allows "Other"
+	OtherCount     int          `json:"otherCount,omitempty" bson:"otherCount,omitempty"`
+	OtherResponses []string     `json:"otherResponses,omitempty" bson:"otherResponses,omitempty"`
+	DateSummary    *DateSummary `json:"dateSummary,omitempty" bson:"dateSummary,omitempty"`
+	// FileCount is the number of files uploaded to a file field
+	FileCount int `json:"fileCount,omitempty" bson:"fileCount,omitempty"`
+	// RowCounts counts the columns picked in each row of a matrix field
+	RowCounts map[string]map[string]int `json:"rowCounts,omitempty" bson:"rowCounts,omitempty"`
+	NPS       *NPSSummary               `json:"nps,omitempty" bson:"nps,omitempty"`
+	Ranking   []RankStats               `json:"ranking,omitempty" bson:"ranking,omitempty"`
+	Boolean   *BooleanSummary           `json:"boolean,omitempty" bson:"boolean,omitempty"`
+	// Histogram buckets a slider's answers over its range
+	Histogram []HistogramBin `json:"histogram,omitempty" bson:"histogram,omitempty"`
+}
+
+// BooleanSummary counts the answers of a yes/no field. TrueRatio is the
+// share of yes answers.
+type BooleanSummary struct {
+	True      int     `json:"true" bson:"true"`
+	False     int     `json:"false" bson:"false"`
+	TrueRatio float64 `json:"trueRatio" bson:"trueRatio"`
+}
+
+// HistogramBin counts the answers from From up to To; the last bin
+// includes To
+type HistogramBin struct {
+	From  float64 `json:"from" bson:"from"`
+	To    float64 `json:"to" bson:"to"`
+	Count int     `json:"count" bson:"count"`
+}
+
+// RankStats sums up how one option of a ranking field was ranked.
+// AverageRank (1 is first) covers the answers that ranked the option.
+// Borda gives each answer's options n-1 points for first place down to 0
+// for last (and unranked), n being the number of options. FirstChoices
+// counts the answers ranking it first, FirstChoiceShare their share of
+// all answers.
+type RankStats struct {
+	Option           string   `json:"option" bson:"option"`
+	AverageRank      *float64 `json:"averageRank,omitempty" bson:"averageRank,omitempty"`
+	Borda            int      `json:"borda" bson:"borda"`
+	FirstChoices     int      `json:"firstChoices" bson:"firstChoices"`
+	FirstChoiceShare float64  `json:"firstChoiceShare" bson:"firstChoiceShare"`
+}
+
+// NPSSummary describes the answers of an NPS field. Promoters answered 9
+// or 10, passives 7 or 8 and detractors 0 to 6. Score is the percentage
+// of promoters minus that of detractors, from -100 to 100; Low and High
+// bound its 95% confidence interval. Distribution counts each score and
+// Trend gives the score per day.
+type NPSSummary struct {
+	Promoters    int            `json:"promoters" bson:"promoters"`
+	Passives     int            `json:"passives" bson:"passives"`
+	Detractors   int            `json:"detractors" bson:"detractors"`
+	Score        float64        `json:"score" bson:"score"`
+	Low          float64        `json:"low" bson:"low"`
+	High         float64        `json:"high" bson:"high"`
+	Distribution map[string]int `json:"distribution" bson:"distribution"`
+	Trend        []NPSPoint     `json:"trend,omitempty" bson:"trend,omitempty"`
+}
+
+// NPSPoint is the NPS of the answers given on one day
+type NPSPoint struct {
+	Date      string  `json:"date" bson:"date"`
+	Score     float64 `json:"score" bson:"score"`
+	Responses int     `json:"responses" bson:"responses"`
+}
+
+// DateSummary describes the answers of a date, time or datetime field:
+// the earliest and latest, in their stored layouts, and for dates and
+// datetimes the answers per day, ISO week (YYYY-Www) and month (YYYY-MM).
+// Datetimes are bucketed in the field's timezone.
+type DateSummary struct {
+	Earliest string         `json:"earliest" bson:"earliest"`
+	Latest   string         `json:"latest" bson:"latest"`
+	ByDay    map[string]int `json:"byDay,omitempty" bson:"byDay,omitempty"`
+	ByWeek   map[string]int `json:"byWeek,omitempty" bson:"byWeek,omitempty"`
+	ByMonth  map[string]int `json:"byMonth,omitempty" bson:"byMonth,omitempty"`
 }
 
 // Trend/extra types
@@ -99,6 +453,181 @@ type Analytics struct {
 	RatingOverTime  []RatingPoint         `json:"ratingOverTime,omitempty" bson:"ratingOverTime,omitempty"`
 	MostSkipped     []MostSkippedItem     `json:"mostSkipped,omitempty" bson:"mostSkipped,omitempty"`
 	TopOptions      map[string]TopOption  `json:"topOptions,omitempty" bson:"topOptions,omitempty"`
+	PageDropOff     []PageStats           `json:"pageDropOff,omitempty" bson:"pageDropOff,omitempty"`
+	Completion      CompletionStats       `json:"completion" bson:"completion"`
+}
+
+// Redacted returns the analytics without the text and "Other" answers
+// they sample, for roles that may view analytics but not read responses
+func (a Analytics) Redacted() Analytics {
+	fields := make(map[string]FieldStats, len(a.FieldAnalytics))
+	for id, fs := range a.FieldAnalytics {
+		fs.TextResponses, fs.OtherResponses = nil, nil
+		fields[id] = fs
+	}
+	a.FieldAnalytics = fields
+	return a
+}
+
+// CompletionStats compares how many respondents started a form with how
+// many submitted it. Saving a draft starts a response; a direct submission
+// starts and completes one at once.
+type CompletionStats struct {
+	Started        int     `json:"started" bson:"started"`
+	Completed      int     `json:"completed" bson:"completed"`
+	CompletionRate float64 `json:"completionRate" bson:"completionRate"`
+}
+
+// PageStats reports how many respondents reached and completed a page of a
+// multi-page form
+type PageStats struct {
+	PageID      string  `json:"pageId" bson:"pageId"`
+	Title       string  `json:"title" bson:"title"`
+	Reached     int     `json:"reached" bson:"reached"`
+	Completed   int     `json:"completed" bson:"completed"`
+	DropOff     int     `json:"dropOff" bson:"dropOff"`
+	DropOffRate float64 `json:"dropOffRate" bson:"dropOffRate"`
+}
+
+// FieldAggregate holds the running totals for one field
+type FieldAggregate struct {
+	ResponseCount      int            `json:"responseCount" bson:"responseCount"`
+	SkipCount          int            `json:"skipCount" bson:"skipCount"`
+	HiddenCount        int            `json:"hiddenCount" bson:"hiddenCount"`
+	OptionCounts       map[string]int `json:"optionCounts,omitempty" bson:"optionCounts,omitempty"`
+	Sum                float64        `json:"sum" bson:"sum"`
+	Min                *float64       `json:"min,omitempty" bson:"min,omitempty"`
+	Max                *float64       `json:"max,omitempty" bson:"max,omitempty"`
+	RatingDistribution map[string]int `json:"ratingDistribution,omitempty" bson:"ratingDistribution,omitempty"`
+	TextResponses      []string       `json:"textResponses,omitempty" bson:"textResponses,omitempty"`
+	OtherCount         int            `json:"otherCount,omitempty" bson:"otherCount,omitempty"`
+	OtherResponses     []string       `json:"otherResponses,omitempty" bson:"otherResponses,omitempty"`
+	// Earliest and Latest are the extreme answers of a date, time or
+	// datetime field; DateCounts counts date and datetime answers per day
+	Earliest   string         `json:"earliest,omitempty" bson:"earliest,omitempty"`
+	Latest     string         `json:"latest,omitempty" bson:"latest,omitempty"`
+	DateCounts map[string]int `json:"dateCounts,omitempty" bson:"dateCounts,omitempty"`
+	// RowCounts counts the columns picked per row of a matrix field
+	RowCounts map[string]map[string]int `json:"rowCounts,omitempty" bson:"rowCounts,omitempty"`
+	// NPSDays counts an NPS field's answers per day, for its trend; the
+	// scores themselves are counted in RatingDistribution
+	NPSDays map[string]*NPSCounts `json:"npsDays,omitempty" bson:"npsDays,omitempty"`
+	// Ranks accumulates the places given to each option of a ranking field
+	Ranks map[string]*RankCounts `json:"ranks,omitempty" bson:"ranks,omitempty"`
+	// Bins counts a slider's answers per histogram bin; yes/no answers
+	// are counted in OptionCounts, as "true" and "false"
+	Bins []int `json:"bins,omitempty" bson:"bins,omitempty"`
+}
+
+// RankCounts accumulates the places given to one option of a ranking
+// field: the sum and number of its ranks, its Borda points and how often
+// it came first
+type RankCounts struct {
+	RankSum int `json:"rankSum" bson:"rankSum"`
+	Ranked  int `json:"ranked" bson:"ranked"`
+	Borda   int `json:"borda" bson:"borda"`
+	First   int `json:"first" bson:"first"`
+}
+
+// NPSCounts counts the promoters, passives and detractors among some
+// answers of an NPS field
+type NPSCounts struct {
+	Promoters  int `json:"promoters" bson:"promoters"`
+	Passives   int `json:"passives" bson:"passives"`
+	Detractors int `json:"detractors" bson:"detractors"`
+}
+
+// Add counts n answers of score
+func (c *NPSCounts) Add(score, n int) {
+	switch {
+	case score >= 9:
+		c.Promoters += n
+	case score >= 7:
+		c.Passives += n
+	default:
+		c.Detractors += n
+	}
+}
+
+// DayBucket accumulates submissions made on one day
+type DayBucket struct {
+	Responses   int     `json:"responses" bson:"responses"`
+	RatingSum   float64 `json:"ratingSum" bson:"ratingSum"`
+	RatingCount int     `json:"ratingCount" bson:"ratingCount"`
+}
+
+// FormAggregate is the running analytics state of a form, updated one
+// response at a time. SchemaHash identifies the field list it was built
+// for, so a changed form definition can be detected and rebuilt.
+type FormAggregate struct {
+	FormID         primitive.ObjectID         `json:"formId" bson:"_id"`
+	SchemaHash     string                     `json:"schemaHash" bson:"schemaHash"`
+	TotalResponses int                        `json:"totalResponses" bson:"totalResponses"`
+	Fields         map[string]*FieldAggregate `json:"fields" bson:"fields"`
+	Days           map[string]*DayBucket      `json:"days" bson:"days"` // keyed by YYYY-MM-DD
+	// DraftsCompleted counts the responses submitted from a draft
+	DraftsCompleted int `json:"draftsCompleted" bson:"draftsCompleted"`
+	// PageCompletions counts, per page ID, the respondents who passed a
+	// page of a multi-page form, and DraftsStarted the drafts created.
+	// Neither can be derived from the responses, so rebuilds carry them
+	// over.
+	PageCompletions map[string]int `json:"pageCompletions,omitempty" bson:"pageCompletions,omitempty"`
+	DraftsStarted   int            `json:"draftsStarted" bson:"draftsStarted"`
+	UpdatedAt       time.Time      `json:"updatedAt" bson:"updatedAt"`
+}
+
+// FieldDelta describes how a single response changed a field's analytics
+type FieldDelta struct {
+	FieldID          string         `json:"fieldId"`
+	Skipped          bool           `json:"skipped,omitempty"`
+	Hidden           bool           `json:"hidden,omitempty"`
+	ResponseCount    int            `json:"responseCount"`
+	OptionIncrements map[string]int `json:"optionIncrements,omitempty"`
+	RatingBucket     string         `json:"ratingBucket,omitempty"`
+	AverageRating    *float64       `json:"averageRating,omitempty"`
+	NumberSummary    *NumberSummary `json:"numberSummary,omitempty"`
+	MinChanged       bool           `json:"minChanged,omitempty"`
+	MaxChanged       bool           `json:"maxChanged,omitempty"`
+	TextResponse     string         `json:"textResponse,omitempty"`
+	OtherResponse    string         `json:"otherResponse,omitempty"`
+	DateSummary      *DateSummary   `json:"dateSummary,omitempty"`
+	FileCount        int            `json:"fileCount,omitempty"`
+	// RowIncrements are the columns picked per row of a matrix field
+	RowIncrements map[string]map[string]int `json:"rowIncrements,omitempty"`
+	// NPS is the updated summary of an NPS field, without its trend;
+	// NPSPoint is the updated score of the day
+	NPS      *NPSSummary `json:"nps,omitempty"`
+	NPSPoint *NPSPoint   `json:"npsPoint,omitempty"`
+	Ranking  []RankStats `json:"ranking,omitempty"`
+	// Boolean and Histogram are the updated summaries of a yes/no field
+	// and a slider
+	Boolean   *BooleanSummary `json:"boolean,omitempty"`
+	Histogram []HistogramBin  `json:"histogram,omitempty"`
+}
+
+// AnalyticsDelta is pushed to dashboards after each submission so they can
+// update without refetching the full analytics
+type AnalyticsDelta struct {
+	FormID         primitive.ObjectID    `json:"formId"`
+	ResponseID     primitive.ObjectID    `json:"responseId"`
+	SubmittedAt    time.Time             `json:"submittedAt"`
+	TotalResponses int                   `json:"totalResponses"`
+	Fields         map[string]FieldDelta `json:"fields"`
+	Skipped        []string              `json:"skipped"`
+	Hidden         []string              `json:"hidden"`
+	RatingPoint    *RatingPoint          `json:"ratingPoint,omitempty"`
+}
+
+// Redacted returns the delta without the text and "Other" answers it
+// adds, for roles that may view analytics but not read responses
+func (d AnalyticsDelta) Redacted() AnalyticsDelta {
+	fields := make(map[string]FieldDelta, len(d.Fields))
+	for id, fd := range d.Fields {
+		fd.TextResponse, fd.OtherResponse = "", ""
+		fields[id] = fd
+	}
+	d.Fields = fields
+	return d
 }
 
 // Create/Update/Submit request DTOs
@@ -106,15 +635,71 @@ type CreateFormRequest struct {
 	Title       string  `json:"title" validate:"required"`
 	Description string  `json:"description"`
 	Fields      []Field `json:"fields" validate:"required,min=1"`
+	Pages       []Page  `json:"pages"`
+	// WorkspaceID optionally creates the form inside a workspace
+	WorkspaceID string `json:"workspaceId"`
+	// Status defaults to draft; the remaining fields are as in
+	// UpdateFormStatusRequest
+	Status        FormStatus `json:"status"`
+	OpensAt       *time.Time `json:"opensAt"`
+	ClosesAt      *time.Time `json:"closesAt"`
+	MaxResponses  int        `json:"maxResponses"`
+	ClosedMessage string     `json:"closedMessage"`
+	// LinkOnly is as in UpdateFormAccessRequest, Lenient as in
+	// UpdateStrictModeRequest
+	LinkOnly bool `json:"linkOnly"`
+	Lenient  bool `json:"lenient"`
 }
 
 type UpdateFormRequest struct {
 	Title       string  `json:"title" validate:"required"`
 	Description string  `json:"description"`
 	Fields      []Field `json:"fields" validate:"required,min=1"`
+	Pages       []Page  `json:"pages"`
+}
+
+// UpdateFormStatusRequest replaces a form's status and schedule; omitted
+// fields are cleared
+type UpdateFormStatusRequest struct {
+	Status        FormStatus `json:"status"`
+	OpensAt       *time.Time `json:"opensAt"`
+	ClosesAt      *time.Time `json:"closesAt"`
+	MaxResponses  int        `json:"maxResponses"`
+	ClosedMessage string     `json:"closedMessage"`
+}
+
+// UpdateFormSlugRequest sets a form's custom slug; an empty slug removes it
+type UpdateFormSlugRequest struct {
+	Slug string `json:"slug"`
+}
+
+// UpdateFormAccessRequest turns ID-based access for respondents off or on
+type UpdateFormAccessRequest struct {
+	LinkOnly bool `json:"linkOnly"`
+}
+
+// UpdateStrictModeRequest turns a form's strict mode off (Lenient) or
+// back on
+type UpdateStrictModeRequest struct {
+	Lenient bool `json:"lenient"`
+}
+
+// SubmitByLinkRequest submits a response to the form behind the shareable
+// link or slug given in the URL
+type SubmitByLinkRequest struct {
+	Responses map[string]interface{} `json:"responses" validate:"required"`
 }
 
 type SubmitResponseRequest struct {
 	FormID    string                 `json:"formId" validate:"required"`
 	Responses map[string]interface{} `json:"responses" validate:"required"`
 }
+
+// ValidatePageRequest carries the answers given so far in a multi-page
+// form. DraftToken is the resume token of the respondent's draft; the page
+// counts as completed in the drop-off analytics the first time that draft
+// passes it, and not at all without one.
+type ValidatePageRequest struct {
+	Responses  map[string]interface{} `json:"responses"`
+	DraftToken string                 `json:"draftToken"`
+}
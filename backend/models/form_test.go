@@ -0,0 +1,65 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClosure(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	before, after := now.Add(-time.Hour), now.Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		form      Form
+		responses int
+		want      string
+	}{
+		{"published", Form{Status: StatusPublished}, 0, ""},
+		{"no status", Form{}, 0, ""},
+		{"draft", Form{Status: StatusDraft}, 0, CodeFormNotPublished},
+		{"closed", Form{Status: StatusClosed}, 0, CodeFormClosed},
+		{"archived", Form{Status: StatusArchived}, 0, CodeFormArchived},
+		{"not open yet", Form{Status: StatusPublished, OpensAt: &after}, 0, CodeFormNotOpenYet},
+		{"opened", Form{Status: StatusPublished, OpensAt: &before}, 0, ""},
+		{"opens now", Form{Status: StatusPublished, OpensAt: &now}, 0, ""},
+		{"closes later", Form{Status: StatusPublished, ClosesAt: &after}, 0, ""},
+		{"closes now", Form{Status: StatusPublished, ClosesAt: &now}, 0, CodeFormClosed},
+		{"closed by schedule", Form{Status: StatusPublished, OpensAt: &before, ClosesAt: &before}, 0, CodeFormClosed},
+		{"draft with a schedule", Form{Status: StatusDraft, OpensAt: &before, ClosesAt: &after}, 0, CodeFormNotPublished},
+		{"under the limit", Form{Status: StatusPublished, MaxResponses: 3}, 2, ""},
+		{"at the limit", Form{Status: StatusPublished, MaxResponses: 3}, 3, CodeResponseLimit},
+		{"limit before opening", Form{Status: StatusPublished, OpensAt: &after, MaxResponses: 1}, 1, CodeFormNotOpenYet},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.form.Closure(now, tt.responses); got != tt.want {
+				t.Errorf("Closure = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClosedText(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		code    string
+		want    string
+	}{
+		{"not published", "", CodeFormNotPublished, "This form hasn't been published yet"},
+		{"not open yet", "", CodeFormNotOpenYet, "This form isn't open for responses yet"},
+		{"limit", "", CodeResponseLimit, "This form has reached its maximum number of responses"},
+		{"closed", "", CodeFormClosed, "This form is no longer accepting responses"},
+		{"custom message", "See you next year", CodeFormClosed, "See you next year"},
+		{"custom message before publishing", "See you next year", CodeFormNotPublished, "This form hasn't been published yet"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := Form{ClosedMessage: tt.message}
+			if got := f.ClosedText(tt.code); got != tt.want {
+				t.Errorf("ClosedText = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
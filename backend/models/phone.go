@@ -0,0 +1,42 @@
+package models
+
+import (
+	"errors"
+	"strings"
+)
+
+var errPhone = errors.New("not a valid phone number")
+
+// ParsePhone reads the answer to a phone field and returns it in E.164: a
+// + followed by the country calling code and the number, 15 digits at
+// most. Spaces, dots, dashes, slashes and parentheses are ignored, as is a
+// trunk 0 written "(0)" after the country code, and 00 may stand for the
+// +. Numbers with neither are read in the field's CountryCode, without
+// their leading 0.
+func (f Field) ParsePhone(s string) (string, error) {
+	s = strings.Replace(strings.TrimSpace(s), "(0)", "", 1)
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r >= '0' && r <= '9', r == '+' && i == 0:
+			b.WriteRune(r)
+		case strings.ContainsRune(" .-/()", r):
+		default:
+			return "", errPhone
+		}
+	}
+	n := b.String()
+	switch {
+	case strings.HasPrefix(n, "+"):
+	case strings.HasPrefix(n, "00"):
+		n = "+" + n[2:]
+	case f.CountryCode != "":
+		n = "+" + strings.TrimPrefix(f.CountryCode, "+") + strings.TrimPrefix(n, "0")
+	default:
+		return "", errPhone
+	}
+	if digits := n[1:]; len(digits) < 7 || len(digits) > 15 || digits[0] == '0' {
+		return "", errPhone
+	}
+	return n, nil
+}
@@ -0,0 +1,40 @@
+package models
+
+import "testing"
+
+func TestParsePhone(t *testing.T) {
+	tests := []struct {
+		name    string
+		country string
+		in      string
+		want    string
+	}{
+		{"international", "", "+44 20 7946 0958", "+442079460958"},
+		{"trunk zero in parentheses", "", "+44 (0)20 7946 0958", "+442079460958"},
+		{"00 for plus", "", "0044 20-7946-0958", "+442079460958"},
+		{"national with country code", "44", "020 7946 0958", "+442079460958"},
+		{"country code with plus", "+1", "(555) 123.4567", "+15551234567"},
+		{"international ignores country code", "44", "+1 555 123 4567", "+15551234567"},
+		{"national without country code", "", "020 7946 0958", ""},
+		{"letters", "", "+44 20 7946 0958 ext 2", ""},
+		{"plus after the start", "", "44+20 7946 0958", ""},
+		{"country code starting with 0", "", "+0 555 123 4567", ""},
+		{"too short", "", "+123456", ""},
+		{"too long", "", "+1234567890123456", ""},
+		{"empty", "1", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Field{Type: FieldTypePhone, CountryCode: tt.country}.ParsePhone(tt.in)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("ParsePhone(%q) = %q, want an error", tt.in, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ParsePhone(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+			}
+		})
+	}
+}
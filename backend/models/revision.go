@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FormRevision is an immutable snapshot of a form's definition. A form
+// starts at revision 1 and every update that changes it adds the next one;
+// responses record the revision they were submitted against.
+type FormRevision struct {
+	FormID      primitive.ObjectID `json:"formId" bson:"formId"`
+	Revision    int                `json:"revision" bson:"revision"`
+	Title       string             `json:"title" bson:"title"`
+	Description string             `json:"description" bson:"description"`
+	Fields      []Field            `json:"fields" bson:"fields"`
+	Pages       []Page             `json:"pages,omitempty" bson:"pages,omitempty"`
+	CreatedBy   primitive.ObjectID `json:"createdBy" bson:"createdBy,omitempty"`
+	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// ValueChange is one property that differs between two revisions
+type ValueChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// FieldChange lists the properties of a field that changed, keyed by their
+// JSON name (label, options, required, ...)
+type FieldChange struct {
+	FieldID string                 `json:"fieldId"`
+	Label   string                 `json:"label"`
+	Changes map[string]ValueChange `json:"changes"`
+}
+
+// RevisionDiff describes what changed from one revision to another
+type RevisionDiff struct {
+	From        int           `json:"from"`
+	To          int           `json:"to"`
+	Title       *ValueChange  `json:"title,omitempty"`
+	Description *ValueChange  `json:"description,omitempty"`
+	Pages       *ValueChange  `json:"pages,omitempty"`
+	Added       []Field       `json:"added"`
+	Removed     []Field       `json:"removed"`
+	Changed     []FieldChange `json:"changed"`
+}
@@ -0,0 +1,17 @@
+package models
+
+// SliderRange returns the lowest and highest answers a slider takes and
+// the step between them: its MinValue, MaxValue and Step, or 0, 100 and 1
+func (f Field) SliderRange() (lo, hi, step float64) {
+	lo, hi, step = 0, 100, 1
+	if f.MinValue != nil {
+		lo = float64(*f.MinValue)
+	}
+	if f.MaxValue != nil {
+		hi = float64(*f.MaxValue)
+	}
+	if f.Step != nil && *f.Step > 0 {
+		step = *f.Step
+	}
+	return lo, hi, step
+}
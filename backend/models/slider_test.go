@@ -0,0 +1,23 @@
+package models
+
+import "testing"
+
+func TestSliderRange(t *testing.T) {
+	one, ten := 1, 10
+	half, zero := 0.5, 0.0
+	tests := []struct {
+		name         string
+		field        Field
+		lo, hi, step float64
+	}{
+		{"defaults", Field{}, 0, 100, 1},
+		{"own range and step", Field{MinValue: &one, MaxValue: &ten, Step: &half}, 1, 10, 0.5},
+		{"zero step ignored", Field{Step: &zero}, 0, 100, 1},
+	}
+	for _, tt := range tests {
+		lo, hi, step := tt.field.SliderRange()
+		if lo != tt.lo || hi != tt.hi || step != tt.step {
+			t.Errorf("%s: SliderRange = %g, %g, %g, want %g, %g, %g", tt.name, lo, hi, step, tt.lo, tt.hi, tt.step)
+		}
+	}
+}
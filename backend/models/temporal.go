@@ -0,0 +1,78 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+	// Field timezones resolve even where the system has no zoneinfo
+	_ "time/tzdata"
+)
+
+// Layouts of stored date, time and datetime answers. Times keep their
+// seconds only when they aren't zero; datetimes are stored in UTC.
+const (
+	DateLayout     = "2006-01-02"
+	TimeLayout     = "15:04"
+	DateTimeLayout = time.RFC3339
+)
+
+var errTemporal = errors.New("not a valid date or time")
+
+// IsTemporal reports whether t is the date, time or datetime type
+func (t FieldType) IsTemporal() bool {
+	return t == FieldTypeDate || t == FieldTypeTime || t == FieldTypeDateTime
+}
+
+// Location returns the timezone a datetime field reads answers without a
+// UTC offset in: its Timezone, or UTC when unset or unknown
+func (f Field) Location() *time.Location {
+	if f.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(f.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// ParseTemporal reads an answer, or a bound, of a date, time or datetime
+// field. Dates are YYYY-MM-DD and times HH:MM or HH:MM:SS. Datetimes are
+// RFC 3339, or YYYY-MM-DDTHH:MM[:SS] without an offset (as sent by a
+// datetime-local input), read in the field's timezone.
+func (f Field) ParseTemporal(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	var layouts []string
+	switch f.Type {
+	case FieldTypeDate:
+		layouts = []string{DateLayout}
+	case FieldTypeTime:
+		layouts = []string{TimeLayout, "15:04:05"}
+	case FieldTypeDateTime:
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return t, nil
+		}
+		layouts = []string{"2006-01-02T15:04", "2006-01-02T15:04:05"}
+	}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, f.Location()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errTemporal
+}
+
+// FormatTemporal formats t as the stored answer of a date, time or
+// datetime field
+func (f Field) FormatTemporal(t time.Time) string {
+	switch f.Type {
+	case FieldTypeDate:
+		return t.Format(DateLayout)
+	case FieldTypeTime:
+		if t.Second() != 0 {
+			return t.Format("15:04:05")
+		}
+		return t.Format(TimeLayout)
+	}
+	return t.UTC().Format(DateTimeLayout)
+}
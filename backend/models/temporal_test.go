@@ -0,0 +1,67 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTemporal(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		field Field
+		in    string
+		want  time.Time
+		ok    bool
+	}{
+		{"date", Field{Type: FieldTypeDate}, "2026-05-01", time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC), true},
+		{"date trims spaces", Field{Type: FieldTypeDate}, " 2026-05-01 ", time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC), true},
+		{"date with time", Field{Type: FieldTypeDate}, "2026-05-01T10:00", time.Time{}, false},
+		{"impossible date", Field{Type: FieldTypeDate}, "2026-02-30", time.Time{}, false},
+		{"time", Field{Type: FieldTypeTime}, "09:30", time.Date(0, 1, 1, 9, 30, 0, 0, time.UTC), true},
+		{"time with seconds", Field{Type: FieldTypeTime}, "09:30:15", time.Date(0, 1, 1, 9, 30, 15, 0, time.UTC), true},
+		{"time out of range", Field{Type: FieldTypeTime}, "25:00", time.Time{}, false},
+		{"datetime with offset", Field{Type: FieldTypeDateTime, Timezone: "Europe/Berlin"}, "2026-05-01T10:00:00-04:00", time.Date(2026, 5, 1, 14, 0, 0, 0, time.UTC), true},
+		{"datetime-local in UTC", Field{Type: FieldTypeDateTime}, "2026-05-01T10:00", time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC), true},
+		{"datetime-local in timezone", Field{Type: FieldTypeDateTime, Timezone: "Europe/Berlin"}, "2026-05-01T10:00:30", time.Date(2026, 5, 1, 10, 0, 30, 0, berlin), true},
+		{"unknown timezone falls back to UTC", Field{Type: FieldTypeDateTime, Timezone: "Mars/Olympus"}, "2026-05-01T10:00", time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC), true},
+		{"datetime without time", Field{Type: FieldTypeDateTime}, "2026-05-01", time.Time{}, false},
+		{"not temporal", Field{Type: FieldTypeText}, "2026-05-01", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, err := tt.field.ParseTemporal(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: ParseTemporal(%q) error = %v, want ok %v", tt.name, tt.in, err, tt.ok)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("%s: ParseTemporal(%q) = %v, want %v", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatTemporal(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2026, 5, 1, 10, 0, 0, 0, berlin)
+	tests := []struct {
+		field Field
+		t     time.Time
+		want  string
+	}{
+		{Field{Type: FieldTypeDate}, at, "2026-05-01"},
+		{Field{Type: FieldTypeTime}, at, "10:00"},
+		{Field{Type: FieldTypeTime}, at.Add(15 * time.Second), "10:00:15"},
+		{Field{Type: FieldTypeDateTime}, at, "2026-05-01T08:00:00Z"},
+	}
+	for _, tt := range tests {
+		if got := tt.field.FormatTemporal(tt.t); got != tt.want {
+			t.Errorf("%s: FormatTemporal = %q, want %q", tt.field.Type, got, tt.want)
+		}
+	}
+}
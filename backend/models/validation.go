@@ -0,0 +1,45 @@
+package models
+
+// Codes identifying why an answer failed validation
+const (
+	CodeRequired      = "required"
+	CodeInvalidEmail  = "invalid_email"
+	CodeInvalidNumber = "invalid_number"
+	CodeBelowMin      = "below_min"
+	CodeAboveMax      = "above_max"
+	CodeOutOfRange    = "out_of_range"
+	CodeTooShort      = "too_short"
+	CodeTooLong       = "too_long"
+	CodePattern       = "pattern_mismatch"
+	CodeInvalidOption = "invalid_option"
+	CodeTooFew        = "too_few_selections"
+	CodeTooMany       = "too_many_selections"
+	CodeNotInteger    = "not_integer"
+	CodeInvalidStep   = "invalid_step"
+	CodeUnknownField  = "unknown_field"
+	CodeInvalidDate   = "invalid_date"
+	CodeTooEarly      = "too_early"
+	CodeTooLate       = "too_late"
+	CodeTooManyFiles  = "too_many_files"
+	CodeFileTooLarge  = "file_too_large"
+	CodeFileType      = "invalid_file_type"
+	CodeInvalidRow    = "invalid_row"
+	CodeDuplicate     = "duplicate_option"
+	CodeInvalidBool   = "invalid_boolean"
+	CodeInvalidPhone  = "invalid_phone"
+	CodeInvalidURL    = "invalid_url"
+)
+
+// CodeValidationFailed is returned with the list of FieldErrors when a
+// submission has invalid answers
+const CodeValidationFailed = "validation_failed"
+
+// FieldError describes one invalid answer. Params carries the values the
+// rule was checked against (for example "min" and "max") so clients can
+// build their own message.
+type FieldError struct {
+	Field   string                 `json:"field"`
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Params  map[string]interface{} `json:"params,omitempty"`
+}
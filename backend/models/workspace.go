@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Role is a member's role within a workspace
+type Role string
+
+const (
+	// RoleOwner manages the workspace and its members and can do
+	// everything an editor can
+	RoleOwner Role = "owner"
+	// RoleEditor creates, edits and deletes forms and reads their results
+	RoleEditor Role = "editor"
+	// RoleViewer sees forms and their aggregated analytics
+	RoleViewer Role = "viewer"
+	// RoleResponseReader sees forms, analytics and the individual
+	// responses, including CSV export
+	RoleResponseReader Role = "responder-data-reader"
+)
+
+// Permission is an action a role may be allowed to take on a form
+type Permission string
+
+const (
+	PermViewForm      Permission = "form:view"
+	PermEditForm      Permission = "form:edit"
+	PermViewAnalytics Permission = "analytics:view"
+	PermReadResponses Permission = "responses:read"
+	PermManageMembers Permission = "workspace:manage"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleOwner:          {PermViewForm, PermEditForm, PermViewAnalytics, PermReadResponses, PermManageMembers},
+	RoleEditor:         {PermViewForm, PermEditForm, PermViewAnalytics, PermReadResponses},
+	RoleViewer:         {PermViewForm, PermViewAnalytics},
+	RoleResponseReader: {PermViewForm, PermViewAnalytics, PermReadResponses},
+}
+
+// Valid reports whether r is a known role
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Can reports whether r grants p
+func (r Role) Can(p Permission) bool {
+	for _, granted := range rolePermissions[r] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Workspace groups forms shared by a team
+type Workspace struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name      string             `json:"name" bson:"name"`
+	CreatedBy primitive.ObjectID `json:"createdBy" bson:"createdBy"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// WorkspaceMember gives a user a role in a workspace
+type WorkspaceMember struct {
+	WorkspaceID primitive.ObjectID `json:"workspaceId" bson:"workspaceId"`
+	UserID      primitive.ObjectID `json:"userId" bson:"userId"`
+	Role        Role               `json:"role" bson:"role"`
+	AddedAt     time.Time          `json:"addedAt" bson:"addedAt"`
+}
+
+// Workspace request DTOs
+type CreateWorkspaceRequest struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type SetMemberRequest struct {
+	Email string `json:"email" validate:"required"`
+	Role  Role   `json:"role" validate:"required"`
+}
@@ -0,0 +1,196 @@
+// Package revisions compares form revisions and maps fields across them.
+package revisions
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"custom-form-builder/models"
+)
+
+// Snapshot captures the current definition of form as its revision
+func Snapshot(form *models.Form, createdBy primitive.ObjectID) models.FormRevision {
+	rev := form.Revision
+	if rev == 0 {
+		rev = 1
+	}
+	return models.FormRevision{
+		FormID:      form.ID,
+		Revision:    rev,
+		Title:       form.Title,
+		Description: form.Description,
+		Fields:      form.Fields,
+		Pages:       form.Pages,
+		CreatedBy:   createdBy,
+		CreatedAt:   time.Now(),
+	}
+}
+
+// Of returns the revision a response was submitted against. Responses
+// older than revisions belong to revision 1.
+func Of(resp models.FormResponse) int {
+	if resp.Revision == 0 {
+		return 1
+	}
+	return resp.Revision
+}
+
+// SameDefinition reports whether a and b define the same form, ignoring
+// revision metadata
+func SameDefinition(a, b *models.FormRevision) bool {
+	return a.Title == b.Title && a.Description == b.Description &&
+		jsonEqual(a.Fields, b.Fields) && samePages(a.Pages, b.Pages)
+}
+
+// Diff lists what changed from revision a to revision b. Fields are matched
+// by ID; a field's changes are keyed by the JSON name of each property.
+func Diff(a, b *models.FormRevision) models.RevisionDiff {
+	d := models.RevisionDiff{
+		From:    a.Revision,
+		To:      b.Revision,
+		Added:   []models.Field{},
+		Removed: []models.Field{},
+		Changed: []models.FieldChange{},
+	}
+	if a.Title != b.Title {
+		d.Title = &models.ValueChange{From: a.Title, To: b.Title}
+	}
+	if a.Description != b.Description {
+		d.Description = &models.ValueChange{From: a.Description, To: b.Description}
+	}
+	if !samePages(a.Pages, b.Pages) {
+		d.Pages = &models.ValueChange{From: a.Pages, To: b.Pages}
+	}
+
+	before := make(map[string]models.Field, len(a.Fields))
+	for _, f := range a.Fields {
+		before[f.ID] = f
+	}
+	after := make(map[string]bool, len(b.Fields))
+	for _, f := range b.Fields {
+		after[f.ID] = true
+		old, ok := before[f.ID]
+		if !ok {
+			d.Added = append(d.Added, f)
+			continue
+		}
+		if changes := fieldChanges(old, f); len(changes) > 0 {
+			d.Changed = append(d.Changed, models.FieldChange{FieldID: f.ID, Label: f.Label, Changes: changes})
+		}
+	}
+	for _, f := range a.Fields {
+		if !after[f.ID] {
+			d.Removed = append(d.Removed, f)
+		}
+	}
+	return d
+}
+
+func fieldChanges(a, b models.Field) map[string]models.ValueChange {
+	am, bm := toMap(a), toMap(b)
+	keys := make([]string, 0, len(am)+len(bm))
+	for k := range am {
+		keys = append(keys, k)
+	}
+	for k := range bm {
+		if _, ok := am[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	out := map[string]models.ValueChange{}
+	for _, k := range keys {
+		if k == "id" {
+			continue
+		}
+		if !reflect.DeepEqual(am[k], bm[k]) {
+			out[k] = models.ValueChange{From: am[k], To: bm[k]}
+		}
+	}
+	return out
+}
+
+// Merge returns one field list covering every revision: the fields of the
+// newest revision in order, followed by fields that were removed along the
+// way, newest removal first. Each field keeps its most recent definition.
+func Merge(revs []models.FormRevision) []models.Field {
+	if len(revs) == 0 {
+		return nil
+	}
+	sorted := append([]models.FormRevision(nil), revs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Revision > sorted[j].Revision })
+
+	seen := map[string]bool{}
+	out := []models.Field{}
+	for _, rev := range sorted {
+		for _, f := range rev.Fields {
+			if seen[f.ID] {
+				continue
+			}
+			seen[f.ID] = true
+			out = append(out, f)
+		}
+	}
+	for i := range out {
+		out[i].Order = i
+	}
+	return out
+}
+
+// CheckTypes rejects fields that reuse the ID of a field with a different
+// type in any of revs. Answers and stats are matched across revisions by
+// field ID, so a retyped field would mix values of both types.
+func CheckTypes(revs []models.FormRevision, fields []models.Field) error {
+	types := map[string]models.FieldType{}
+	for _, rev := range revs {
+		for _, f := range rev.Fields {
+			types[f.ID] = f.Type
+		}
+	}
+	for _, f := range fields {
+		if t, ok := types[f.ID]; ok && t != f.Type {
+			return fmt.Errorf("field %q: type can't change from %s to %s; add a new field instead", f.Label, t, f.Type)
+		}
+	}
+	return nil
+}
+
+// FieldSets returns, for each revision number, the IDs of its fields
+func FieldSets(revs []models.FormRevision) map[int]map[string]bool {
+	out := make(map[int]map[string]bool, len(revs))
+	for _, rev := range revs {
+		ids := make(map[string]bool, len(rev.Fields))
+		for _, f := range rev.Fields {
+			ids[f.ID] = true
+		}
+		out[rev.Revision] = ids
+	}
+	return out
+}
+
+func toMap(v interface{}) map[string]interface{} {
+	b, _ := json.Marshal(v)
+	m := map[string]interface{}{}
+	_ = json.Unmarshal(b, &m)
+	return m
+}
+
+// samePages treats a missing and an empty page list alike
+func samePages(a, b []models.Page) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return jsonEqual(a, b)
+}
+
+func jsonEqual(a, b interface{}) bool {
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return string(x) == string(y)
+}
@@ -0,0 +1,172 @@
+package revisions
+
+import (
+	"reflect"
+	"testing"
+
+	"custom-form-builder/models"
+)
+
+func rev(n int, title string, fields ...models.Field) *models.FormRevision {
+	return &models.FormRevision{Revision: n, Title: title, Fields: fields}
+}
+
+func TestDiff(t *testing.T) {
+	name := models.Field{ID: "name", Type: models.FieldTypeText, Label: "Name"}
+	renamed := models.Field{ID: "name", Type: models.FieldTypeText, Label: "Full name", Required: true}
+	color := models.Field{ID: "color", Type: models.FieldTypeMultipleChoice, Label: "Color", Options: []string{"Red"}}
+	moreColors := models.Field{ID: "color", Type: models.FieldTypeMultipleChoice, Label: "Color", Options: []string{"Red", "Blue"}}
+	email := models.Field{ID: "email", Type: models.FieldTypeEmail, Label: "Email"}
+
+	tests := []struct {
+		name    string
+		a, b    *models.FormRevision
+		title   bool
+		pages   bool
+		added   []string
+		removed []string
+		changed map[string][]string
+	}{
+		{"same", rev(1, "T", name), rev(2, "T", name), false, false, nil, nil, nil},
+		{"title", rev(1, "T", name), rev(2, "U", name), true, false, nil, nil, nil},
+		{"field added", rev(1, "T", name), rev(2, "T", name, email), false, false, []string{"email"}, nil, nil},
+		{"field removed", rev(1, "T", name, email), rev(2, "T", name), false, false, nil, []string{"email"}, nil},
+		{"field changed", rev(1, "T", name, color), rev(2, "T", renamed, moreColors), false, false, nil, nil,
+			map[string][]string{"name": {"label", "required"}, "color": {"options"}}},
+		{"pages", rev(1, "T", name), &models.FormRevision{Revision: 2, Title: "T", Fields: []models.Field{name}, Pages: []models.Page{{ID: "p1"}}}, false, true, nil, nil, nil},
+		{"no pages and empty pages", rev(1, "T", name), &models.FormRevision{Revision: 2, Title: "T", Fields: []models.Field{name}, Pages: []models.Page{}}, false, false, nil, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Diff(tt.a, tt.b)
+			if d.From != tt.a.Revision || d.To != tt.b.Revision {
+				t.Errorf("diff of %d..%d, want %d..%d", d.From, d.To, tt.a.Revision, tt.b.Revision)
+			}
+			if (d.Title != nil) != tt.title || (d.Pages != nil) != tt.pages {
+				t.Errorf("title change %v, pages change %v", d.Title, d.Pages)
+			}
+			if got := ids(d.Added); !reflect.DeepEqual(got, tt.added) {
+				t.Errorf("added %v, want %v", got, tt.added)
+			}
+			if got := ids(d.Removed); !reflect.DeepEqual(got, tt.removed) {
+				t.Errorf("removed %v, want %v", got, tt.removed)
+			}
+			changed := map[string][]string{}
+			for _, fc := range d.Changed {
+				for k := range fc.Changes {
+					changed[fc.FieldID] = append(changed[fc.FieldID], k)
+				}
+			}
+			for id, keys := range tt.changed {
+				if !sameKeys(changed[id], keys) {
+					t.Errorf("%s changed %v, want %v", id, changed[id], keys)
+				}
+			}
+			if len(changed) != len(tt.changed) {
+				t.Errorf("changed %v, want %v", changed, tt.changed)
+			}
+			if same := SameDefinition(tt.a, tt.b); same != (!tt.title && !tt.pages && tt.added == nil && tt.removed == nil && tt.changed == nil) {
+				t.Errorf("SameDefinition = %v", same)
+			}
+		})
+	}
+}
+
+func ids(fields []models.Field) []string {
+	var out []string
+	for _, f := range fields {
+		out = append(out, f.ID)
+	}
+	return out
+}
+
+func sameKeys(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := map[string]bool{}
+	for _, k := range a {
+		seen[k] = true
+	}
+	for _, k := range b {
+		if !seen[k] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMerge(t *testing.T) {
+	a := models.Field{ID: "a", Type: models.FieldTypeText, Label: "A"}
+	b := models.Field{ID: "b", Type: models.FieldTypeText, Label: "B"}
+	b2 := models.Field{ID: "b", Type: models.FieldTypeText, Label: "B, relabelled"}
+	c := models.Field{ID: "c", Type: models.FieldTypeText, Label: "C"}
+
+	tests := []struct {
+		name   string
+		revs   []models.FormRevision
+		want   []string
+		labels map[string]string
+	}{
+		{"none", nil, nil, nil},
+		{"one", []models.FormRevision{*rev(1, "T", a, b)}, []string{"a", "b"}, nil},
+		{"removed field last", []models.FormRevision{*rev(1, "T", a, b), *rev(2, "T", b2)}, []string{"b", "a"}, map[string]string{"b": "B, relabelled"}},
+		{"newest removal first", []models.FormRevision{*rev(3, "T", c), *rev(1, "T", a), *rev(2, "T", b)}, []string{"c", "b", "a"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Merge(tt.revs)
+			if g := ids(got); !reflect.DeepEqual(g, tt.want) {
+				t.Fatalf("Merge = %v, want %v", g, tt.want)
+			}
+			for i, f := range got {
+				if f.Order != i {
+					t.Errorf("%s has order %d, want %d", f.ID, f.Order, i)
+				}
+				if l, ok := tt.labels[f.ID]; ok && f.Label != l {
+					t.Errorf("%s label = %q, want %q", f.ID, f.Label, l)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckTypes(t *testing.T) {
+	revs := []models.FormRevision{*rev(1, "T",
+		models.Field{ID: "a", Type: models.FieldTypeText},
+		models.Field{ID: "b", Type: models.FieldTypeNumber},
+	)}
+	tests := []struct {
+		name   string
+		fields []models.Field
+		ok     bool
+	}{
+		{"same types", []models.Field{{ID: "a", Type: models.FieldTypeText}, {ID: "b", Type: models.FieldTypeNumber}}, true},
+		{"new field", []models.Field{{ID: "c", Type: models.FieldTypeRating}}, true},
+		{"removed field comes back", []models.Field{{ID: "b", Type: models.FieldTypeNumber}}, true},
+		{"retyped", []models.Field{{ID: "b", Type: models.FieldTypeText, Label: "B"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckTypes(revs, tt.fields); (err == nil) != tt.ok {
+				t.Errorf("CheckTypes = %v, want ok = %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestOf(t *testing.T) {
+	tests := []struct {
+		revision int
+		want     int
+	}{
+		{0, 1},
+		{1, 1},
+		{4, 4},
+	}
+	for _, tt := range tests {
+		if got := Of(models.FormResponse{Revision: tt.revision}); got != tt.want {
+			t.Errorf("Of(revision %d) = %d, want %d", tt.revision, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,747 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"custom-form-builder/models"
+)
+
+// MemoryStore is an in-process Store, useful for tests and local runs
+// without a database. Data is lost when the process exits.
+type MemoryStore struct {
+	mu         sync.RWMutex
+	forms      map[primitive.ObjectID]models.Form
+	responses  map[primitive.ObjectID][]models.FormResponse
+	aggregates map[primitive.ObjectID][]byte
+	users      map[primitive.ObjectID]models.User
+	workspaces map[primitive.ObjectID]models.Workspace
+	members    map[primitive.ObjectID]map[primitive.ObjectID]models.WorkspaceMember
+	apiKeys    map[primitive.ObjectID]models.APIKey
+	drafts     map[primitive.ObjectID]models.Draft
+	draftPages map[primitive.ObjectID]map[string]bool
+	revisions  map[primitive.ObjectID][]models.FormRevision
+}
+
+// NewMemoryStore creates an empty in-memory Store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		forms:      make(map[primitive.ObjectID]models.Form),
+		responses:  make(map[primitive.ObjectID][]models.FormResponse),
+		aggregates: make(map[primitive.ObjectID][]byte),
+		users:      make(map[primitive.ObjectID]models.User),
+		workspaces: make(map[primitive.ObjectID]models.Workspace),
+		members:    make(map[primitive.ObjectID]map[primitive.ObjectID]models.WorkspaceMember),
+		apiKeys:    make(map[primitive.ObjectID]models.APIKey),
+		drafts:     make(map[primitive.ObjectID]models.Draft),
+		draftPages: make(map[primitive.ObjectID]map[string]bool),
+		revisions:  make(map[primitive.ObjectID][]models.FormRevision),
+	}
+}
+
+func (s *MemoryStore) CreateForm(ctx context.Context, form *models.Form) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if form.ID.IsZero() {
+		form.ID = primitive.NewObjectID()
+	}
+	s.forms[form.ID] = copyForm(*form)
+	return nil
+}
+
+func (s *MemoryStore) ListForms(ctx context.Context, filter FormFilter) ([]models.Form, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]models.Form, 0, len(s.forms))
+	for _, f := range s.forms {
+		if !matchesFilter(f, filter) {
+			continue
+		}
+		out = append(out, copyForm(f))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (s *MemoryStore) GetForm(ctx context.Context, id primitive.ObjectID) (*models.Form, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	f, ok := s.forms[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	f = copyForm(f)
+	return &f, nil
+}
+
+func (s *MemoryStore) GetFormByShareableLink(ctx context.Context, link string) (*models.Form, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, f := range s.forms {
+		if link != "" && f.ShareableLink == link {
+			f = copyForm(f)
+			return &f, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) GetFormBySlug(ctx context.Context, slug string) (*models.Form, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, f := range s.forms {
+		if slug != "" && f.Slug == slug {
+			f = copyForm(f)
+			return &f, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) UpdateFormLink(ctx context.Context, form *models.Form) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.forms[form.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if form.Slug != "" {
+		for id, f := range s.forms {
+			if id != form.ID && f.Slug == form.Slug {
+				return ErrDuplicate
+			}
+		}
+	}
+	existing.ShareableLink = form.ShareableLink
+	existing.Slug = form.Slug
+	existing.LinkOnly = form.LinkOnly
+	existing.UpdatedAt = form.UpdatedAt
+	s.forms[form.ID] = copyForm(existing)
+	return nil
+}
+
+func (s *MemoryStore) UpdateFormStrictMode(ctx context.Context, form *models.Form) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.forms[form.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	existing.Lenient = form.Lenient
+	existing.UpdatedAt = form.UpdatedAt
+	s.forms[form.ID] = copyForm(existing)
+	return nil
+}
+
+func (s *MemoryStore) ClaimUnownedForms(ctx context.Context, ownerID primitive.ObjectID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int64
+	for id, f := range s.forms {
+		if f.OwnerID.IsZero() && f.WorkspaceID.IsZero() {
+			f.OwnerID = ownerID
+			s.forms[id] = f
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *MemoryStore) UpdateForm(ctx context.Context, form *models.Form, prevRevision int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.forms[form.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if existing.Revision != prevRevision {
+		return ErrConflict
+	}
+	existing.Title = form.Title
+	existing.Description = form.Description
+	existing.Fields = form.Fields
+	existing.Pages = form.Pages
+	existing.Revision = form.Revision
+	existing.UpdatedAt = form.UpdatedAt
+	s.forms[form.ID] = copyForm(existing)
+	return nil
+}
+
+func (s *MemoryStore) UpdateFormStatus(ctx context.Context, form *models.Form) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.forms[form.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	existing.Status = form.Status
+	existing.OpensAt = form.OpensAt
+	existing.ClosesAt = form.ClosesAt
+	existing.MaxResponses = form.MaxResponses
+	existing.ClosedMessage = form.ClosedMessage
+	existing.UpdatedAt = form.UpdatedAt
+	s.forms[form.ID] = copyForm(existing)
+	return nil
+}
+
+func (s *MemoryStore) DeleteForm(ctx context.Context, id primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.forms[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.forms, id)
+	return nil
+}
+
+func (s *MemoryStore) CreateResponse(ctx context.Context, resp *models.FormResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if resp.ID.IsZero() {
+		resp.ID = primitive.NewObjectID()
+	}
+	s.responses[resp.FormID] = append(s.responses[resp.FormID], copyResponse(*resp))
+	return nil
+}
+
+func (s *MemoryStore) ListResponses(ctx context.Context, formID primitive.ObjectID) ([]models.FormResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]models.FormResponse, 0, len(s.responses[formID]))
+	for _, r := range s.responses[formID] {
+		out = append(out, copyResponse(r))
+	}
+	return out, nil
+}
+
+func (s *MemoryStore) GetResponse(ctx context.Context, formID, id primitive.ObjectID) (*models.FormResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, r := range s.responses[formID] {
+		if r.ID == id {
+			out := copyResponse(r)
+			return &out, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) ForEachResponse(ctx context.Context, formID primitive.ObjectID, fn func(models.FormResponse) error) error {
+	all, err := s.ListResponses(ctx, formID)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].SubmittedAt.Before(all[j].SubmittedAt) })
+	for _, r := range all {
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) CountResponsesSince(ctx context.Context, formID primitive.ObjectID, since time.Time) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var n int64
+	for _, r := range s.responses[formID] {
+		if !r.SubmittedAt.Before(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *MemoryStore) DeleteResponses(ctx context.Context, formID primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.responses, formID)
+	return nil
+}
+
+// Aggregates are kept JSON-encoded so callers never share nested maps.
+
+func (s *MemoryStore) GetAggregate(ctx context.Context, formID primitive.ObjectID) (*models.FormAggregate, error) {
+	s.mu.RLock()
+	data, ok := s.aggregates[formID]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	var agg models.FormAggregate
+	if err := json.Unmarshal(data, &agg); err != nil {
+		return nil, err
+	}
+	return &agg, nil
+}
+
+func (s *MemoryStore) SaveAggregate(ctx context.Context, agg *models.FormAggregate) error {
+	data, err := json.Marshal(agg)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.aggregates[agg.FormID] = data
+	return nil
+}
+
+func (s *MemoryStore) DeleteAggregate(ctx context.Context, formID primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.aggregates, formID)
+	return nil
+}
+
+func (s *MemoryStore) CreateUser(ctx context.Context, user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if u.Email == user.Email {
+			return ErrDuplicate
+		}
+	}
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	s.users[user.ID] = *user
+	return nil
+}
+
+func (s *MemoryStore) GetUser(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &u, nil
+}
+
+func (s *MemoryStore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if u.Email == email {
+			return &u, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) CreateWorkspace(ctx context.Context, ws *models.Workspace) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ws.ID.IsZero() {
+		ws.ID = primitive.NewObjectID()
+	}
+	s.workspaces[ws.ID] = *ws
+	return nil
+}
+
+func (s *MemoryStore) GetWorkspace(ctx context.Context, id primitive.ObjectID) (*models.Workspace, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ws, ok := s.workspaces[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &ws, nil
+}
+
+func (s *MemoryStore) DeleteWorkspace(ctx context.Context, id primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.workspaces[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.workspaces, id)
+	delete(s.members, id)
+	return nil
+}
+
+func (s *MemoryStore) SetMember(ctx context.Context, member *models.WorkspaceMember) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byUser := s.members[member.WorkspaceID]
+	if byUser == nil {
+		byUser = make(map[primitive.ObjectID]models.WorkspaceMember)
+		s.members[member.WorkspaceID] = byUser
+	}
+	if existing, ok := byUser[member.UserID]; ok {
+		member.AddedAt = existing.AddedAt
+	}
+	byUser[member.UserID] = *member
+	return nil
+}
+
+func (s *MemoryStore) GetMember(ctx context.Context, workspaceID, userID primitive.ObjectID) (*models.WorkspaceMember, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	m, ok := s.members[workspaceID][userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &m, nil
+}
+
+func (s *MemoryStore) RemoveMember(ctx context.Context, workspaceID, userID primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.members[workspaceID][userID]; !ok {
+		return ErrNotFound
+	}
+	delete(s.members[workspaceID], userID)
+	return nil
+}
+
+func (s *MemoryStore) ListMembers(ctx context.Context, workspaceID primitive.ObjectID) ([]models.WorkspaceMember, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]models.WorkspaceMember, 0, len(s.members[workspaceID]))
+	for _, m := range s.members[workspaceID] {
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].AddedAt.Before(out[j].AddedAt) })
+	return out, nil
+}
+
+func (s *MemoryStore) ListMemberships(ctx context.Context, userID primitive.ObjectID) ([]models.WorkspaceMember, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := []models.WorkspaceMember{}
+	for _, byUser := range s.members {
+		if m, ok := byUser[userID]; ok {
+			out = append(out, m)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].AddedAt.Before(out[j].AddedAt) })
+	return out, nil
+}
+
+func (s *MemoryStore) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, k := range s.apiKeys {
+		if k.KeyHash == key.KeyHash {
+			return ErrDuplicate
+		}
+	}
+	if key.ID.IsZero() {
+		key.ID = primitive.NewObjectID()
+	}
+	s.apiKeys[key.ID] = copyAPIKey(*key)
+	return nil
+}
+
+func (s *MemoryStore) GetAPIKey(ctx context.Context, id primitive.ObjectID) (*models.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	k, ok := s.apiKeys[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	k = copyAPIKey(k)
+	return &k, nil
+}
+
+func (s *MemoryStore) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, k := range s.apiKeys {
+		if k.KeyHash == hash {
+			k = copyAPIKey(k)
+			return &k, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) ListAPIKeys(ctx context.Context, userID primitive.ObjectID) ([]models.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := []models.APIKey{}
+	for _, k := range s.apiKeys {
+		if k.UserID == userID {
+			out = append(out, copyAPIKey(k))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (s *MemoryStore) UpdateAPIKey(ctx context.Context, key *models.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k, ok := s.apiKeys[key.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	k.Prefix, k.KeyHash, k.RotatedAt, k.RevokedAt = key.Prefix, key.KeyHash, key.RotatedAt, key.RevokedAt
+	s.apiKeys[key.ID] = k
+	return nil
+}
+
+func (s *MemoryStore) TouchAPIKey(ctx context.Context, id primitive.ObjectID, usedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k, ok := s.apiKeys[id]
+	if !ok {
+		return ErrNotFound
+	}
+	k.LastUsedAt = &usedAt
+	s.apiKeys[id] = k
+	return nil
+}
+
+func (s *MemoryStore) CreateDraft(ctx context.Context, draft *models.Draft) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if draft.ID.IsZero() {
+		draft.ID = primitive.NewObjectID()
+	}
+	s.drafts[draft.ID] = copyDraft(*draft)
+	return nil
+}
+
+func (s *MemoryStore) GetDraftByTokenHash(ctx context.Context, hash string) (*models.Draft, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, d := range s.drafts {
+		if d.TokenHash == hash {
+			d = copyDraft(d)
+			return &d, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) UpdateDraft(ctx context.Context, draft *models.Draft) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.drafts[draft.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	existing.Responses = draft.Responses
+	existing.PageID = draft.PageID
+	existing.UpdatedAt = draft.UpdatedAt
+	existing.ExpiresAt = draft.ExpiresAt
+	s.drafts[draft.ID] = copyDraft(existing)
+	return nil
+}
+
+func (s *MemoryStore) DeleteDraft(ctx context.Context, id primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.drafts[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.drafts, id)
+	delete(s.draftPages, id)
+	return nil
+}
+
+func (s *MemoryStore) PassDraftPage(ctx context.Context, id primitive.ObjectID, pageID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.drafts[id]; !ok {
+		return false, ErrNotFound
+	}
+	if s.draftPages[id][pageID] {
+		return false, nil
+	}
+	if s.draftPages[id] == nil {
+		s.draftPages[id] = map[string]bool{}
+	}
+	s.draftPages[id][pageID] = true
+	return true, nil
+}
+
+func (s *MemoryStore) DeleteDrafts(ctx context.Context, formID primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, d := range s.drafts {
+		if d.FormID == formID {
+			delete(s.drafts, id)
+			delete(s.draftPages, id)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) CreateRevision(ctx context.Context, rev *models.FormRevision) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.revisions[rev.FormID] {
+		if r.Revision == rev.Revision {
+			return ErrDuplicate
+		}
+	}
+	revs := append(s.revisions[rev.FormID], copyRevision(*rev))
+	sort.Slice(revs, func(i, j int) bool { return revs[i].Revision < revs[j].Revision })
+	s.revisions[rev.FormID] = revs
+	return nil
+}
+
+func (s *MemoryStore) GetRevision(ctx context.Context, formID primitive.ObjectID, revision int) (*models.FormRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, r := range s.revisions[formID] {
+		if r.Revision == revision {
+			r = copyRevision(r)
+			return &r, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) ListRevisions(ctx context.Context, formID primitive.ObjectID) ([]models.FormRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]models.FormRevision, 0, len(s.revisions[formID]))
+	for _, r := range s.revisions[formID] {
+		out = append(out, copyRevision(r))
+	}
+	return out, nil
+}
+
+func (s *MemoryStore) DeleteRevisions(ctx context.Context, formID primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.revisions, formID)
+	return nil
+}
+
+// matchesFilter applies the FormFilter rules to f
+func matchesFilter(f models.Form, filter FormFilter) bool {
+	if !filter.WorkspaceID.IsZero() {
+		return f.WorkspaceID == filter.WorkspaceID
+	}
+	if filter.OwnerID.IsZero() {
+		return true
+	}
+	if !f.WorkspaceID.IsZero() {
+		return false
+	}
+	return f.OwnerID == filter.OwnerID
+}
+
+// copyForm returns a copy of f that shares no slices with the original
+func copyForm(f models.Form) models.Form {
+	f.Fields = append([]models.Field(nil), f.Fields...)
+	for i := range f.Fields {
+		f.Fields[i].Options = append([]string(nil), f.Fields[i].Options...)
+	}
+	f.Pages = append([]models.Page(nil), f.Pages...)
+	f.OpensAt = copyTime(f.OpensAt)
+	f.ClosesAt = copyTime(f.ClosesAt)
+	return f
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
+
+// copyAPIKey returns a copy of k with its own scope list
+func copyAPIKey(k models.APIKey) models.APIKey {
+	k.Scopes = append([]models.Scope(nil), k.Scopes...)
+	return k
+}
+
+// copyRevision returns a copy of r that shares no slices with the original
+func copyRevision(r models.FormRevision) models.FormRevision {
+	f := copyForm(models.Form{Fields: r.Fields, Pages: r.Pages})
+	r.Fields, r.Pages = f.Fields, f.Pages
+	return r
+}
+
+// copyDraft returns a copy of d with its own answer map
+func copyDraft(d models.Draft) models.Draft {
+	answers := make(map[string]interface{}, len(d.Responses))
+	for k, v := range d.Responses {
+		answers[k] = v
+	}
+	d.Responses = answers
+	return d
+}
+
+// copyResponse returns a copy of r with its own answer map
+func copyResponse(r models.FormResponse) models.FormResponse {
+	answers := make(map[string]models.Answer, len(r.Responses))
+	for k, v := range r.Responses {
+		v.Choices = append([]string(nil), v.Choices...)
+		v.Files = append([]models.FileRef(nil), v.Files...)
+		if v.Matrix != nil {
+			rows := make(map[string][]string, len(v.Matrix))
+			for row, cols := range v.Matrix {
+				rows[row] = append([]string(nil), cols...)
+			}
+			v.Matrix = rows
+		}
+		answers[k] = v
+	}
+	r.Responses = answers
+	r.Hidden = append([]string(nil), r.Hidden...)
+	if r.Other != nil {
+		other := make(map[string]string, len(r.Other))
+		for k, v := range r.Other {
+			other[k] = v
+		}
+		r.Other = other
+	}
+	return r
+}
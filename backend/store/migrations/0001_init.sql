@@ -0,0 +1,35 @@
+-- Forms, their ordered field list and submitted responses.
+-- Kept to types shared by SQLite and Postgres.
+
+CREATE TABLE IF NOT EXISTS forms (
+    id             TEXT PRIMARY KEY,
+    title          TEXT NOT NULL,
+    description    TEXT NOT NULL DEFAULT '',
+    shareable_link TEXT NOT NULL UNIQUE,
+    created_at     TIMESTAMP NOT NULL,
+    updated_at     TIMESTAMP NOT NULL
+);
+
+-- One row per field. The full field definition is kept as JSON in
+-- "definition" so new field settings don't need a schema change.
+CREATE TABLE IF NOT EXISTS form_fields (
+    form_id    TEXT NOT NULL REFERENCES forms(id) ON DELETE CASCADE,
+    position   INTEGER NOT NULL,
+    field_id   TEXT NOT NULL,
+    type       TEXT NOT NULL,
+    label      TEXT NOT NULL,
+    required   BOOLEAN NOT NULL DEFAULT FALSE,
+    definition TEXT NOT NULL,
+    PRIMARY KEY (form_id, field_id)
+);
+
+CREATE INDEX IF NOT EXISTS form_fields_form_position ON form_fields (form_id, position);
+
+CREATE TABLE IF NOT EXISTS responses (
+    id           TEXT PRIMARY KEY,
+    form_id      TEXT NOT NULL,
+    answers      TEXT NOT NULL,
+    submitted_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS responses_form_submitted ON responses (form_id, submitted_at);
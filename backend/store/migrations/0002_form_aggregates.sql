@@ -0,0 +1,7 @@
+-- Incrementally maintained analytics, one JSON document per form.
+
+CREATE TABLE IF NOT EXISTS form_aggregates (
+    form_id    TEXT PRIMARY KEY,
+    data       TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
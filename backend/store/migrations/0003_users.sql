@@ -0,0 +1,14 @@
+-- User accounts and form ownership.
+
+CREATE TABLE IF NOT EXISTS users (
+    id            TEXT PRIMARY KEY,
+    email         TEXT NOT NULL UNIQUE,
+    name          TEXT NOT NULL DEFAULT '',
+    password_hash TEXT NOT NULL,
+    created_at    TIMESTAMP NOT NULL
+);
+
+-- Empty for forms created before accounts existed.
+ALTER TABLE forms ADD COLUMN owner_id TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS forms_owner ON forms (owner_id);
@@ -0,0 +1,23 @@
+-- Workspaces shared by a team, with per-member roles.
+
+CREATE TABLE IF NOT EXISTS workspaces (
+    id         TEXT PRIMARY KEY,
+    name       TEXT NOT NULL,
+    created_by TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+    workspace_id TEXT NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+    user_id      TEXT NOT NULL,
+    role         TEXT NOT NULL,
+    added_at     TIMESTAMP NOT NULL,
+    PRIMARY KEY (workspace_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS workspace_members_user ON workspace_members (user_id);
+
+-- Empty for personal forms.
+ALTER TABLE forms ADD COLUMN workspace_id TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS forms_workspace ON forms (workspace_id);
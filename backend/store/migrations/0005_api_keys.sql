@@ -0,0 +1,16 @@
+-- API keys for scripted access. Only a SHA-256 hash of each key is kept.
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id           TEXT PRIMARY KEY,
+    user_id      TEXT NOT NULL,
+    name         TEXT NOT NULL,
+    prefix       TEXT NOT NULL,
+    key_hash     TEXT NOT NULL UNIQUE,
+    scopes       TEXT NOT NULL,
+    created_at   TIMESTAMP NOT NULL,
+    rotated_at   TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at   TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_user ON api_keys (user_id);
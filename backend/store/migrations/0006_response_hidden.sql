@@ -0,0 +1,3 @@
+-- Fields hidden from the respondent by conditional rules, as a JSON list.
+
+ALTER TABLE responses ADD COLUMN hidden TEXT NOT NULL DEFAULT 'null';
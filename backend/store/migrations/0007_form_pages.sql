@@ -0,0 +1,4 @@
+-- Page breaks of multi-page forms, kept as a JSON list of
+-- {id, title, description}; fields refer to their page by ID.
+
+ALTER TABLE forms ADD COLUMN pages TEXT NOT NULL DEFAULT '[]';
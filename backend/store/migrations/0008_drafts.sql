@@ -0,0 +1,18 @@
+-- Save-and-resume: partially filled responses, looked up by the hash of
+-- their resume token, and a link from each response to the draft it was
+-- completed from.
+
+CREATE TABLE IF NOT EXISTS drafts (
+    id         TEXT PRIMARY KEY,
+    form_id    TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    answers    TEXT NOT NULL,
+    page_id    TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS drafts_form ON drafts (form_id);
+
+ALTER TABLE responses ADD COLUMN draft_id TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,19 @@
+-- Immutable snapshots of form definitions. Fields and pages are kept as
+-- JSON, like form_fields.definition. Responses record the revision they
+-- were submitted against; 0 marks responses older than revisions.
+
+CREATE TABLE IF NOT EXISTS form_revisions (
+    form_id     TEXT NOT NULL,
+    revision    INTEGER NOT NULL,
+    title       TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    fields      TEXT NOT NULL,
+    pages       TEXT NOT NULL DEFAULT '[]',
+    created_by  TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMP NOT NULL,
+    PRIMARY KEY (form_id, revision)
+);
+
+ALTER TABLE forms ADD COLUMN revision INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE responses ADD COLUMN revision INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,11 @@
+-- Form lifecycle. Forms that existed before statuses stay published.
+
+ALTER TABLE forms ADD COLUMN status TEXT NOT NULL DEFAULT 'published';
+
+ALTER TABLE forms ADD COLUMN opens_at TIMESTAMP;
+
+ALTER TABLE forms ADD COLUMN closes_at TIMESTAMP;
+
+ALTER TABLE forms ADD COLUMN max_responses INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE forms ADD COLUMN closed_message TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,8 @@
+-- Custom slugs and revocable shareable links. shareable_link is NOT NULL
+-- UNIQUE, so a revoked link keeps its value and is marked inactive.
+
+ALTER TABLE forms ADD COLUMN link_active BOOLEAN NOT NULL DEFAULT TRUE;
+
+ALTER TABLE forms ADD COLUMN slug TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS forms_slug ON forms (slug);
@@ -0,0 +1,4 @@
+-- Lets owners turn off ID-based access, so a form can only be read and
+-- answered through its shareable link or slug.
+
+ALTER TABLE forms ADD COLUMN link_only BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,5 @@
+-- Strict mode is on unless a form is lenient. Free-text "Other" answers
+-- are kept apart from the answers, as a JSON object keyed by field ID.
+
+ALTER TABLE forms ADD COLUMN lenient BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE responses ADD COLUMN other TEXT NOT NULL DEFAULT 'null';
@@ -0,0 +1,8 @@
+-- The pages each draft has moved past, so a respondent counts towards a
+-- page's completions in the drop-off analytics only once.
+
+CREATE TABLE IF NOT EXISTS draft_pages (
+    draft_id TEXT NOT NULL,
+    page_id  TEXT NOT NULL,
+    PRIMARY KEY (draft_id, page_id)
+);
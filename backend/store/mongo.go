@@ -0,0 +1,813 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"custom-form-builder/models"
+)
+
+// MongoStore is a Store backed by a MongoDB database
+type MongoStore struct {
+	db *mongo.Database
+}
+
+// NewMongoStore creates a Store using the given database on client
+func NewMongoStore(client *mongo.Client, database string) *MongoStore {
+	return &MongoStore{db: client.Database(database)}
+}
+
+// EnsureIndexes creates the indexes the queries rely on
+func (s *MongoStore) EnsureIndexes(ctx context.Context) error {
+	if _, err := s.responses().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "formId", Value: 1}, {Key: "submittedAt", Value: 1}},
+	}); err != nil {
+		return err
+	}
+	if _, err := s.forms().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "ownerId", Value: 1}}},
+		{Keys: bson.D{{Key: "workspaceId", Value: 1}}},
+		{Keys: bson.D{{Key: "shareableLink", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "slug", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"slug": bson.M{"$type": "string"}}),
+		},
+	}); err != nil {
+		return err
+	}
+	if _, err := s.users().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}); err != nil {
+		return err
+	}
+	if _, err := s.members().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "workspaceId", Value: 1}, {Key: "userId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+	}); err != nil {
+		return err
+	}
+	if _, err := s.apiKeys().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "keyHash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+	}); err != nil {
+		return err
+	}
+	if _, err := s.drafts().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "tokenHash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "formId", Value: 1}}},
+	}); err != nil {
+		return err
+	}
+	_, err := s.revisions().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "formId", Value: 1}, {Key: "revision", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (s *MongoStore) forms() *mongo.Collection     { return s.db.Collection("forms") }
+func (s *MongoStore) responses() *mongo.Collection { return s.db.Collection("responses") }
+
+func (s *MongoStore) CreateForm(ctx context.Context, form *models.Form) error {
+	result, err := s.forms().InsertOne(ctx, form)
+	if err != nil {
+		return err
+	}
+	form.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (s *MongoStore) ListForms(ctx context.Context, filter FormFilter) ([]models.Form, error) {
+	query := bson.M{}
+	switch {
+	case !filter.WorkspaceID.IsZero():
+		query["workspaceId"] = filter.WorkspaceID
+	case !filter.OwnerID.IsZero():
+		query["workspaceId"] = bson.M{"$exists": false}
+		query["ownerId"] = filter.OwnerID
+	}
+
+	cursor, err := s.forms().Find(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var forms []models.Form
+	if err := cursor.All(ctx, &forms); err != nil {
+		return nil, err
+	}
+	for i := range forms {
+		defaultStatus(&forms[i])
+	}
+	return forms, nil
+}
+
+func (s *MongoStore) GetForm(ctx context.Context, id primitive.ObjectID) (*models.Form, error) {
+	return s.findForm(ctx, bson.M{"_id": id})
+}
+
+func (s *MongoStore) GetFormByShareableLink(ctx context.Context, link string) (*models.Form, error) {
+	if link == "" {
+		return nil, ErrNotFound
+	}
+	return s.findForm(ctx, bson.M{"shareableLink": link})
+}
+
+func (s *MongoStore) GetFormBySlug(ctx context.Context, slug string) (*models.Form, error) {
+	if slug == "" {
+		return nil, ErrNotFound
+	}
+	return s.findForm(ctx, bson.M{"slug": slug})
+}
+
+func (s *MongoStore) UpdateFormLink(ctx context.Context, form *models.Form) error {
+	update := bson.M{
+		"$set": bson.M{
+			"shareableLink": form.ShareableLink,
+			"linkOnly":      form.LinkOnly,
+			"updatedAt":     form.UpdatedAt,
+		},
+	}
+	// Forms without a slug don't store one, so the unique index skips them
+	if form.Slug != "" {
+		update["$set"].(bson.M)["slug"] = form.Slug
+	} else {
+		update["$unset"] = bson.M{"slug": ""}
+	}
+	result, err := s.forms().UpdateOne(ctx, bson.M{"_id": form.ID}, update)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *MongoStore) UpdateFormStrictMode(ctx context.Context, form *models.Form) error {
+	result, err := s.forms().UpdateOne(ctx, bson.M{"_id": form.ID}, bson.M{
+		"$set": bson.M{"lenient": form.Lenient, "updatedAt": form.UpdatedAt},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *MongoStore) ClaimUnownedForms(ctx context.Context, ownerID primitive.ObjectID) (int64, error) {
+	result, err := s.forms().UpdateMany(ctx, bson.M{
+		"ownerId":     bson.M{"$exists": false},
+		"workspaceId": bson.M{"$exists": false},
+	}, bson.M{"$set": bson.M{"ownerId": ownerID}})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (s *MongoStore) findForm(ctx context.Context, filter bson.M) (*models.Form, error) {
+	var form models.Form
+	if err := s.forms().FindOne(ctx, filter).Decode(&form); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	defaultStatus(&form)
+	return &form, nil
+}
+
+// defaultStatus marks forms stored before statuses existed as published,
+// as the SQL migration does
+func defaultStatus(form *models.Form) {
+	if form.Status == "" {
+		form.Status = models.StatusPublished
+	}
+}
+
+// UpdateForm matches a missing revision as 0, for forms saved before
+// revisions existed
+func (s *MongoStore) UpdateForm(ctx context.Context, form *models.Form, prevRevision int) error {
+	update := bson.M{
+		"$set": bson.M{
+			"title":       form.Title,
+			"description": form.Description,
+			"fields":      form.Fields,
+			"pages":       form.Pages,
+			"revision":    form.Revision,
+			"updatedAt":   form.UpdatedAt,
+		},
+	}
+	filter := bson.M{"_id": form.ID, "revision": prevRevision}
+	if prevRevision == 0 {
+		filter["revision"] = bson.M{"$in": bson.A{0, nil}}
+	}
+	result, err := s.forms().UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		n, err := s.forms().CountDocuments(ctx, bson.M{"_id": form.ID})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrNotFound
+		}
+		return ErrConflict
+	}
+	return nil
+}
+
+func (s *MongoStore) UpdateFormStatus(ctx context.Context, form *models.Form) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":        form.Status,
+			"opensAt":       form.OpensAt,
+			"closesAt":      form.ClosesAt,
+			"maxResponses":  form.MaxResponses,
+			"closedMessage": form.ClosedMessage,
+			"updatedAt":     form.UpdatedAt,
+		},
+	}
+	result, err := s.forms().UpdateOne(ctx, bson.M{"_id": form.ID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *MongoStore) DeleteForm(ctx context.Context, id primitive.ObjectID) error {
+	result, err := s.forms().DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *MongoStore) CreateResponse(ctx context.Context, resp *models.FormResponse) error {
+	result, err := s.responses().InsertOne(ctx, resp)
+	if err != nil {
+		return err
+	}
+	resp.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (s *MongoStore) ListResponses(ctx context.Context, formID primitive.ObjectID) ([]models.FormResponse, error) {
+	cur, err := s.responses().Find(ctx, bson.M{"formId": formID})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []models.FormResponse
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *MongoStore) GetResponse(ctx context.Context, formID, id primitive.ObjectID) (*models.FormResponse, error) {
+	var r models.FormResponse
+	err := s.responses().FindOne(ctx, bson.M{"_id": id, "formId": formID}).Decode(&r)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (s *MongoStore) ForEachResponse(ctx context.Context, formID primitive.ObjectID, fn func(models.FormResponse) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "submittedAt", Value: 1}})
+	cur, err := s.responses().Find(ctx, bson.M{"formId": formID}, opts)
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var doc models.FormResponse
+		if err := cur.Decode(&doc); err != nil {
+			return err
+		}
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+}
+
+func (s *MongoStore) CountResponsesSince(ctx context.Context, formID primitive.ObjectID, since time.Time) (int64, error) {
+	return s.responses().CountDocuments(ctx, bson.M{
+		"formId":      formID,
+		"submittedAt": bson.M{"$gte": since},
+	})
+}
+
+func (s *MongoStore) DeleteResponses(ctx context.Context, formID primitive.ObjectID) error {
+	_, err := s.responses().DeleteMany(ctx, bson.M{"formId": formID})
+	return err
+}
+
+func (s *MongoStore) aggregates() *mongo.Collection { return s.db.Collection("analytics_aggregates") }
+
+func (s *MongoStore) GetAggregate(ctx context.Context, formID primitive.ObjectID) (*models.FormAggregate, error) {
+	var agg models.FormAggregate
+	if err := s.aggregates().FindOne(ctx, bson.M{"_id": formID}).Decode(&agg); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &agg, nil
+}
+
+func (s *MongoStore) SaveAggregate(ctx context.Context, agg *models.FormAggregate) error {
+	_, err := s.aggregates().ReplaceOne(ctx, bson.M{"_id": agg.FormID}, agg, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (s *MongoStore) DeleteAggregate(ctx context.Context, formID primitive.ObjectID) error {
+	_, err := s.aggregates().DeleteOne(ctx, bson.M{"_id": formID})
+	return err
+}
+
+func (s *MongoStore) users() *mongo.Collection { return s.db.Collection("users") }
+
+func (s *MongoStore) CreateUser(ctx context.Context, user *models.User) error {
+	result, err := s.users().InsertOne(ctx, user)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	user.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (s *MongoStore) GetUser(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	return s.findUser(ctx, bson.M{"_id": id})
+}
+
+func (s *MongoStore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	return s.findUser(ctx, bson.M{"email": email})
+}
+
+func (s *MongoStore) findUser(ctx context.Context, filter bson.M) (*models.User, error) {
+	var user models.User
+	if err := s.users().FindOne(ctx, filter).Decode(&user); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *MongoStore) workspaces() *mongo.Collection { return s.db.Collection("workspaces") }
+func (s *MongoStore) members() *mongo.Collection    { return s.db.Collection("workspace_members") }
+
+func (s *MongoStore) CreateWorkspace(ctx context.Context, ws *models.Workspace) error {
+	result, err := s.workspaces().InsertOne(ctx, ws)
+	if err != nil {
+		return err
+	}
+	ws.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (s *MongoStore) GetWorkspace(ctx context.Context, id primitive.ObjectID) (*models.Workspace, error) {
+	var ws models.Workspace
+	if err := s.workspaces().FindOne(ctx, bson.M{"_id": id}).Decode(&ws); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &ws, nil
+}
+
+func (s *MongoStore) DeleteWorkspace(ctx context.Context, id primitive.ObjectID) error {
+	result, err := s.workspaces().DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	_, err = s.members().DeleteMany(ctx, bson.M{"workspaceId": id})
+	return err
+}
+
+func (s *MongoStore) SetMember(ctx context.Context, member *models.WorkspaceMember) error {
+	filter := bson.M{"workspaceId": member.WorkspaceID, "userId": member.UserID}
+	update := bson.M{
+		"$set":         bson.M{"role": member.Role},
+		"$setOnInsert": bson.M{"addedAt": member.AddedAt},
+	}
+	_, err := s.members().UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+func (s *MongoStore) GetMember(ctx context.Context, workspaceID, userID primitive.ObjectID) (*models.WorkspaceMember, error) {
+	var m models.WorkspaceMember
+	err := s.members().FindOne(ctx, bson.M{"workspaceId": workspaceID, "userId": userID}).Decode(&m)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (s *MongoStore) RemoveMember(ctx context.Context, workspaceID, userID primitive.ObjectID) error {
+	result, err := s.members().DeleteOne(ctx, bson.M{"workspaceId": workspaceID, "userId": userID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *MongoStore) ListMembers(ctx context.Context, workspaceID primitive.ObjectID) ([]models.WorkspaceMember, error) {
+	return s.findMembers(ctx, bson.M{"workspaceId": workspaceID})
+}
+
+func (s *MongoStore) ListMemberships(ctx context.Context, userID primitive.ObjectID) ([]models.WorkspaceMember, error) {
+	return s.findMembers(ctx, bson.M{"userId": userID})
+}
+
+func (s *MongoStore) findMembers(ctx context.Context, filter bson.M) ([]models.WorkspaceMember, error) {
+	cur, err := s.members().Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "addedAt", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	out := []models.WorkspaceMember{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *MongoStore) apiKeys() *mongo.Collection { return s.db.Collection("api_keys") }
+
+func (s *MongoStore) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	result, err := s.apiKeys().InsertOne(ctx, key)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	key.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (s *MongoStore) GetAPIKey(ctx context.Context, id primitive.ObjectID) (*models.APIKey, error) {
+	return s.findAPIKey(ctx, bson.M{"_id": id})
+}
+
+func (s *MongoStore) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	return s.findAPIKey(ctx, bson.M{"keyHash": hash})
+}
+
+func (s *MongoStore) findAPIKey(ctx context.Context, filter bson.M) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := s.apiKeys().FindOne(ctx, filter).Decode(&key); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (s *MongoStore) ListAPIKeys(ctx context.Context, userID primitive.ObjectID) ([]models.APIKey, error) {
+	cur, err := s.apiKeys().Find(ctx, bson.M{"userId": userID}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	out := []models.APIKey{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *MongoStore) UpdateAPIKey(ctx context.Context, key *models.APIKey) error {
+	update := bson.M{
+		"$set": bson.M{
+			"prefix":    key.Prefix,
+			"keyHash":   key.KeyHash,
+			"rotatedAt": key.RotatedAt,
+			"revokedAt": key.RevokedAt,
+		},
+	}
+	result, err := s.apiKeys().UpdateOne(ctx, bson.M{"_id": key.ID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *MongoStore) TouchAPIKey(ctx context.Context, id primitive.ObjectID, usedAt time.Time) error {
+	_, err := s.apiKeys().UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"lastUsedAt": usedAt}})
+	return err
+}
+
+func (s *MongoStore) drafts() *mongo.Collection { return s.db.Collection("drafts") }
+
+func (s *MongoStore) CreateDraft(ctx context.Context, draft *models.Draft) error {
+	result, err := s.drafts().InsertOne(ctx, draft)
+	if err != nil {
+		return err
+	}
+	draft.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (s *MongoStore) GetDraftByTokenHash(ctx context.Context, hash string) (*models.Draft, error) {
+	var d models.Draft
+	if err := s.drafts().FindOne(ctx, bson.M{"tokenHash": hash}).Decode(&d); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (s *MongoStore) UpdateDraft(ctx context.Context, draft *models.Draft) error {
+	update := bson.M{
+		"$set": bson.M{
+			"responses": draft.Responses,
+			"pageId":    draft.PageID,
+			"updatedAt": draft.UpdatedAt,
+			"expiresAt": draft.ExpiresAt,
+		},
+	}
+	result, err := s.drafts().UpdateOne(ctx, bson.M{"_id": draft.ID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *MongoStore) DeleteDraft(ctx context.Context, id primitive.ObjectID) error {
+	result, err := s.drafts().DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// PassDraftPage keeps the pages a draft has passed in its passedPages
+// array; the update only matches while the page isn't listed yet
+func (s *MongoStore) PassDraftPage(ctx context.Context, id primitive.ObjectID, pageID string) (bool, error) {
+	result, err := s.drafts().UpdateOne(ctx,
+		bson.M{"_id": id, "passedPages": bson.M{"$ne": pageID}},
+		bson.M{"$push": bson.M{"passedPages": pageID}})
+	if err != nil {
+		return false, err
+	}
+	if result.MatchedCount == 0 {
+		n, err := s.drafts().CountDocuments(ctx, bson.M{"_id": id})
+		if err != nil {
+			return false, err
+		}
+		if n == 0 {
+			return false, ErrNotFound
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+func (s *MongoStore) DeleteDrafts(ctx context.Context, formID primitive.ObjectID) error {
+	_, err := s.drafts().DeleteMany(ctx, bson.M{"formId": formID})
+	return err
+}
+
+func (s *MongoStore) revisions() *mongo.Collection { return s.db.Collection("form_revisions") }
+
+func (s *MongoStore) CreateRevision(ctx context.Context, rev *models.FormRevision) error {
+	if _, err := s.revisions().InsertOne(ctx, rev); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *MongoStore) GetRevision(ctx context.Context, formID primitive.ObjectID, revision int) (*models.FormRevision, error) {
+	var r models.FormRevision
+	err := s.revisions().FindOne(ctx, bson.M{"formId": formID, "revision": revision}).Decode(&r)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (s *MongoStore) ListRevisions(ctx context.Context, formID primitive.ObjectID) ([]models.FormRevision, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "revision", Value: 1}})
+	cur, err := s.revisions().Find(ctx, bson.M{"formId": formID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	out := []models.FormRevision{}
+	if err := cur.All(ctx, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *MongoStore) DeleteRevisions(ctx context.Context, formID primitive.ObjectID) error {
+	_, err := s.revisions().DeleteMany(ctx, bson.M{"formId": formID})
+	return err
+}
+
+func (s *MongoStore) migrations() *mongo.Collection { return s.db.Collection("migrations") }
+
+// Migrate runs the one-time data migrations not yet recorded in the
+// migrations collection. A step that stops partway records how far it got
+// in its document there, without appliedAt, and resumes from that point on
+// the next run. Migrations may take a while on large databases, so ctx
+// shouldn't carry a short deadline.
+func (s *MongoStore) Migrate(ctx context.Context) error {
+	steps := []struct {
+		id  string
+		run func(ctx context.Context, id string) error
+	}{
+		{"typed_answers", s.migrateTypedAnswers},
+	}
+	for _, step := range steps {
+		n, err := s.migrations().CountDocuments(ctx, bson.M{"_id": step.id, "appliedAt": bson.M{"$exists": true}})
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			continue
+		}
+		if err := step.run(ctx, step.id); err != nil {
+			return fmt.Errorf("migration %s: %w", step.id, err)
+		}
+		if _, err := s.migrations().UpdateOne(ctx, bson.M{"_id": step.id},
+			bson.M{"$set": bson.M{"appliedAt": time.Now()}, "$unset": bson.M{"position": ""}},
+			options.Update().SetUpsert(true)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrationPosition returns the ID a migration step last recorded with
+// saveMigrationPosition, or the zero ID if it hasn't recorded one
+func (s *MongoStore) migrationPosition(ctx context.Context, id string) (primitive.ObjectID, error) {
+	var doc struct {
+		Position primitive.ObjectID `bson:"position"`
+	}
+	err := s.migrations().FindOne(ctx, bson.M{"_id": id}).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return primitive.NilObjectID, nil
+	}
+	return doc.Position, err
+}
+
+func (s *MongoStore) saveMigrationPosition(ctx context.Context, id string, position primitive.ObjectID) error {
+	_, err := s.migrations().UpdateOne(ctx, bson.M{"_id": id},
+		bson.M{"$set": bson.M{"position": position}}, options.Update().SetUpsert(true))
+	return err
+}
+
+// migrateTypedAnswers rewrites answers stored before they were typed,
+// when checkbox answers were comma-joined strings and numbers could be
+// strings. Each answer is typed by its field's type in the form, or in the
+// latest revision that had the field. Responses are rewritten in batches
+// of 500, in ID order, recording the last ID of each batch.
+func (s *MongoStore) migrateTypedAnswers(ctx context.Context, id string) error {
+	fields := map[primitive.ObjectID][]models.Field{}
+	fieldsOf := func(formID primitive.ObjectID) ([]models.Field, error) {
+		if fs, ok := fields[formID]; ok {
+			return fs, nil
+		}
+		var fs []models.Field
+		seen := map[string]bool{}
+		form, err := s.GetForm(ctx, formID)
+		if err != nil && err != ErrNotFound {
+			return nil, err
+		}
+		if form != nil {
+			for _, f := range form.Fields {
+				seen[f.ID] = true
+				fs = append(fs, f)
+			}
+		}
+		revs, err := s.ListRevisions(ctx, formID)
+		if err != nil {
+			return nil, err
+		}
+		for i := len(revs) - 1; i >= 0; i-- {
+			for _, f := range revs[i].Fields {
+				if !seen[f.ID] {
+					seen[f.ID] = true
+					fs = append(fs, f)
+				}
+			}
+		}
+		fields[formID] = fs
+		return fs, nil
+	}
+
+	last, err := s.migrationPosition(ctx, id)
+	if err != nil {
+		return err
+	}
+	for {
+		var docs []struct {
+			ID        primitive.ObjectID     `bson:"_id"`
+			FormID    primitive.ObjectID     `bson:"formId"`
+			Responses map[string]interface{} `bson:"responses"`
+		}
+		cur, err := s.responses().Find(ctx, bson.M{"_id": bson.M{"$gt": last}},
+			options.Find().SetSort(bson.M{"_id": 1}).SetLimit(500))
+		if err != nil {
+			return err
+		}
+		if err := cur.All(ctx, &docs); err != nil {
+			return err
+		}
+		if len(docs) == 0 {
+			return nil
+		}
+
+		for _, doc := range docs {
+			fs, err := fieldsOf(doc.FormID)
+			if err != nil {
+				return err
+			}
+			typed := models.TypedAnswers(fs, doc.Responses)
+			if _, err := s.responses().UpdateOne(ctx, bson.M{"_id": doc.ID}, bson.M{"$set": bson.M{"responses": typed}}); err != nil {
+				return err
+			}
+		}
+		last = docs[len(docs)-1].ID
+		if err := s.saveMigrationPosition(ctx, id, last); err != nil {
+			return err
+		}
+	}
+}
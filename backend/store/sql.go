@@ -0,0 +1,1245 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"custom-form-builder/models"
+)
+
+// Supported SQL dialects (also the database/sql driver names)
+const (
+	DialectSQLite   = "sqlite3"
+	DialectPostgres = "postgres"
+)
+
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+// SQLStore is a Store backed by SQLite or Postgres. The schema is created
+// and upgraded by Migrate from the migrations embedded in the binary.
+type SQLStore struct {
+	db      *sql.DB
+	dialect string
+}
+
+// OpenSQLStore connects to dsn using dialect. Call Migrate before using the
+// store.
+func OpenSQLStore(ctx context.Context, dialect, dsn string) (*SQLStore, error) {
+	if dialect != DialectSQLite && dialect != DialectPostgres {
+		return nil, fmt.Errorf("unsupported SQL dialect %q", dialect)
+	}
+	db, err := sql.Open(dialect, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if dialect == DialectSQLite {
+		// SQLite allows a single writer; serialize access instead of
+		// failing with "database is locked".
+		db.SetMaxOpenConns(1)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLStore{db: db, dialect: dialect}, nil
+}
+
+// Close releases the underlying connection pool
+func (s *SQLStore) Close() error { return s.db.Close() }
+
+// dataMigrations are migrations written in Go, for changes SQL can't
+// express portably. They run in version order along with the embedded
+// SQL migrations, but outside a transaction: long ones commit in batches
+// and record how far they got with saveProgress, so an interrupted run
+// resumes where it stopped.
+var dataMigrations = map[string]func(s *SQLStore, ctx context.Context, version string) error{
+	"0014_typed_answers": (*SQLStore).migrateTypedAnswers,
+}
+
+// Migrate applies every migration not yet recorded in schema_migrations.
+// Data migrations may take a while on large databases, so ctx shouldn't
+// carry a short deadline.
+func (s *SQLStore) Migrate(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    TEXT PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL
+	)`); err != nil {
+		return err
+	}
+	if _, err := s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS migration_progress (
+		version  TEXT PRIMARY KEY,
+		position TEXT NOT NULL
+	)`); err != nil {
+		return err
+	}
+
+	entries, err := fs.ReadDir(migrationFS, "migrations")
+	if err != nil {
+		return err
+	}
+	versions := make([]string, 0, len(entries)+len(dataMigrations))
+	for _, e := range entries {
+		versions = append(versions, strings.TrimSuffix(e.Name(), ".sql"))
+	}
+	for version := range dataMigrations {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+
+	for _, version := range versions {
+		var n int
+		if err := s.db.QueryRowContext(ctx, s.rebind(`SELECT COUNT(*) FROM schema_migrations WHERE version = ?`), version).Scan(&n); err != nil {
+			return err
+		}
+		if n > 0 {
+			continue
+		}
+
+		run, isData := dataMigrations[version]
+		if isData {
+			if err := run(s, ctx, version); err != nil {
+				return fmt.Errorf("%s: %w", version, err)
+			}
+		}
+		err = s.inTx(ctx, func(tx *sql.Tx) error {
+			if isData {
+				if _, err := tx.ExecContext(ctx, s.rebind(`DELETE FROM migration_progress WHERE version = ?`), version); err != nil {
+					return err
+				}
+			} else {
+				script, err := migrationFS.ReadFile("migrations/" + version + ".sql")
+				if err != nil {
+					return err
+				}
+				if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+					return fmt.Errorf("%s.sql: %w", version, err)
+				}
+			}
+			_, err := tx.ExecContext(ctx, s.rebind(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`), version, time.Now().UTC())
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// progress returns the position a data migration last recorded with
+// saveProgress, or "" if it hasn't recorded one
+func (s *SQLStore) progress(ctx context.Context, version string) (string, error) {
+	var position string
+	err := s.db.QueryRowContext(ctx, s.rebind(`SELECT position FROM migration_progress WHERE version = ?`), version).Scan(&position)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return position, err
+}
+
+// saveProgress records how far a data migration got, in the transaction
+// that commits that work
+func (s *SQLStore) saveProgress(ctx context.Context, tx *sql.Tx, version, position string) error {
+	if _, err := tx.ExecContext(ctx, s.rebind(`DELETE FROM migration_progress WHERE version = ?`), version); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, s.rebind(`INSERT INTO migration_progress (version, position) VALUES (?, ?)`), version, position)
+	return err
+}
+
+// migrateTypedAnswers rewrites answers stored before they were typed,
+// when checkbox answers were comma-joined strings and numbers could be
+// strings. Each answer is typed by its field's type in the form, or in the
+// latest revision that had the field. Responses are rewritten in batches
+// of 500, in ID order, each committed with the last ID it covered.
+func (s *SQLStore) migrateTypedAnswers(ctx context.Context, version string) error {
+	fields := map[string][]models.Field{}
+	seen := map[string]bool{}
+	add := func(formID string, f models.Field) {
+		if key := formID + "/" + f.ID; !seen[key] {
+			seen[key] = true
+			fields[formID] = append(fields[formID], f)
+		}
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT form_id, definition FROM form_fields`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var formID, def string
+		var f models.Field
+		if err := rows.Scan(&formID, &def); err != nil {
+			rows.Close()
+			return err
+		}
+		if err := json.Unmarshal([]byte(def), &f); err != nil {
+			rows.Close()
+			return err
+		}
+		add(formID, f)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = s.db.QueryContext(ctx, `SELECT form_id, fields FROM form_revisions ORDER BY revision DESC`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var formID, defs string
+		var fs []models.Field
+		if err := rows.Scan(&formID, &defs); err != nil {
+			rows.Close()
+			return err
+		}
+		if err := json.Unmarshal([]byte(defs), &fs); err != nil {
+			rows.Close()
+			return err
+		}
+		for _, f := range fs {
+			add(formID, f)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	type stored struct{ id, formID, answers string }
+	last, err := s.progress(ctx, version)
+	if err != nil {
+		return err
+	}
+	for {
+		rows, err := s.db.QueryContext(ctx, s.rebind(`SELECT id, form_id, answers FROM responses WHERE id > ? ORDER BY id LIMIT 500`), last)
+		if err != nil {
+			return err
+		}
+		var batch []stored
+		for rows.Next() {
+			var r stored
+			if err := rows.Scan(&r.id, &r.formID, &r.answers); err != nil {
+				rows.Close()
+				return err
+			}
+			batch = append(batch, r)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		err = s.inTx(ctx, func(tx *sql.Tx) error {
+			for _, r := range batch {
+				var raw map[string]interface{}
+				if err := json.Unmarshal([]byte(r.answers), &raw); err != nil {
+					return fmt.Errorf("response %s: %w", r.id, err)
+				}
+				typed, err := json.Marshal(models.TypedAnswers(fields[r.formID], raw))
+				if err != nil {
+					return err
+				}
+				if _, err := tx.ExecContext(ctx, s.rebind(`UPDATE responses SET answers = ? WHERE id = ?`), string(typed), r.id); err != nil {
+					return err
+				}
+			}
+			return s.saveProgress(ctx, tx, version, batch[len(batch)-1].id)
+		})
+		if err != nil {
+			return err
+		}
+		last = batch[len(batch)-1].id
+	}
+}
+
+// rebind converts ? placeholders to the dialect's bind syntax
+func (s *SQLStore) rebind(query string) string {
+	if s.dialect != DialectPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (s *SQLStore) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLStore) CreateForm(ctx context.Context, form *models.Form) error {
+	if form.ID.IsZero() {
+		form.ID = primitive.NewObjectID()
+	}
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		pages, err := json.Marshal(pagesOrEmpty(form.Pages))
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, s.rebind(`INSERT INTO forms (id, title, description, shareable_link, link_active, slug, link_only, lenient, owner_id, workspace_id, pages, revision,
+			status, opens_at, closes_at, max_responses, closed_message, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+			form.ID.Hex(), form.Title, form.Description, form.ShareableLink, true, nullString(form.Slug), form.LinkOnly, form.Lenient, hexOrEmpty(form.OwnerID), hexOrEmpty(form.WorkspaceID),
+			string(pages), form.Revision, string(form.Status), nullTime(form.OpensAt), nullTime(form.ClosesAt), form.MaxResponses,
+			form.ClosedMessage, form.CreatedAt.UTC(), form.UpdatedAt.UTC())
+		if err != nil {
+			return err
+		}
+		return s.insertFields(ctx, tx, form.ID, form.Fields)
+	})
+}
+
+func (s *SQLStore) insertFields(ctx context.Context, tx *sql.Tx, formID primitive.ObjectID, fields []models.Field) error {
+	for i, f := range fields {
+		def, err := json.Marshal(f)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, s.rebind(`INSERT INTO form_fields (form_id, position, field_id, type, label, required, definition)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`),
+			formID.Hex(), i, f.ID, string(f.Type), f.Label, f.Required, string(def))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const formColumns = `id, title, description, shareable_link, link_active, slug, link_only, lenient, owner_id, workspace_id, pages, revision,
+	status, opens_at, closes_at, max_responses, closed_message, created_at, updated_at`
+
+func scanForm(row interface{ Scan(...interface{}) error }) (models.Form, error) {
+	var (
+		f                               models.Form
+		id, ownerID, workspaceID, pages string
+		status                          string
+		linkActive                      bool
+		slug                            sql.NullString
+		opensAt, closesAt               sql.NullTime
+	)
+	if err := row.Scan(&id, &f.Title, &f.Description, &f.ShareableLink, &linkActive, &slug, &f.LinkOnly, &f.Lenient, &ownerID, &workspaceID, &pages, &f.Revision,
+		&status, &opensAt, &closesAt, &f.MaxResponses, &f.ClosedMessage, &f.CreatedAt, &f.UpdatedAt); err != nil {
+		return f, err
+	}
+	if !linkActive {
+		f.ShareableLink = ""
+	}
+	f.Slug = slug.String
+	f.Status = models.FormStatus(status)
+	f.OpensAt, f.ClosesAt = timePtr(opensAt), timePtr(closesAt)
+	if err := json.Unmarshal([]byte(pages), &f.Pages); err != nil {
+		return f, err
+	}
+	if len(f.Pages) == 0 {
+		f.Pages = nil
+	}
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return f, err
+	}
+	f.ID = oid
+	if f.OwnerID, err = parseHexOrEmpty(ownerID); err != nil {
+		return f, err
+	}
+	if f.WorkspaceID, err = parseHexOrEmpty(workspaceID); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
+// pagesOrEmpty keeps single-page forms stored as an empty JSON list
+func pagesOrEmpty(pages []models.Page) []models.Page {
+	if pages == nil {
+		return []models.Page{}
+	}
+	return pages
+}
+
+// nullString maps the empty string to NULL, so unique indexes ignore it
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// hexOrEmpty maps a zero ObjectID to the empty string stored for "none"
+func hexOrEmpty(id primitive.ObjectID) string {
+	if id.IsZero() {
+		return ""
+	}
+	return id.Hex()
+}
+
+func parseHexOrEmpty(s string) (primitive.ObjectID, error) {
+	if s == "" {
+		return primitive.NilObjectID, nil
+	}
+	return primitive.ObjectIDFromHex(s)
+}
+
+// isUniqueViolation reports whether err is a unique constraint failure
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505"
+	}
+	return false
+}
+
+// loadFields fills in the field list of every form in forms
+func (s *SQLStore) loadFields(ctx context.Context, forms []models.Form) error {
+	if len(forms) == 0 {
+		return nil
+	}
+	index := make(map[string]int, len(forms))
+	args := make([]interface{}, len(forms))
+	for i := range forms {
+		index[forms[i].ID.Hex()] = i
+		args[i] = forms[i].ID.Hex()
+		forms[i].Fields = []models.Field{}
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(forms)), ",")
+
+	rows, err := s.db.QueryContext(ctx, s.rebind(`SELECT form_id, definition FROM form_fields
+		WHERE form_id IN (`+placeholders+`) ORDER BY form_id, position`), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var formID, def string
+		if err := rows.Scan(&formID, &def); err != nil {
+			return err
+		}
+		var field models.Field
+		if err := json.Unmarshal([]byte(def), &field); err != nil {
+			return err
+		}
+		i := index[formID]
+		forms[i].Fields = append(forms[i].Fields, field)
+	}
+	return rows.Err()
+}
+
+func (s *SQLStore) ListForms(ctx context.Context, filter FormFilter) ([]models.Form, error) {
+	query := `SELECT ` + formColumns + ` FROM forms`
+	var args []interface{}
+	switch {
+	case !filter.WorkspaceID.IsZero():
+		query += ` WHERE workspace_id = ?`
+		args = append(args, filter.WorkspaceID.Hex())
+	case !filter.OwnerID.IsZero():
+		query += ` WHERE workspace_id = '' AND owner_id = ?`
+		args = append(args, filter.OwnerID.Hex())
+	}
+	rows, err := s.db.QueryContext(ctx, s.rebind(query+` ORDER BY created_at`), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	forms := []models.Form{}
+	for rows.Next() {
+		f, err := scanForm(rows)
+		if err != nil {
+			return nil, err
+		}
+		forms = append(forms, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if err := s.loadFields(ctx, forms); err != nil {
+		return nil, err
+	}
+	return forms, nil
+}
+
+func (s *SQLStore) GetForm(ctx context.Context, id primitive.ObjectID) (*models.Form, error) {
+	return s.findForm(ctx, `id = ?`, id.Hex())
+}
+
+func (s *SQLStore) GetFormByShareableLink(ctx context.Context, link string) (*models.Form, error) {
+	return s.findForm(ctx, `shareable_link = ? AND link_active = ?`, link, true)
+}
+
+func (s *SQLStore) GetFormBySlug(ctx context.Context, slug string) (*models.Form, error) {
+	if slug == "" {
+		return nil, ErrNotFound
+	}
+	return s.findForm(ctx, `slug = ?`, slug)
+}
+
+func (s *SQLStore) findForm(ctx context.Context, where string, args ...interface{}) (*models.Form, error) {
+	row := s.db.QueryRowContext(ctx, s.rebind(`SELECT `+formColumns+` FROM forms WHERE `+where), args...)
+	f, err := scanForm(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	forms := []models.Form{f}
+	if err := s.loadFields(ctx, forms); err != nil {
+		return nil, err
+	}
+	return &forms[0], nil
+}
+
+func (s *SQLStore) UpdateForm(ctx context.Context, form *models.Form, prevRevision int) error {
+	pages, err := json.Marshal(pagesOrEmpty(form.Pages))
+	if err != nil {
+		return err
+	}
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, s.rebind(`UPDATE forms SET title = ?, description = ?, pages = ?, revision = ?, updated_at = ? WHERE id = ? AND revision = ?`),
+			form.Title, form.Description, string(pages), form.Revision, form.UpdatedAt.UTC(), form.ID.Hex(), prevRevision)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			var exists int
+			if err := tx.QueryRowContext(ctx, s.rebind(`SELECT COUNT(*) FROM forms WHERE id = ?`), form.ID.Hex()).Scan(&exists); err != nil {
+				return err
+			}
+			if exists == 0 {
+				return ErrNotFound
+			}
+			return ErrConflict
+		}
+		if _, err := tx.ExecContext(ctx, s.rebind(`DELETE FROM form_fields WHERE form_id = ?`), form.ID.Hex()); err != nil {
+			return err
+		}
+		return s.insertFields(ctx, tx, form.ID, form.Fields)
+	})
+}
+
+func (s *SQLStore) UpdateFormStatus(ctx context.Context, form *models.Form) error {
+	res, err := s.db.ExecContext(ctx, s.rebind(`UPDATE forms SET status = ?, opens_at = ?, closes_at = ?, max_responses = ?, closed_message = ?, updated_at = ? WHERE id = ?`),
+		string(form.Status), nullTime(form.OpensAt), nullTime(form.ClosesAt), form.MaxResponses, form.ClosedMessage, form.UpdatedAt.UTC(), form.ID.Hex())
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UpdateFormLink keeps a revoked link's value, since shareable_link is
+// NOT NULL UNIQUE, and marks it inactive instead
+func (s *SQLStore) UpdateFormLink(ctx context.Context, form *models.Form) error {
+	query := `UPDATE forms SET shareable_link = ?, link_active = ?, slug = ?, link_only = ?, updated_at = ? WHERE id = ?`
+	args := []interface{}{form.ShareableLink, true, nullString(form.Slug), form.LinkOnly, form.UpdatedAt.UTC(), form.ID.Hex()}
+	if form.ShareableLink == "" {
+		query = `UPDATE forms SET link_active = ?, slug = ?, link_only = ?, updated_at = ? WHERE id = ?`
+		args = []interface{}{false, nullString(form.Slug), form.LinkOnly, form.UpdatedAt.UTC(), form.ID.Hex()}
+	}
+	res, err := s.db.ExecContext(ctx, s.rebind(query), args...)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLStore) UpdateFormStrictMode(ctx context.Context, form *models.Form) error {
+	res, err := s.db.ExecContext(ctx, s.rebind(`UPDATE forms SET lenient = ?, updated_at = ? WHERE id = ?`),
+		form.Lenient, form.UpdatedAt.UTC(), form.ID.Hex())
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLStore) ClaimUnownedForms(ctx context.Context, ownerID primitive.ObjectID) (int64, error) {
+	res, err := s.db.ExecContext(ctx, s.rebind(`UPDATE forms SET owner_id = ? WHERE owner_id = '' AND workspace_id = ''`),
+		ownerID.Hex())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (s *SQLStore) DeleteForm(ctx context.Context, id primitive.ObjectID) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, s.rebind(`DELETE FROM form_fields WHERE form_id = ?`), id.Hex()); err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx, s.rebind(`DELETE FROM forms WHERE id = ?`), id.Hex())
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (s *SQLStore) CreateResponse(ctx context.Context, resp *models.FormResponse) error {
+	if resp.ID.IsZero() {
+		resp.ID = primitive.NewObjectID()
+	}
+	answers, err := json.Marshal(resp.Responses)
+	if err != nil {
+		return err
+	}
+	hidden, err := json.Marshal(resp.Hidden)
+	if err != nil {
+		return err
+	}
+	other, err := json.Marshal(resp.Other)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, s.rebind(`INSERT INTO responses (id, form_id, answers, hidden, other, draft_id, revision, submitted_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`),
+		resp.ID.Hex(), resp.FormID.Hex(), string(answers), string(hidden), string(other), hexOrEmpty(resp.DraftID), resp.Revision, resp.SubmittedAt.UTC())
+	return err
+}
+
+func (s *SQLStore) ListResponses(ctx context.Context, formID primitive.ObjectID) ([]models.FormResponse, error) {
+	out := []models.FormResponse{}
+	err := s.ForEachResponse(ctx, formID, func(r models.FormResponse) error {
+		out = append(out, r)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+const responseColumns = `id, answers, hidden, other, draft_id, revision, submitted_at`
+
+func (s *SQLStore) GetResponse(ctx context.Context, formID, id primitive.ObjectID) (*models.FormResponse, error) {
+	row := s.db.QueryRowContext(ctx, s.rebind(`SELECT `+responseColumns+` FROM responses WHERE form_id = ? AND id = ?`), formID.Hex(), id.Hex())
+	r, err := scanResponse(row, formID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &r, nil
+}
+
+// ForEachResponse streams rows while fn runs; with SQLite the single
+// connection is held until iteration ends, so fn must not call the store.
+func (s *SQLStore) ForEachResponse(ctx context.Context, formID primitive.ObjectID, fn func(models.FormResponse) error) error {
+	rows, err := s.db.QueryContext(ctx, s.rebind(`SELECT `+responseColumns+` FROM responses
+		WHERE form_id = ? ORDER BY submitted_at`), formID.Hex())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		r, err := scanResponse(rows, formID)
+		if err != nil {
+			return err
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func scanResponse(row interface{ Scan(...interface{}) error }, formID primitive.ObjectID) (models.FormResponse, error) {
+	var (
+		id, answers, hidden, other, draftID string
+		r                                   = models.FormResponse{FormID: formID}
+	)
+	err := row.Scan(&id, &answers, &hidden, &other, &draftID, &r.Revision, &r.SubmittedAt)
+	if err != nil {
+		return r, err
+	}
+	if r.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return r, err
+	}
+	if r.DraftID, err = parseHexOrEmpty(draftID); err != nil {
+		return r, err
+	}
+	if err := json.Unmarshal([]byte(answers), &r.Responses); err != nil {
+		return r, err
+	}
+	if err := json.Unmarshal([]byte(hidden), &r.Hidden); err != nil {
+		return r, err
+	}
+	if err := json.Unmarshal([]byte(other), &r.Other); err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
+func (s *SQLStore) CountResponsesSince(ctx context.Context, formID primitive.ObjectID, since time.Time) (int64, error) {
+	var n int64
+	err := s.db.QueryRowContext(ctx, s.rebind(`SELECT COUNT(*) FROM responses WHERE form_id = ? AND submitted_at >= ?`),
+		formID.Hex(), since.UTC()).Scan(&n)
+	return n, err
+}
+
+func (s *SQLStore) DeleteResponses(ctx context.Context, formID primitive.ObjectID) error {
+	_, err := s.db.ExecContext(ctx, s.rebind(`DELETE FROM responses WHERE form_id = ?`), formID.Hex())
+	return err
+}
+
+func (s *SQLStore) GetAggregate(ctx context.Context, formID primitive.ObjectID) (*models.FormAggregate, error) {
+	var data string
+	err := s.db.QueryRowContext(ctx, s.rebind(`SELECT data FROM form_aggregates WHERE form_id = ?`), formID.Hex()).Scan(&data)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	var agg models.FormAggregate
+	if err := json.Unmarshal([]byte(data), &agg); err != nil {
+		return nil, err
+	}
+	return &agg, nil
+}
+
+func (s *SQLStore) SaveAggregate(ctx context.Context, agg *models.FormAggregate) error {
+	data, err := json.Marshal(agg)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, s.rebind(`INSERT INTO form_aggregates (form_id, data, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT (form_id) DO UPDATE SET data = excluded.data, updated_at = excluded.updated_at`),
+		agg.FormID.Hex(), string(data), agg.UpdatedAt.UTC())
+	return err
+}
+
+func (s *SQLStore) DeleteAggregate(ctx context.Context, formID primitive.ObjectID) error {
+	_, err := s.db.ExecContext(ctx, s.rebind(`DELETE FROM form_aggregates WHERE form_id = ?`), formID.Hex())
+	return err
+}
+
+func (s *SQLStore) CreateUser(ctx context.Context, user *models.User) error {
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	_, err := s.db.ExecContext(ctx, s.rebind(`INSERT INTO users (id, email, name, password_hash, created_at) VALUES (?, ?, ?, ?, ?)`),
+		user.ID.Hex(), user.Email, user.Name, user.PasswordHash, user.CreatedAt.UTC())
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *SQLStore) GetUser(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	return s.findUser(ctx, `id = ?`, id.Hex())
+}
+
+func (s *SQLStore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	return s.findUser(ctx, `email = ?`, email)
+}
+
+func (s *SQLStore) findUser(ctx context.Context, where string, arg interface{}) (*models.User, error) {
+	var (
+		u  models.User
+		id string
+	)
+	err := s.db.QueryRowContext(ctx, s.rebind(`SELECT id, email, name, password_hash, created_at FROM users WHERE `+where), arg).
+		Scan(&id, &u.Email, &u.Name, &u.PasswordHash, &u.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if u.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (s *SQLStore) CreateWorkspace(ctx context.Context, ws *models.Workspace) error {
+	if ws.ID.IsZero() {
+		ws.ID = primitive.NewObjectID()
+	}
+	_, err := s.db.ExecContext(ctx, s.rebind(`INSERT INTO workspaces (id, name, created_by, created_at) VALUES (?, ?, ?, ?)`),
+		ws.ID.Hex(), ws.Name, ws.CreatedBy.Hex(), ws.CreatedAt.UTC())
+	return err
+}
+
+func (s *SQLStore) GetWorkspace(ctx context.Context, id primitive.ObjectID) (*models.Workspace, error) {
+	var (
+		ws        models.Workspace
+		createdBy string
+	)
+	err := s.db.QueryRowContext(ctx, s.rebind(`SELECT name, created_by, created_at FROM workspaces WHERE id = ?`), id.Hex()).
+		Scan(&ws.Name, &createdBy, &ws.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	ws.ID = id
+	if ws.CreatedBy, err = primitive.ObjectIDFromHex(createdBy); err != nil {
+		return nil, err
+	}
+	return &ws, nil
+}
+
+func (s *SQLStore) DeleteWorkspace(ctx context.Context, id primitive.ObjectID) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, s.rebind(`DELETE FROM workspace_members WHERE workspace_id = ?`), id.Hex()); err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx, s.rebind(`DELETE FROM workspaces WHERE id = ?`), id.Hex())
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (s *SQLStore) SetMember(ctx context.Context, member *models.WorkspaceMember) error {
+	_, err := s.db.ExecContext(ctx, s.rebind(`INSERT INTO workspace_members (workspace_id, user_id, role, added_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (workspace_id, user_id) DO UPDATE SET role = excluded.role`),
+		member.WorkspaceID.Hex(), member.UserID.Hex(), string(member.Role), member.AddedAt.UTC())
+	return err
+}
+
+func (s *SQLStore) GetMember(ctx context.Context, workspaceID, userID primitive.ObjectID) (*models.WorkspaceMember, error) {
+	members, err := s.queryMembers(ctx, `workspace_id = ? AND user_id = ?`, workspaceID.Hex(), userID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, ErrNotFound
+	}
+	return &members[0], nil
+}
+
+func (s *SQLStore) RemoveMember(ctx context.Context, workspaceID, userID primitive.ObjectID) error {
+	res, err := s.db.ExecContext(ctx, s.rebind(`DELETE FROM workspace_members WHERE workspace_id = ? AND user_id = ?`),
+		workspaceID.Hex(), userID.Hex())
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLStore) ListMembers(ctx context.Context, workspaceID primitive.ObjectID) ([]models.WorkspaceMember, error) {
+	return s.queryMembers(ctx, `workspace_id = ?`, workspaceID.Hex())
+}
+
+func (s *SQLStore) ListMemberships(ctx context.Context, userID primitive.ObjectID) ([]models.WorkspaceMember, error) {
+	return s.queryMembers(ctx, `user_id = ?`, userID.Hex())
+}
+
+func (s *SQLStore) queryMembers(ctx context.Context, where string, args ...interface{}) ([]models.WorkspaceMember, error) {
+	rows, err := s.db.QueryContext(ctx, s.rebind(`SELECT workspace_id, user_id, role, added_at FROM workspace_members
+		WHERE `+where+` ORDER BY added_at`), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.WorkspaceMember{}
+	for rows.Next() {
+		var (
+			m                   models.WorkspaceMember
+			workspaceID, userID string
+			role                string
+		)
+		if err := rows.Scan(&workspaceID, &userID, &role, &m.AddedAt); err != nil {
+			return nil, err
+		}
+		if m.WorkspaceID, err = primitive.ObjectIDFromHex(workspaceID); err != nil {
+			return nil, err
+		}
+		if m.UserID, err = primitive.ObjectIDFromHex(userID); err != nil {
+			return nil, err
+		}
+		m.Role = models.Role(role)
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+const apiKeyColumns = `id, user_id, name, prefix, key_hash, scopes, created_at, rotated_at, last_used_at, revoked_at`
+
+func (s *SQLStore) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	if key.ID.IsZero() {
+		key.ID = primitive.NewObjectID()
+	}
+	scopes, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, s.rebind(`INSERT INTO api_keys (`+apiKeyColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		key.ID.Hex(), key.UserID.Hex(), key.Name, key.Prefix, key.KeyHash, string(scopes), key.CreatedAt.UTC(),
+		nullTime(key.RotatedAt), nullTime(key.LastUsedAt), nullTime(key.RevokedAt))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *SQLStore) GetAPIKey(ctx context.Context, id primitive.ObjectID) (*models.APIKey, error) {
+	return s.findAPIKey(ctx, `id = ?`, id.Hex())
+}
+
+func (s *SQLStore) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	return s.findAPIKey(ctx, `key_hash = ?`, hash)
+}
+
+func (s *SQLStore) findAPIKey(ctx context.Context, where string, arg interface{}) (*models.APIKey, error) {
+	key, err := scanAPIKey(s.db.QueryRowContext(ctx, s.rebind(`SELECT `+apiKeyColumns+` FROM api_keys WHERE `+where), arg))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (s *SQLStore) ListAPIKeys(ctx context.Context, userID primitive.ObjectID) ([]models.APIKey, error) {
+	rows, err := s.db.QueryContext(ctx, s.rebind(`SELECT `+apiKeyColumns+` FROM api_keys WHERE user_id = ? ORDER BY created_at`), userID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, key)
+	}
+	return out, rows.Err()
+}
+
+func (s *SQLStore) UpdateAPIKey(ctx context.Context, key *models.APIKey) error {
+	res, err := s.db.ExecContext(ctx, s.rebind(`UPDATE api_keys SET prefix = ?, key_hash = ?, rotated_at = ?, revoked_at = ? WHERE id = ?`),
+		key.Prefix, key.KeyHash, nullTime(key.RotatedAt), nullTime(key.RevokedAt), key.ID.Hex())
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLStore) TouchAPIKey(ctx context.Context, id primitive.ObjectID, usedAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, s.rebind(`UPDATE api_keys SET last_used_at = ? WHERE id = ?`), usedAt.UTC(), id.Hex())
+	return err
+}
+
+func scanAPIKey(row interface{ Scan(...interface{}) error }) (models.APIKey, error) {
+	var (
+		k                            models.APIKey
+		id, userID, scopes           string
+		rotatedAt, usedAt, revokedAt sql.NullTime
+	)
+	if err := row.Scan(&id, &userID, &k.Name, &k.Prefix, &k.KeyHash, &scopes, &k.CreatedAt, &rotatedAt, &usedAt, &revokedAt); err != nil {
+		return k, err
+	}
+	var err error
+	if k.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return k, err
+	}
+	if k.UserID, err = primitive.ObjectIDFromHex(userID); err != nil {
+		return k, err
+	}
+	if err := json.Unmarshal([]byte(scopes), &k.Scopes); err != nil {
+		return k, err
+	}
+	k.RotatedAt = timePtr(rotatedAt)
+	k.LastUsedAt = timePtr(usedAt)
+	k.RevokedAt = timePtr(revokedAt)
+	return k, nil
+}
+
+// nullTime converts an optional time into a value for a nullable column
+func nullTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+const draftColumns = `id, form_id, token_hash, answers, page_id, created_at, updated_at, expires_at`
+
+func (s *SQLStore) CreateDraft(ctx context.Context, draft *models.Draft) error {
+	if draft.ID.IsZero() {
+		draft.ID = primitive.NewObjectID()
+	}
+	answers, err := json.Marshal(draft.Responses)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, s.rebind(`INSERT INTO drafts (`+draftColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`),
+		draft.ID.Hex(), draft.FormID.Hex(), draft.TokenHash, string(answers), draft.PageID,
+		draft.CreatedAt.UTC(), draft.UpdatedAt.UTC(), draft.ExpiresAt.UTC())
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *SQLStore) GetDraftByTokenHash(ctx context.Context, hash string) (*models.Draft, error) {
+	var (
+		d                   models.Draft
+		id, formID, answers string
+	)
+	err := s.db.QueryRowContext(ctx, s.rebind(`SELECT `+draftColumns+` FROM drafts WHERE token_hash = ?`), hash).
+		Scan(&id, &formID, &d.TokenHash, &answers, &d.PageID, &d.CreatedAt, &d.UpdatedAt, &d.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if d.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return nil, err
+	}
+	if d.FormID, err = primitive.ObjectIDFromHex(formID); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(answers), &d.Responses); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (s *SQLStore) UpdateDraft(ctx context.Context, draft *models.Draft) error {
+	answers, err := json.Marshal(draft.Responses)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, s.rebind(`UPDATE drafts SET answers = ?, page_id = ?, updated_at = ?, expires_at = ? WHERE id = ?`),
+		string(answers), draft.PageID, draft.UpdatedAt.UTC(), draft.ExpiresAt.UTC(), draft.ID.Hex())
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLStore) PassDraftPage(ctx context.Context, id primitive.ObjectID, pageID string) (bool, error) {
+	var n int
+	if err := s.db.QueryRowContext(ctx, s.rebind(`SELECT COUNT(*) FROM drafts WHERE id = ?`), id.Hex()).Scan(&n); err != nil {
+		return false, err
+	}
+	if n == 0 {
+		return false, ErrNotFound
+	}
+	_, err := s.db.ExecContext(ctx, s.rebind(`INSERT INTO draft_pages (draft_id, page_id) VALUES (?, ?)`), id.Hex(), pageID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *SQLStore) DeleteDraft(ctx context.Context, id primitive.ObjectID) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, s.rebind(`DELETE FROM drafts WHERE id = ?`), id.Hex())
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return ErrNotFound
+		}
+		_, err = tx.ExecContext(ctx, s.rebind(`DELETE FROM draft_pages WHERE draft_id = ?`), id.Hex())
+		return err
+	})
+}
+
+func (s *SQLStore) DeleteDrafts(ctx context.Context, formID primitive.ObjectID) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, s.rebind(`DELETE FROM draft_pages WHERE draft_id IN (SELECT id FROM drafts WHERE form_id = ?)`), formID.Hex()); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, s.rebind(`DELETE FROM drafts WHERE form_id = ?`), formID.Hex())
+		return err
+	})
+}
+
+const revisionColumns = `form_id, revision, title, description, fields, pages, created_by, created_at`
+
+func (s *SQLStore) CreateRevision(ctx context.Context, rev *models.FormRevision) error {
+	fields, err := json.Marshal(rev.Fields)
+	if err != nil {
+		return err
+	}
+	pages, err := json.Marshal(pagesOrEmpty(rev.Pages))
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, s.rebind(`INSERT INTO form_revisions (`+revisionColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`),
+		rev.FormID.Hex(), rev.Revision, rev.Title, rev.Description, string(fields), string(pages),
+		hexOrEmpty(rev.CreatedBy), rev.CreatedAt.UTC())
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *SQLStore) GetRevision(ctx context.Context, formID primitive.ObjectID, revision int) (*models.FormRevision, error) {
+	revs, err := s.queryRevisions(ctx, `form_id = ? AND revision = ?`, formID.Hex(), revision)
+	if err != nil {
+		return nil, err
+	}
+	if len(revs) == 0 {
+		return nil, ErrNotFound
+	}
+	return &revs[0], nil
+}
+
+func (s *SQLStore) ListRevisions(ctx context.Context, formID primitive.ObjectID) ([]models.FormRevision, error) {
+	return s.queryRevisions(ctx, `form_id = ?`, formID.Hex())
+}
+
+func (s *SQLStore) queryRevisions(ctx context.Context, where string, args ...interface{}) ([]models.FormRevision, error) {
+	rows, err := s.db.QueryContext(ctx, s.rebind(`SELECT `+revisionColumns+` FROM form_revisions WHERE `+where+` ORDER BY revision`), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.FormRevision{}
+	for rows.Next() {
+		var (
+			r                                models.FormRevision
+			formID, fields, pages, createdBy string
+		)
+		if err := rows.Scan(&formID, &r.Revision, &r.Title, &r.Description, &fields, &pages, &createdBy, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		if r.FormID, err = primitive.ObjectIDFromHex(formID); err != nil {
+			return nil, err
+		}
+		if r.CreatedBy, err = parseHexOrEmpty(createdBy); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(fields), &r.Fields); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(pages), &r.Pages); err != nil {
+			return nil, err
+		}
+		if len(r.Pages) == 0 {
+			r.Pages = nil
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+func (s *SQLStore) DeleteRevisions(ctx context.Context, formID primitive.ObjectID) error {
+	_, err := s.db.ExecContext(ctx, s.rebind(`DELETE FROM form_revisions WHERE form_id = ?`), formID.Hex())
+	return err
+}
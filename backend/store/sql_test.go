@@ -0,0 +1,122 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"custom-form-builder/models"
+)
+
+func openTestSQLStore(t *testing.T) *SQLStore {
+	t.Helper()
+	ctx := context.Background()
+	s, err := OpenSQLStore(ctx, DialectSQLite, "file:"+filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	if err := s.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestMigrateTypedAnswers(t *testing.T) {
+	ctx := context.Background()
+	s := openTestSQLStore(t)
+
+	form := models.Form{
+		Title:         "Legacy",
+		ShareableLink: "legacy",
+		Fields: []models.Field{
+			{ID: "colors", Type: models.FieldTypeCheckbox, Label: "Colors", Options: []string{"Red, dark", "Blue"}},
+			{ID: "age", Type: models.FieldTypeNumber, Label: "Age"},
+			{ID: "name", Type: models.FieldTypeText, Label: "Name"},
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := s.CreateForm(ctx, &form); err != nil {
+		t.Fatal(err)
+	}
+
+	// Answers as stored before they were typed, in ID order
+	ids := []string{"000000000000000000000001", "000000000000000000000002", "000000000000000000000003"}
+	legacy := []string{
+		`{"colors":"Red, dark, Blue","age":"42","name":"Ada"}`,
+		`{"colors":"Blue","age":"7"}`,
+		`{"colors":"Blue, Green","name":"12"}`,
+	}
+	for i, id := range ids {
+		if _, err := s.db.ExecContext(ctx, `INSERT INTO responses (id, form_id, answers, submitted_at) VALUES (?, ?, ?, ?)`,
+			id, form.ID.Hex(), legacy[i], time.Now().UTC()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rerun := func(position string) {
+		t.Helper()
+		if _, err := s.db.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = '0014_typed_answers'`); err != nil {
+			t.Fatal(err)
+		}
+		if position != "" {
+			if _, err := s.db.ExecContext(ctx, `INSERT INTO migration_progress (version, position) VALUES ('0014_typed_answers', ?)`, position); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := s.Migrate(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	answers := func(id string) map[string]models.Answer {
+		t.Helper()
+		objectID, _ := primitive.ObjectIDFromHex(id)
+		resp, err := s.GetResponse(ctx, form.ID, objectID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Responses
+	}
+
+	// A run interrupted after the first response resumes after it
+	rerun(ids[0])
+	if a := answers(ids[0])["colors"]; a.Kind != models.AnswerText {
+		t.Errorf("response before the recorded position was rewritten: %+v", a)
+	}
+	if a := answers(ids[1])["age"]; a.Kind != models.AnswerNumber || a.Number != 7 {
+		t.Errorf("age = %+v, want the number 7", a)
+	}
+	var pending int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM migration_progress`).Scan(&pending); err != nil {
+		t.Fatal(err)
+	}
+	if pending != 0 {
+		t.Errorf("progress left behind after the migration finished")
+	}
+
+	// A full run converts everything; typed answers stay as they are
+	rerun("")
+	tests := []struct {
+		name string
+		id   string
+		key  string
+		want models.Answer
+	}{
+		{"options with commas", ids[0], "colors", models.ChoicesAnswer([]string{"Red, dark", "Blue"})},
+		{"number from string", ids[0], "age", models.NumberAnswer(42)},
+		{"text stays text", ids[0], "name", models.TextAnswer("Ada")},
+		{"single pick", ids[1], "colors", models.ChoicesAnswer([]string{"Blue"})},
+		{"unlisted picks split on commas", ids[2], "colors", models.ChoicesAnswer([]string{"Blue", "Green"})},
+		{"numeric text stays text", ids[2], "name", models.TextAnswer("12")},
+	}
+	for _, tt := range tests {
+		if got := answers(tt.id)[tt.key]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"custom-form-builder/models"
+)
+
+// ErrNotFound is returned when the requested document does not exist
+var ErrNotFound = errors.New("not found")
+
+// ErrDuplicate is returned when a value that must be unique is already taken
+var ErrDuplicate = errors.New("duplicate")
+
+// ErrConflict is returned when a document changed since it was read
+var ErrConflict = errors.New("conflict")
+
+// FormFilter narrows ListForms. The zero value matches every form.
+type FormFilter struct {
+	// WorkspaceID limits results to the forms of this workspace
+	WorkspaceID primitive.ObjectID
+	// OwnerID, when WorkspaceID is not set, limits results to the personal
+	// (workspace-less) forms owned by this user
+	OwnerID primitive.ObjectID
+}
+
+// FormStore persists form definitions
+type FormStore interface {
+	CreateForm(ctx context.Context, form *models.Form) error
+	ListForms(ctx context.Context, filter FormFilter) ([]models.Form, error)
+	GetForm(ctx context.Context, id primitive.ObjectID) (*models.Form, error)
+	GetFormByShareableLink(ctx context.Context, link string) (*models.Form, error)
+	GetFormBySlug(ctx context.Context, slug string) (*models.Form, error)
+	// UpdateForm saves a form's definition and revision if its stored
+	// revision is still prevRevision, and gives ErrConflict otherwise
+	UpdateForm(ctx context.Context, form *models.Form, prevRevision int) error
+	// UpdateFormStatus saves a form's status, schedule, response limit and
+	// closed message, leaving its definition alone
+	UpdateFormStatus(ctx context.Context, form *models.Form) error
+	// UpdateFormLink saves a form's shareable link, slug and LinkOnly
+	// setting. An empty link is revoked; a slug already used by another
+	// form gives ErrDuplicate.
+	UpdateFormLink(ctx context.Context, form *models.Form) error
+	// UpdateFormStrictMode saves a form's Lenient setting
+	UpdateFormStrictMode(ctx context.Context, form *models.Form) error
+	// ClaimUnownedForms gives the personal forms without an owner, created
+	// before accounts existed, to ownerID and returns how many it changed
+	ClaimUnownedForms(ctx context.Context, ownerID primitive.ObjectID) (int64, error)
+	DeleteForm(ctx context.Context, id primitive.ObjectID) error
+}
+
+// ResponseStore persists submitted responses
+type ResponseStore interface {
+	CreateResponse(ctx context.Context, resp *models.FormResponse) error
+	ListResponses(ctx context.Context, formID primitive.ObjectID) ([]models.FormResponse, error)
+	// GetResponse returns one response to the form formID
+	GetResponse(ctx context.Context, formID, id primitive.ObjectID) (*models.FormResponse, error)
+	// ForEachResponse streams the responses of a form in submission order.
+	// Iteration stops at the first error returned by fn.
+	ForEachResponse(ctx context.Context, formID primitive.ObjectID, fn func(models.FormResponse) error) error
+	CountResponsesSince(ctx context.Context, formID primitive.ObjectID, since time.Time) (int64, error)
+	DeleteResponses(ctx context.Context, formID primitive.ObjectID) error
+}
+
+// AggregateStore persists the per-form analytics aggregates
+type AggregateStore interface {
+	GetAggregate(ctx context.Context, formID primitive.ObjectID) (*models.FormAggregate, error)
+	// SaveAggregate inserts or replaces the aggregate of agg.FormID
+	SaveAggregate(ctx context.Context, agg *models.FormAggregate) error
+	DeleteAggregate(ctx context.Context, formID primitive.ObjectID) error
+}
+
+// UserStore persists user accounts
+type UserStore interface {
+	// CreateUser returns ErrDuplicate if the email is already registered
+	CreateUser(ctx context.Context, user *models.User) error
+	GetUser(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+}
+
+// WorkspaceStore persists workspaces and their memberships
+type WorkspaceStore interface {
+	CreateWorkspace(ctx context.Context, ws *models.Workspace) error
+	GetWorkspace(ctx context.Context, id primitive.ObjectID) (*models.Workspace, error)
+	// DeleteWorkspace removes the workspace and all of its memberships
+	DeleteWorkspace(ctx context.Context, id primitive.ObjectID) error
+	// SetMember adds the member or changes the role of an existing one
+	SetMember(ctx context.Context, member *models.WorkspaceMember) error
+	GetMember(ctx context.Context, workspaceID, userID primitive.ObjectID) (*models.WorkspaceMember, error)
+	RemoveMember(ctx context.Context, workspaceID, userID primitive.ObjectID) error
+	ListMembers(ctx context.Context, workspaceID primitive.ObjectID) ([]models.WorkspaceMember, error)
+	// ListMemberships returns every workspace membership of a user
+	ListMemberships(ctx context.Context, userID primitive.ObjectID) ([]models.WorkspaceMember, error)
+}
+
+// APIKeyStore persists API keys
+type APIKeyStore interface {
+	CreateAPIKey(ctx context.Context, key *models.APIKey) error
+	GetAPIKey(ctx context.Context, id primitive.ObjectID) (*models.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	// ListAPIKeys returns a user's keys, including revoked ones
+	ListAPIKeys(ctx context.Context, userID primitive.ObjectID) ([]models.APIKey, error)
+	// UpdateAPIKey saves the key's prefix, hash, rotation and revocation
+	// times
+	UpdateAPIKey(ctx context.Context, key *models.APIKey) error
+	TouchAPIKey(ctx context.Context, id primitive.ObjectID, usedAt time.Time) error
+}
+
+// DraftStore persists partially filled responses
+type DraftStore interface {
+	CreateDraft(ctx context.Context, draft *models.Draft) error
+	GetDraftByTokenHash(ctx context.Context, hash string) (*models.Draft, error)
+	// UpdateDraft saves the draft's answers, page and timestamps
+	UpdateDraft(ctx context.Context, draft *models.Draft) error
+	// PassDraftPage records that a draft moved past a page and reports
+	// whether it hadn't before
+	PassDraftPage(ctx context.Context, id primitive.ObjectID, pageID string) (bool, error)
+	DeleteDraft(ctx context.Context, id primitive.ObjectID) error
+	// DeleteDrafts removes every draft of a form
+	DeleteDrafts(ctx context.Context, formID primitive.ObjectID) error
+}
+
+// RevisionStore persists immutable form revisions
+type RevisionStore interface {
+	// CreateRevision returns ErrDuplicate if the form already has a
+	// revision with that number
+	CreateRevision(ctx context.Context, rev *models.FormRevision) error
+	GetRevision(ctx context.Context, formID primitive.ObjectID, revision int) (*models.FormRevision, error)
+	// ListRevisions returns a form's revisions, oldest first
+	ListRevisions(ctx context.Context, formID primitive.ObjectID) ([]models.FormRevision, error)
+	DeleteRevisions(ctx context.Context, formID primitive.ObjectID) error
+}
+
+// Store is the storage backend used by the HTTP handlers
+type Store interface {
+	FormStore
+	ResponseStore
+	AggregateStore
+	UserStore
+	WorkspaceStore
+	APIKeyStore
+	DraftStore
+	RevisionStore
+}
@@ -0,0 +1,235 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"regexp"
+	"strings"
+	"time"
+
+	"custom-form-builder/models"
+)
+
+// messageCodes are the rule codes a field's Messages may override
+var messageCodes = map[string]bool{
+	models.CodeRequired:      true,
+	models.CodeInvalidEmail:  true,
+	models.CodeInvalidNumber: true,
+	models.CodeBelowMin:      true,
+	models.CodeAboveMax:      true,
+	models.CodeOutOfRange:    true,
+	models.CodeTooShort:      true,
+	models.CodeTooLong:       true,
+	models.CodePattern:       true,
+	models.CodeInvalidOption: true,
+	models.CodeTooFew:        true,
+	models.CodeTooMany:       true,
+	models.CodeNotInteger:    true,
+	models.CodeInvalidStep:   true,
+	models.CodeInvalidDate:   true,
+	models.CodeTooEarly:      true,
+	models.CodeTooLate:       true,
+	models.CodeTooManyFiles:  true,
+	models.CodeFileTooLarge:  true,
+	models.CodeFileType:      true,
+	models.CodeInvalidRow:    true,
+	models.CodeDuplicate:     true,
+	models.CodeInvalidBool:   true,
+	models.CodeInvalidPhone:  true,
+	models.CodeInvalidURL:    true,
+}
+
+// CheckRules checks that the validation rules configured on fields make
+// sense: bounds that aren't negative or crossed, patterns that compile and
+// messages for known rules only.
+func CheckRules(fields []models.Field) error {
+	for _, f := range fields {
+		if err := checkBounds("length", f.MinLength, f.MaxLength); err != nil {
+			return fmt.Errorf("field %q: %v", f.Label, err)
+		}
+		if err := checkBounds("selections", f.MinSelections, f.MaxSelections); err != nil {
+			return fmt.Errorf("field %q: %v", f.Label, err)
+		}
+		if f.MinSelections != nil && len(f.Options) > 0 && *f.MinSelections > len(f.Options) {
+			return fmt.Errorf("field %q: minimum selections exceeds the number of options", f.Label)
+		}
+		if f.MinValue != nil && f.MaxValue != nil && *f.MinValue > *f.MaxValue {
+			return fmt.Errorf("field %q: minimum value is above maximum value", f.Label)
+		}
+		if f.Pattern != "" {
+			// Compiled on its own so "a)|(b" can't escape the anchoring
+			if _, err := regexp.Compile(f.Pattern); err != nil {
+				return fmt.Errorf("field %q: invalid pattern: %v", f.Label, err)
+			}
+		}
+		if f.Step != nil && *f.Step <= 0 {
+			return fmt.Errorf("field %q: step must be positive", f.Label)
+		}
+		if f.Type.IsTemporal() {
+			if err := checkTemporalRules(f); err != nil {
+				return fmt.Errorf("field %q: %v", f.Label, err)
+			}
+		}
+		if f.Type == models.FieldTypeFile {
+			if err := checkFileRules(f); err != nil {
+				return fmt.Errorf("field %q: %v", f.Label, err)
+			}
+		}
+		if f.Type == models.FieldTypeMatrix {
+			if err := checkMatrixRules(f); err != nil {
+				return fmt.Errorf("field %q: %v", f.Label, err)
+			}
+		}
+		if f.Type == models.FieldTypeRanking {
+			if err := checkRankingRules(f); err != nil {
+				return fmt.Errorf("field %q: %v", f.Label, err)
+			}
+		}
+		if f.Type == models.FieldTypeDropdown {
+			if err := checkDropdownRules(f); err != nil {
+				return fmt.Errorf("field %q: %v", f.Label, err)
+			}
+		}
+		if f.Type == models.FieldTypeSlider {
+			lo, hi, step := f.SliderRange()
+			if lo >= hi {
+				return fmt.Errorf("field %q: slider must go from a lower to a higher value", f.Label)
+			}
+			if step > hi-lo {
+				return fmt.Errorf("field %q: step is larger than the slider's range", f.Label)
+			}
+		}
+		if f.Type == models.FieldTypePhone && f.CountryCode != "" && !countryCode.MatchString(f.CountryCode) {
+			return fmt.Errorf("field %q: invalid country code %q", f.Label, f.CountryCode)
+		}
+		if f.Type == models.FieldTypeRating {
+			if lo, hi := RatingScale(f); lo >= hi {
+				return fmt.Errorf("field %q: rating scale must go from a lower to a higher number", f.Label)
+			}
+		}
+		if f.AllowOther && !f.TakesOther() {
+			return fmt.Errorf("field %q: only multiple choice, dropdown and checkbox fields can take an \"Other\" answer", f.Label)
+		}
+		for code := range f.Messages {
+			if !messageCodes[code] {
+				return fmt.Errorf("field %q: message for unknown rule %q", f.Label, code)
+			}
+		}
+	}
+	return nil
+}
+
+// checkTemporalRules checks a date, time or datetime field's timezone and
+// that its bounds are written like its answers and in order
+func checkTemporalRules(f models.Field) error {
+	if f.Timezone != "" {
+		if _, err := time.LoadLocation(f.Timezone); err != nil {
+			return fmt.Errorf("unknown timezone %q", f.Timezone)
+		}
+	}
+	var min, max time.Time
+	var err error
+	if f.Earliest != "" {
+		if min, err = f.ParseTemporal(f.Earliest); err != nil {
+			return fmt.Errorf("earliest %q isn't a valid %s", f.Earliest, temporalNames[f.Type])
+		}
+	}
+	if f.Latest != "" {
+		if max, err = f.ParseTemporal(f.Latest); err != nil {
+			return fmt.Errorf("latest %q isn't a valid %s", f.Latest, temporalNames[f.Type])
+		}
+	}
+	if f.Earliest != "" && f.Latest != "" && min.After(max) {
+		return errors.New("earliest is after latest")
+	}
+	return nil
+}
+
+// checkFileRules checks a file field's limits and that it accepts MIME
+// types written as "type/subtype" or "type/*"
+func checkFileRules(f models.Field) error {
+	if f.MaxFiles != nil && *f.MaxFiles < 1 {
+		return errors.New("must take at least one file")
+	}
+	if f.MaxFileSize != nil && *f.MaxFileSize < 1 {
+		return errors.New("maximum file size must be positive")
+	}
+	for _, a := range f.Accept {
+		mt, _, err := mime.ParseMediaType(a)
+		if err != nil || !strings.Contains(mt, "/") || strings.HasPrefix(mt, "*") {
+			return fmt.Errorf("invalid accepted type %q", a)
+		}
+	}
+	return nil
+}
+
+// checkMatrixRules checks that a matrix field has rows and columns, each
+// named and listed once
+func checkMatrixRules(f models.Field) error {
+	if len(f.Rows) == 0 || len(f.Columns) == 0 {
+		return errors.New("a matrix needs at least one row and one column")
+	}
+	for i, list := range [][]string{f.Rows, f.Columns} {
+		what := [...]string{"row", "column"}[i]
+		seen := make(map[string]bool, len(list))
+		for _, s := range list {
+			if strings.TrimSpace(s) == "" {
+				return fmt.Errorf("%s names can't be empty", what)
+			}
+			if seen[s] {
+				return fmt.Errorf("%s %q is listed twice", what, s)
+			}
+			seen[s] = true
+		}
+	}
+	return nil
+}
+
+// countryCode matches a country calling code, with or without its +
+var countryCode = regexp.MustCompile(`^\+?[1-9][0-9]{0,2}$`)
+
+// checkDropdownRules checks that a dropdown has options to pick from, each
+// named and listed once
+func checkDropdownRules(f models.Field) error {
+	if len(f.Options) == 0 {
+		return errors.New("a dropdown needs at least one option")
+	}
+	seen := make(map[string]bool, len(f.Options))
+	for _, o := range f.Options {
+		if strings.TrimSpace(o) == "" {
+			return errors.New("option names can't be empty")
+		}
+		if seen[o] {
+			return fmt.Errorf("option %q is listed twice", o)
+		}
+		seen[o] = true
+	}
+	return nil
+}
+
+// checkRankingRules checks that a ranking has options to order, each
+// listed once
+func checkRankingRules(f models.Field) error {
+	if len(f.Options) < 2 {
+		return errors.New("a ranking needs at least two options")
+	}
+	seen := make(map[string]bool, len(f.Options))
+	for _, o := range f.Options {
+		if seen[o] {
+			return fmt.Errorf("option %q is listed twice", o)
+		}
+		seen[o] = true
+	}
+	return nil
+}
+
+func checkBounds(what string, min, max *int) error {
+	if (min != nil && *min < 0) || (max != nil && *max < 0) {
+		return fmt.Errorf("%s limits can't be negative", what)
+	}
+	if min != nil && max != nil && *min > *max {
+		return fmt.Errorf("minimum %s is above maximum", what)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	"custom-form-builder/models"
+)
+
+func floatPtr(f float64) *float64 { return &f }
+
+func TestFieldRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		field  models.Field
+		answer interface{}
+		code   string
+	}{
+		{"min length", models.Field{Type: models.FieldTypeText, MinLength: intPtr(3)}, "ab", models.CodeTooShort},
+		{"min length counts runes", models.Field{Type: models.FieldTypeText, MinLength: intPtr(3)}, "äöü", ""},
+		{"max length", models.Field{Type: models.FieldTypeTextarea, MaxLength: intPtr(3)}, "abcd", models.CodeTooLong},
+		{"pattern matches", models.Field{Type: models.FieldTypeText, Pattern: `[A-Z]{2}\d{3}`}, "AB123", ""},
+		{"pattern is anchored", models.Field{Type: models.FieldTypeText, Pattern: `[A-Z]{2}\d{3}`}, "xAB123", models.CodePattern},
+		{"email with display name", models.Field{Type: models.FieldTypeEmail}, "Ada <ada@example.com>", models.CodeInvalidEmail},
+		{"email with plus", models.Field{Type: models.FieldTypeEmail}, "ada+forms@example.com", ""},
+		{"email length rules apply", models.Field{Type: models.FieldTypeEmail, MaxLength: intPtr(5)}, "ada@example.com", models.CodeTooLong},
+		{"too few selections", models.Field{Type: models.FieldTypeCheckbox, MinSelections: intPtr(2)}, []interface{}{"A"}, models.CodeTooFew},
+		{"too many selections", models.Field{Type: models.FieldTypeCheckbox, MaxSelections: intPtr(1)}, []interface{}{"A", "B"}, models.CodeTooMany},
+		{"integer only", models.Field{Type: models.FieldTypeNumber, IntegerOnly: true}, 2.5, models.CodeNotInteger},
+		{"step from minimum", models.Field{Type: models.FieldTypeNumber, MinValue: intPtr(1), Step: floatPtr(2)}, 5, ""},
+		{"off step", models.Field{Type: models.FieldTypeNumber, MinValue: intPtr(1), Step: floatPtr(2)}, 4, models.CodeInvalidStep},
+		{"decimal step", models.Field{Type: models.FieldTypeNumber, Step: floatPtr(0.1)}, 0.3, ""},
+		{"custom rating scale", models.Field{Type: models.FieldTypeRating, Min: intPtr(0), Max: intPtr(10)}, 9, ""},
+		{"slider default range", models.Field{Type: models.FieldTypeSlider}, 100, ""},
+		{"slider out of range", models.Field{Type: models.FieldTypeSlider, MinValue: intPtr(1), MaxValue: intPtr(10)}, 0, models.CodeOutOfRange},
+		{"slider step from minimum", models.Field{Type: models.FieldTypeSlider, MinValue: intPtr(1), MaxValue: intPtr(10), Step: floatPtr(3)}, 7, ""},
+		{"slider off step", models.Field{Type: models.FieldTypeSlider, MinValue: intPtr(1), MaxValue: intPtr(10), Step: floatPtr(3)}, 6, models.CodeInvalidStep},
+		{"slider decimal step", models.Field{Type: models.FieldTypeSlider, MaxValue: intPtr(1), Step: floatPtr(0.1)}, 0.7, ""},
+		{"slider not a number", models.Field{Type: models.FieldTypeSlider}, "lots", models.CodeInvalidNumber},
+		{"phone in international form", models.Field{Type: models.FieldTypePhone}, "+44 20 7946 0958", ""},
+		{"phone without country code", models.Field{Type: models.FieldTypePhone}, "020 7946 0958", models.CodeInvalidPhone},
+		{"phone in field's country", models.Field{Type: models.FieldTypePhone, CountryCode: "44"}, "020 7946 0958", ""},
+		{"outside custom rating scale", models.Field{Type: models.FieldTypeRating, Min: intPtr(0), Max: intPtr(10)}, 11, models.CodeOutOfRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.field.ID = "f"
+			errs := Validate([]models.Field{tt.field}, map[string]interface{}{"f": tt.answer}, true)
+			switch {
+			case tt.code == "" && len(errs) != 0:
+				t.Errorf("unexpected errors: %+v", errs)
+			case tt.code != "" && (len(errs) != 1 || errs[0].Code != tt.code):
+				t.Errorf("errors = %+v, want one %s", errs, tt.code)
+			}
+		})
+	}
+}
+
+func TestCustomMessages(t *testing.T) {
+	field := models.Field{
+		ID:        "f",
+		Type:      models.FieldTypeText,
+		Required:  true,
+		MinLength: intPtr(3),
+		Messages:  map[string]string{models.CodeTooShort: "Tell us a bit more"},
+	}
+	tests := []struct {
+		answer  interface{}
+		message string
+	}{
+		{"ab", "Tell us a bit more"},
+		{"", "This field is required"},
+	}
+	for _, tt := range tests {
+		errs := Validate([]models.Field{field}, map[string]interface{}{"f": tt.answer}, true)
+		if len(errs) != 1 || errs[0].Message != tt.message {
+			t.Errorf("answer %q: errors = %+v, want message %q", tt.answer, errs, tt.message)
+		}
+	}
+}
+
+func TestCheckRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		field models.Field
+		err   string
+	}{
+		{"valid", models.Field{Type: models.FieldTypeText, MinLength: intPtr(1), MaxLength: intPtr(5), Pattern: `\w+`}, ""},
+		{"negative length", models.Field{Type: models.FieldTypeText, MinLength: intPtr(-1)}, "can't be negative"},
+		{"crossed length", models.Field{Type: models.FieldTypeText, MinLength: intPtr(5), MaxLength: intPtr(2)}, "length"},
+		{"more selections than options", models.Field{Type: models.FieldTypeCheckbox, Options: []string{"A"}, MinSelections: intPtr(2)}, "exceeds the number of options"},
+		{"crossed values", models.Field{Type: models.FieldTypeNumber, MinValue: intPtr(5), MaxValue: intPtr(1)}, "minimum value is above maximum"},
+		{"bad pattern", models.Field{Type: models.FieldTypeText, Pattern: `(`}, "invalid pattern"},
+		{"zero step", models.Field{Type: models.FieldTypeNumber, Step: floatPtr(0)}, "step must be positive"},
+		{"crossed rating scale", models.Field{Type: models.FieldTypeRating, Min: intPtr(5), Max: intPtr(5)}, "rating scale"},
+		{"crossed slider range", models.Field{Type: models.FieldTypeSlider, MinValue: intPtr(10), MaxValue: intPtr(10)}, "lower to a higher value"},
+		{"slider step beyond range", models.Field{Type: models.FieldTypeSlider, MaxValue: intPtr(5), Step: floatPtr(10)}, "step is larger"},
+		{"phone country code", models.Field{Type: models.FieldTypePhone, CountryCode: "+44"}, ""},
+		{"bad phone country code", models.Field{Type: models.FieldTypePhone, CountryCode: "044"}, "invalid country code"},
+		{"message for unknown rule", models.Field{Type: models.FieldTypeText, Messages: map[string]string{"nope": "x"}}, "unknown rule"},
+		{"message for known rule", models.Field{Type: models.FieldTypeText, Messages: map[string]string{models.CodeRequired: "x"}}, ""},
+		{"other on checkbox", models.Field{Type: models.FieldTypeCheckbox, Options: []string{"A"}, AllowOther: true}, ""},
+		{"other on text", models.Field{Type: models.FieldTypeText, AllowOther: true}, "\"Other\""},
+		{"ranking with one option", models.Field{Type: models.FieldTypeRanking, Options: []string{"A"}}, "at least two options"},
+		{"ranking with repeated option", models.Field{Type: models.FieldTypeRanking, Options: []string{"A", "B", "A"}}, "listed twice"},
+		{"other on ranking", models.Field{Type: models.FieldTypeRanking, Options: []string{"A", "B"}, AllowOther: true}, "\"Other\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.field.Label = "F"
+			err := CheckRules([]models.Field{tt.field})
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error = %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestCompilePatternCached(t *testing.T) {
+	first, err := compilePattern(`[a-z]+-cached`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := compilePattern(`[a-z]+-cached`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Error("pattern compiled twice")
+	}
+	if !first.MatchString("abc-cached") || first.MatchString("x abc-cached") {
+		t.Error("cached pattern isn't anchored")
+	}
+	if _, err := compilePattern(`(`); err == nil {
+		t.Error("invalid pattern compiled")
+	}
+	if _, ok := patterns.Load(`(`); ok {
+		t.Error("invalid pattern cached")
+	}
+}
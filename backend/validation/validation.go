@@ -0,0 +1,544 @@
+// Package validation checks submitted answers against their form fields.
+package validation
+
+import (
+	"fmt"
+	"math"
+	"mime"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"custom-form-builder/models"
+)
+
+// Validate checks answers against fields and returns every invalid answer,
+// in field order. Each field reports at most one error, from the first
+// rule it breaks. Choice answers must be listed options when strict is set,
+// unless the field allows "Other".
+func Validate(fields []models.Field, answers map[string]interface{}, strict bool) []models.FieldError {
+	var errs []models.FieldError
+	for _, f := range fields {
+		if err := check(f, answers[f.ID], strict && !f.TakesOther()); err != nil {
+			err.Field = f.ID
+			errs = append(errs, *err)
+		}
+	}
+	return errs
+}
+
+// Unknown reports answers to fields that aren't in fields, sorted by ID
+func Unknown(fields []models.Field, answers map[string]interface{}) []models.FieldError {
+	known := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		known[f.ID] = true
+	}
+	var ids []string
+	for id := range answers {
+		if !known[id] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	errs := make([]models.FieldError, 0, len(ids))
+	for _, id := range ids {
+		errs = append(errs, models.FieldError{Field: id, Code: models.CodeUnknownField, Message: "The form has no such field"})
+	}
+	return errs
+}
+
+// SplitOther moves the answers of fields that allow "Other" but aren't
+// among their options out of answers. It returns them by field ID, the
+// picks of a checkbox field joined with ", "; its listed picks stay in
+// answers as a list.
+func SplitOther(fields []models.Field, answers map[string]interface{}) map[string]string {
+	other := map[string]string{}
+	for _, f := range fields {
+		val, ok := answers[f.ID]
+		if !f.TakesOther() || !ok || isEmpty(val) {
+			continue
+		}
+		switch f.Type {
+		case models.FieldTypeMultipleChoice, models.FieldTypeDropdown:
+			if s := toString(val); !contains(f.Options, s) {
+				other[f.ID] = s
+				delete(answers, f.ID)
+			}
+		case models.FieldTypeCheckbox:
+			var listed, rest []string
+			for _, p := range selections(f, val) {
+				if contains(f.Options, p) {
+					listed = append(listed, p)
+				} else {
+					rest = append(rest, p)
+				}
+			}
+			if len(rest) > 0 {
+				other[f.ID] = strings.Join(rest, ", ")
+				answers[f.ID] = listed
+			}
+		}
+	}
+	if len(other) == 0 {
+		return nil
+	}
+	return other
+}
+
+func check(field models.Field, val interface{}, checkOptions bool) *models.FieldError {
+	err := checkRules(field, val, checkOptions)
+	if err != nil {
+		if msg := field.Messages[err.Code]; msg != "" {
+			err.Message = msg
+		}
+	}
+	return err
+}
+
+func checkRules(field models.Field, val interface{}, checkOptions bool) *models.FieldError {
+	if isEmpty(val) {
+		if field.Required {
+			return fail(models.CodeRequired, "This field is required", nil)
+		}
+		return nil
+	}
+
+	switch field.Type {
+	case models.FieldTypeText, models.FieldTypeTextarea:
+		return checkText(field, toString(val))
+	case models.FieldTypeEmail:
+		s := toString(val)
+		if !IsEmail(s) {
+			return fail(models.CodeInvalidEmail, "Invalid email format", nil)
+		}
+		return checkText(field, s)
+	case models.FieldTypeURL:
+		s := strings.TrimSpace(toString(val))
+		if !IsURL(s) {
+			return fail(models.CodeInvalidURL, "Enter a web address starting with http:// or https://", nil)
+		}
+		return checkText(field, s)
+	case models.FieldTypePhone:
+		if _, err := field.ParsePhone(toString(val)); err != nil {
+			if field.CountryCode != "" {
+				return fail(models.CodeInvalidPhone, "Invalid phone number", nil)
+			}
+			return fail(models.CodeInvalidPhone, "Enter a phone number with its country code, such as +1 555 123 4567", nil)
+		}
+	case models.FieldTypeBoolean:
+		if a, _ := models.NewAnswer(models.FieldTypeBoolean, val); a.Kind != models.AnswerBool {
+			return fail(models.CodeInvalidBool, "Answer yes or no", nil)
+		}
+	case models.FieldTypeNumber:
+		return checkNumber(field, val)
+	case models.FieldTypeSlider:
+		return checkSlider(field, val)
+	case models.FieldTypeMultipleChoice, models.FieldTypeDropdown:
+		s := toString(val)
+		if checkOptions && len(field.Options) > 0 && !contains(field.Options, s) {
+			return fail(models.CodeInvalidOption, "Not one of the available options", params("option", s))
+		}
+	case models.FieldTypeCheckbox:
+		return checkSelections(field, selections(field, val), checkOptions)
+	case models.FieldTypeDate, models.FieldTypeTime, models.FieldTypeDateTime:
+		return checkTemporal(field, toString(val))
+	case models.FieldTypeFile:
+		a, _ := models.NewAnswer(models.FieldTypeFile, val)
+		return checkFiles(field, a.Files)
+	case models.FieldTypeMatrix:
+		return checkMatrix(field, val)
+	case models.FieldTypeRanking:
+		return checkRanking(field, val)
+	case models.FieldTypeNPS:
+		r, ok := toFloat(val)
+		if !ok || r < 0 || r > 10 || r != math.Trunc(r) {
+			return fail(models.CodeOutOfRange, "Score must be a whole number from 0 to 10", params("min", 0, "max", 10))
+		}
+	case models.FieldTypeRating:
+		lo, hi := RatingScale(field)
+		r, ok := toFloat(val)
+		if !ok || r < float64(lo) || r > float64(hi) {
+			return fail(models.CodeOutOfRange, fmt.Sprintf("Rating must be between %d and %d", lo, hi), params("min", lo, "max", hi))
+		}
+	}
+	return nil
+}
+
+func checkText(field models.Field, s string) *models.FieldError {
+	n := utf8.RuneCountInString(s)
+	if field.MinLength != nil && n < *field.MinLength {
+		return fail(models.CodeTooShort, fmt.Sprintf("Must be at least %d characters", *field.MinLength), params("min", *field.MinLength))
+	}
+	if field.MaxLength != nil && n > *field.MaxLength {
+		return fail(models.CodeTooLong, fmt.Sprintf("Must be at most %d characters", *field.MaxLength), params("max", *field.MaxLength))
+	}
+	if field.Pattern != "" {
+		re, err := compilePattern(field.Pattern)
+		if err != nil || !re.MatchString(s) {
+			return fail(models.CodePattern, "Doesn't match the expected format", params("pattern", field.Pattern))
+		}
+	}
+	return nil
+}
+
+func checkNumber(field models.Field, val interface{}) *models.FieldError {
+	num, ok := toFloat(val)
+	if !ok || math.IsNaN(num) || math.IsInf(num, 0) {
+		return fail(models.CodeInvalidNumber, "Invalid number format", nil)
+	}
+	if field.IntegerOnly && num != math.Trunc(num) {
+		return fail(models.CodeNotInteger, "Must be a whole number", nil)
+	}
+	if field.MinValue != nil && num < float64(*field.MinValue) {
+		return fail(models.CodeBelowMin, "Value is below minimum", params("min", *field.MinValue))
+	}
+	if field.MaxValue != nil && num > float64(*field.MaxValue) {
+		return fail(models.CodeAboveMax, "Value is above maximum", params("max", *field.MaxValue))
+	}
+	if field.Step != nil && *field.Step > 0 {
+		// Steps count from the minimum, like an HTML number input
+		base := 0.0
+		if field.MinValue != nil {
+			base = float64(*field.MinValue)
+		}
+		q := (num - base) / *field.Step
+		if math.Abs(q-math.Round(q)) > 1e-9 {
+			return fail(models.CodeInvalidStep, fmt.Sprintf("Must be in steps of %g", *field.Step), params("step", *field.Step))
+		}
+	}
+	return nil
+}
+
+// checkSlider checks that a slider answer is in its range, on one of its
+// steps
+func checkSlider(field models.Field, val interface{}) *models.FieldError {
+	lo, hi, step := field.SliderRange()
+	num, ok := toFloat(val)
+	if !ok || math.IsNaN(num) || math.IsInf(num, 0) {
+		return fail(models.CodeInvalidNumber, "Invalid number format", nil)
+	}
+	if num < lo || num > hi {
+		return fail(models.CodeOutOfRange, fmt.Sprintf("Must be between %g and %g", lo, hi), params("min", lo, "max", hi))
+	}
+	q := (num - lo) / step
+	if math.Abs(q-math.Round(q)) > 1e-9 {
+		return fail(models.CodeInvalidStep, fmt.Sprintf("Must be in steps of %g", step), params("step", step))
+	}
+	return nil
+}
+
+// temporalNames name each temporal field type in messages
+var temporalNames = map[models.FieldType]string{
+	models.FieldTypeDate:     "date",
+	models.FieldTypeTime:     "time",
+	models.FieldTypeDateTime: "date and time",
+}
+
+func checkTemporal(field models.Field, s string) *models.FieldError {
+	t, err := field.ParseTemporal(s)
+	if err != nil {
+		return fail(models.CodeInvalidDate, "Invalid "+temporalNames[field.Type], nil)
+	}
+	if field.Earliest != "" {
+		if min, err := field.ParseTemporal(field.Earliest); err == nil && t.Before(min) {
+			return fail(models.CodeTooEarly, "Must be "+field.Earliest+" or later", params("min", field.Earliest))
+		}
+	}
+	if field.Latest != "" {
+		if max, err := field.ParseTemporal(field.Latest); err == nil && t.After(max) {
+			return fail(models.CodeTooLate, "Must be "+field.Latest+" or earlier", params("max", field.Latest))
+		}
+	}
+	return nil
+}
+
+func checkFiles(field models.Field, files []models.FileRef) *models.FieldError {
+	maxFiles, maxSize := FileLimits(field)
+	if len(files) > maxFiles {
+		return fail(models.CodeTooManyFiles, fmt.Sprintf("Upload at most %d files", maxFiles), params("max", maxFiles))
+	}
+	for _, f := range files {
+		if f.Size > maxSize {
+			return fail(models.CodeFileTooLarge, fmt.Sprintf("%s is larger than %d bytes", f.Name, maxSize), params("max", maxSize, "file", f.Name))
+		}
+		if !Accepts(field, f.ContentType) {
+			return fail(models.CodeFileType, fmt.Sprintf("%s isn't an accepted file type", f.Name), params("accept", field.Accept, "file", f.Name))
+		}
+	}
+	return nil
+}
+
+// DefaultMaxFileSize is the largest upload a file field takes unless it
+// sets MaxFileSize
+const DefaultMaxFileSize = 10 << 20
+
+// FileLimits returns how many files a file field takes and the largest
+// size of each, in bytes
+func FileLimits(field models.Field) (maxFiles int, maxSize int64) {
+	maxFiles, maxSize = 1, DefaultMaxFileSize
+	if field.MaxFiles != nil {
+		maxFiles = *field.MaxFiles
+	}
+	if field.MaxFileSize != nil {
+		maxSize = *field.MaxFileSize
+	}
+	return maxFiles, maxSize
+}
+
+// Accepts reports whether a file field takes uploads of contentType. Types
+// in Accept match exactly, or by their major type when written "image/*".
+func Accepts(field models.Field, contentType string) bool {
+	if len(field.Accept) == 0 {
+		return true
+	}
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, a := range field.Accept {
+		a = strings.ToLower(strings.TrimSpace(a))
+		if a == mt || (strings.HasSuffix(a, "/*") && strings.HasPrefix(mt, strings.TrimSuffix(a, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkMatrix checks that a matrix answer picks listed columns in listed
+// rows, one per row unless the field allows several. A required matrix
+// needs every row answered.
+func checkMatrix(field models.Field, val interface{}) *models.FieldError {
+	a, _ := models.NewAnswer(models.FieldTypeMatrix, val)
+	if a.Kind != models.AnswerMatrix {
+		return fail(models.CodeInvalidRow, "Answer each row with one of the columns", nil)
+	}
+	for _, row := range sortedRows(a.Matrix) {
+		if !contains(field.Rows, row) {
+			return fail(models.CodeInvalidRow, "Not one of the rows", params("row", row))
+		}
+		cols := a.Matrix[row]
+		for _, c := range cols {
+			if !contains(field.Columns, c) {
+				return fail(models.CodeInvalidOption, "Not one of the available options", params("row", row, "option", c))
+			}
+		}
+		if len(cols) > 1 && !field.MultiplePerRow {
+			return fail(models.CodeTooMany, fmt.Sprintf("Pick one option for %s", row), params("row", row, "max", 1))
+		}
+	}
+	if field.Required {
+		var missing []string
+		for _, row := range field.Rows {
+			if len(a.Matrix[row]) == 0 {
+				missing = append(missing, row)
+			}
+		}
+		if len(missing) > 0 {
+			return fail(models.CodeRequired, "Answer every row", params("rows", missing))
+		}
+	}
+	return nil
+}
+
+// checkRanking checks that a ranking lists each option at most once and
+// ranks all of them, or as many as MinSelections and MaxSelections allow
+func checkRanking(field models.Field, val interface{}) *models.FieldError {
+	a, _ := models.NewAnswer(models.FieldTypeRanking, val)
+	if a.Kind != models.AnswerChoices {
+		return fail(models.CodeInvalidOption, "Rank the options as a list", nil)
+	}
+	seen := make(map[string]bool, len(a.Choices))
+	for _, p := range a.Choices {
+		if !contains(field.Options, p) {
+			return fail(models.CodeInvalidOption, "Not one of the available options", params("option", p))
+		}
+		if seen[p] {
+			return fail(models.CodeDuplicate, "Each option can only be ranked once", params("option", p))
+		}
+		seen[p] = true
+	}
+	min := len(field.Options)
+	if field.MinSelections != nil {
+		min = *field.MinSelections
+	}
+	if len(a.Choices) < min {
+		if min == len(field.Options) {
+			return fail(models.CodeTooFew, "Rank every option", params("min", min))
+		}
+		return fail(models.CodeTooFew, fmt.Sprintf("Rank at least %d options", min), params("min", min))
+	}
+	if field.MaxSelections != nil && len(a.Choices) > *field.MaxSelections {
+		return fail(models.CodeTooMany, fmt.Sprintf("Rank at most %d options", *field.MaxSelections), params("max", *field.MaxSelections))
+	}
+	return nil
+}
+
+func sortedRows(m map[string][]string) []string {
+	rows := make([]string, 0, len(m))
+	for row := range m {
+		rows = append(rows, row)
+	}
+	sort.Strings(rows)
+	return rows
+}
+
+func checkSelections(field models.Field, picked []string, checkOptions bool) *models.FieldError {
+	if checkOptions && len(field.Options) > 0 {
+		for _, p := range picked {
+			if !contains(field.Options, p) {
+				return fail(models.CodeInvalidOption, "Not one of the available options", params("option", p))
+			}
+		}
+	}
+	if field.MinSelections != nil && len(picked) < *field.MinSelections {
+		return fail(models.CodeTooFew, fmt.Sprintf("Select at least %d options", *field.MinSelections), params("min", *field.MinSelections))
+	}
+	if field.MaxSelections != nil && len(picked) > *field.MaxSelections {
+		return fail(models.CodeTooMany, fmt.Sprintf("Select at most %d options", *field.MaxSelections), params("max", *field.MaxSelections))
+	}
+	return nil
+}
+
+// RatingScale returns the lowest and highest rating a rating field
+// accepts
+func RatingScale(field models.Field) (lo, hi int) {
+	lo, hi = 1, 5
+	if field.Min != nil {
+		lo = *field.Min
+	}
+	if field.Max != nil {
+		hi = *field.Max
+	}
+	return lo, hi
+}
+
+// IsEmail reports whether s is a bare RFC 5322 address, without a display
+// name or angle brackets
+func IsEmail(s string) bool {
+	if len(s) > 254 {
+		return false
+	}
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Name != "" || addr.Address != s {
+		return false
+	}
+	at := strings.LastIndexByte(s, '@')
+	return at > 0 && at <= 64
+}
+
+// IsURL reports whether s is an absolute http or https URL with a host
+func IsURL(s string) bool {
+	if strings.ContainsAny(s, " \t\r\n") {
+		return false
+	}
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Hostname() != ""
+}
+
+// patterns caches compiled field patterns by their source, so a form's
+// patterns are compiled once rather than on every answer
+var patterns sync.Map
+
+// compilePattern anchors pattern so it has to match the whole answer, as
+// the pattern attribute of an HTML input does
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(pattern, re)
+	return re, nil
+}
+
+// selections returns the options picked in a checkbox answer, given as a
+// list or as a comma-joined string split around the field's options
+func selections(field models.Field, v interface{}) []string {
+	if s, ok := v.(string); ok {
+		return field.SplitChoices(s)
+	}
+	a, _ := models.NewAnswer(models.FieldTypeCheckbox, v)
+	return a.Choices
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func fail(code, message string, p map[string]interface{}) *models.FieldError {
+	return &models.FieldError{Code: code, Message: message, Params: p}
+}
+
+// params builds a Params map from key/value pairs
+func params(kv ...interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		out[kv[i].(string)] = kv[i+1]
+	}
+	return out
+}
+
+// isEmpty reports whether v is no answer at all: nil, "" or an empty list
+func isEmpty(v interface{}) bool {
+	_, ok := models.NewAnswer("", v)
+	return !ok
+}
+
+func toString(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case fmt.Stringer:
+		return t.String()
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case float32:
+		return float64(t), true
+	case int:
+		return float64(t), true
+	case int8:
+		return float64(t), true
+	case int16:
+		return float64(t), true
+	case int32:
+		return float64(t), true
+	case int64:
+		return float64(t), true
+	case uint:
+		return float64(t), true
+	case uint8:
+		return float64(t), true
+	case uint16:
+		return float64(t), true
+	case uint32:
+		return float64(t), true
+	case uint64:
+		return float64(t), true
+	case string:
+		f, err := strconv.ParseFloat(t, 64)
+		return f, err == nil
+	default:
+		f, err := strconv.ParseFloat(fmt.Sprint(t), 64)
+		return f, err == nil
+	}
+}
@@ -0,0 +1,267 @@
+package validation
+
+import (
+	"reflect"
+	"testing"
+
+	"custom-form-builder/models"
+)
+
+func intPtr(n int) *int { return &n }
+
+func TestValidateReportsEveryField(t *testing.T) {
+	fields := []models.Field{
+		{ID: "name", Type: models.FieldTypeText, Required: true},
+		{ID: "email", Type: models.FieldTypeEmail},
+		{ID: "age", Type: models.FieldTypeNumber, MinValue: intPtr(18), MaxValue: intPtr(99)},
+		{ID: "pet", Type: models.FieldTypeMultipleChoice, Options: []string{"Cat", "Dog"}},
+		{ID: "ok", Type: models.FieldTypeText},
+	}
+	answers := map[string]interface{}{
+		"email": "not-an-email",
+		"age":   120,
+		"pet":   "Fish",
+		"ok":    "fine",
+	}
+
+	errs := Validate(fields, answers, true)
+	want := []models.FieldError{
+		{Field: "name", Code: models.CodeRequired, Message: "This field is required"},
+		{Field: "email", Code: models.CodeInvalidEmail, Message: "Invalid email format"},
+		{Field: "age", Code: models.CodeAboveMax, Message: "Value is above maximum", Params: map[string]interface{}{"max": 99}},
+		{Field: "pet", Code: models.CodeInvalidOption, Message: "Not one of the available options", Params: map[string]interface{}{"option": "Fish"}},
+	}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("Validate =\n%+v\nwant\n%+v", errs, want)
+	}
+}
+
+func TestValidateCodes(t *testing.T) {
+	tests := []struct {
+		name   string
+		field  models.Field
+		answer interface{}
+		code   string
+		params map[string]interface{}
+	}{
+		{"required empty string", models.Field{Type: models.FieldTypeText, Required: true}, "", models.CodeRequired, nil},
+		{"required empty list", models.Field{Type: models.FieldTypeCheckbox, Required: true}, []interface{}{}, models.CodeRequired, nil},
+		{"optional empty", models.Field{Type: models.FieldTypeEmail}, "", "", nil},
+		{"number as string", models.Field{Type: models.FieldTypeNumber}, "42", "", nil},
+		{"not a number", models.Field{Type: models.FieldTypeNumber}, "lots", models.CodeInvalidNumber, nil},
+		{"below min", models.Field{Type: models.FieldTypeNumber, MinValue: intPtr(5)}, 4, models.CodeBelowMin, map[string]interface{}{"min": 5}},
+		{"unlisted checkbox pick", models.Field{Type: models.FieldTypeCheckbox, Options: []string{"A"}}, []interface{}{"A", "B"}, models.CodeInvalidOption, map[string]interface{}{"option": "B"}},
+		{"comma-joined picks with commas", models.Field{Type: models.FieldTypeCheckbox, Options: []string{"Red, dark", "Blue"}}, "Red, dark, Blue", "", nil},
+		{"unlisted choice allowed with Other", models.Field{Type: models.FieldTypeMultipleChoice, Options: []string{"A"}, AllowOther: true}, "Z", "", nil},
+		{"default rating scale", models.Field{Type: models.FieldTypeRating}, 6, models.CodeOutOfRange, map[string]interface{}{"min": 1, "max": 5}},
+		{"yes/no", models.Field{Type: models.FieldTypeBoolean}, "maybe", models.CodeInvalidBool, nil},
+		{"url", models.Field{Type: models.FieldTypeURL}, "ftp://example.com", models.CodeInvalidURL, nil},
+		{"nps", models.Field{Type: models.FieldTypeNPS}, 7.5, models.CodeOutOfRange, map[string]interface{}{"min": 0, "max": 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.field.ID = "f"
+			errs := Validate([]models.Field{tt.field}, map[string]interface{}{"f": tt.answer}, true)
+			if tt.code == "" {
+				if len(errs) != 0 {
+					t.Fatalf("unexpected errors: %+v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 {
+				t.Fatalf("got %d errors, want 1: %+v", len(errs), errs)
+			}
+			if errs[0].Field != "f" || errs[0].Code != tt.code {
+				t.Errorf("error = %+v, want code %s on f", errs[0], tt.code)
+			}
+			if tt.params != nil && !reflect.DeepEqual(errs[0].Params, tt.params) {
+				t.Errorf("params = %v, want %v", errs[0].Params, tt.params)
+			}
+		})
+	}
+}
+
+func TestValidateStrict(t *testing.T) {
+	tests := []struct {
+		name   string
+		field  models.Field
+		answer interface{}
+		strict bool
+		code   string
+	}{
+		{"strict unlisted choice", models.Field{Type: models.FieldTypeMultipleChoice, Options: []string{"Cat"}}, "Fish", true, models.CodeInvalidOption},
+		{"lenient unlisted choice", models.Field{Type: models.FieldTypeMultipleChoice, Options: []string{"Cat"}}, "Fish", false, ""},
+		{"strict unlisted dropdown", models.Field{Type: models.FieldTypeDropdown, Options: []string{"Cat"}}, "Fish", true, models.CodeInvalidOption},
+		{"lenient unlisted dropdown", models.Field{Type: models.FieldTypeDropdown, Options: []string{"Cat"}}, "Fish", false, ""},
+		{"strict unlisted pick", models.Field{Type: models.FieldTypeCheckbox, Options: []string{"Cat"}}, []interface{}{"Cat", "Fish"}, true, models.CodeInvalidOption},
+		{"lenient unlisted pick", models.Field{Type: models.FieldTypeCheckbox, Options: []string{"Cat"}}, []interface{}{"Cat", "Fish"}, false, ""},
+		{"strict with Other", models.Field{Type: models.FieldTypeDropdown, Options: []string{"Cat"}, AllowOther: true}, "Fish", true, ""},
+		{"strict listed choice", models.Field{Type: models.FieldTypeMultipleChoice, Options: []string{"Cat"}}, "Cat", true, ""},
+		{"lenient still checks required", models.Field{Type: models.FieldTypeDropdown, Options: []string{"Cat"}, Required: true}, "", false, models.CodeRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.field.ID = "f"
+			errs := Validate([]models.Field{tt.field}, map[string]interface{}{"f": tt.answer}, tt.strict)
+			switch {
+			case tt.code == "" && len(errs) != 0:
+				t.Errorf("unexpected errors: %+v", errs)
+			case tt.code != "" && (len(errs) != 1 || errs[0].Code != tt.code):
+				t.Errorf("errors = %+v, want one %s", errs, tt.code)
+			}
+		})
+	}
+}
+
+func TestUnknown(t *testing.T) {
+	fields := []models.Field{{ID: "a"}}
+	errs := Unknown(fields, map[string]interface{}{"a": 1, "z": 1, "b": 1})
+	var got []string
+	for _, e := range errs {
+		if e.Code != models.CodeUnknownField {
+			t.Errorf("code = %s, want %s", e.Code, models.CodeUnknownField)
+		}
+		got = append(got, e.Field)
+	}
+	if want := []string{"b", "z"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unknown fields = %v, want %v", got, want)
+	}
+}
+
+func TestSplitOther(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   models.Field
+		answer  interface{}
+		other   map[string]string
+		remains interface{}
+	}{
+		{"unlisted choice", models.Field{Type: models.FieldTypeMultipleChoice, Options: []string{"Cat"}, AllowOther: true}, "Fish", map[string]string{"f": "Fish"}, nil},
+		{"listed choice", models.Field{Type: models.FieldTypeMultipleChoice, Options: []string{"Cat"}, AllowOther: true}, "Cat", nil, "Cat"},
+		{"unlisted dropdown", models.Field{Type: models.FieldTypeDropdown, Options: []string{"Cat"}, AllowOther: true}, "Fish", map[string]string{"f": "Fish"}, nil},
+		{"unlisted picks", models.Field{Type: models.FieldTypeCheckbox, Options: []string{"Cat", "Dog"}, AllowOther: true}, []interface{}{"Cat", "Fish", "Newt"}, map[string]string{"f": "Fish, Newt"}, []string{"Cat"}},
+		{"only unlisted picks", models.Field{Type: models.FieldTypeCheckbox, Options: []string{"Cat"}, AllowOther: true}, []interface{}{"Fish"}, map[string]string{"f": "Fish"}, []string(nil)},
+		{"listed picks", models.Field{Type: models.FieldTypeCheckbox, Options: []string{"Cat", "Dog"}, AllowOther: true}, []interface{}{"Cat", "Dog"}, nil, []interface{}{"Cat", "Dog"}},
+		{"without Other", models.Field{Type: models.FieldTypeMultipleChoice, Options: []string{"Cat"}}, "Fish", nil, "Fish"},
+		{"empty answer", models.Field{Type: models.FieldTypeDropdown, Options: []string{"Cat"}, AllowOther: true}, "", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.field.ID = "f"
+			answers := map[string]interface{}{"f": tt.answer}
+			other := SplitOther([]models.Field{tt.field}, answers)
+			if !reflect.DeepEqual(other, tt.other) {
+				t.Errorf("other = %#v, want %#v", other, tt.other)
+			}
+			if got := answers["f"]; !reflect.DeepEqual(got, tt.remains) {
+				t.Errorf("answer = %#v, want %#v", got, tt.remains)
+			}
+		})
+	}
+}
+
+func TestValidateTemporal(t *testing.T) {
+	tests := []struct {
+		name   string
+		field  models.Field
+		answer string
+		code   string
+	}{
+		{"date", models.Field{Type: models.FieldTypeDate}, "2026-05-01", ""},
+		{"not a date", models.Field{Type: models.FieldTypeDate}, "May 1st", models.CodeInvalidDate},
+		{"on the earliest date", models.Field{Type: models.FieldTypeDate, Earliest: "2026-05-01"}, "2026-05-01", ""},
+		{"before the earliest date", models.Field{Type: models.FieldTypeDate, Earliest: "2026-05-01"}, "2026-04-30", models.CodeTooEarly},
+		{"after the latest date", models.Field{Type: models.FieldTypeDate, Latest: "2026-05-01"}, "2026-05-02", models.CodeTooLate},
+		{"within time bounds", models.Field{Type: models.FieldTypeTime, Earliest: "09:00", Latest: "17:00"}, "12:30", ""},
+		{"after the latest time", models.Field{Type: models.FieldTypeTime, Latest: "17:00"}, "17:00:01", models.CodeTooLate},
+		{"not a time", models.Field{Type: models.FieldTypeTime}, "noon", models.CodeInvalidDate},
+		{"local datetime before the bound", models.Field{Type: models.FieldTypeDateTime, Timezone: "Europe/Berlin", Earliest: "2026-05-01T10:00"}, "2026-05-01T09:30", models.CodeTooEarly},
+		{"UTC datetime after the local bound", models.Field{Type: models.FieldTypeDateTime, Timezone: "Europe/Berlin", Earliest: "2026-05-01T10:00"}, "2026-05-01T09:30:00Z", ""},
+		{"UTC datetime before the local bound", models.Field{Type: models.FieldTypeDateTime, Timezone: "Europe/Berlin", Earliest: "2026-05-01T10:00"}, "2026-05-01T07:59:00Z", models.CodeTooEarly},
+		{"datetime on the bound", models.Field{Type: models.FieldTypeDateTime, Timezone: "Europe/Berlin", Earliest: "2026-05-01T10:00"}, "2026-05-01T08:00:00Z", ""},
+		{"unreadable bound ignored", models.Field{Type: models.FieldTypeDate, Earliest: "soon"}, "2000-01-01", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.field.ID = "f"
+			errs := Validate([]models.Field{tt.field}, map[string]interface{}{"f": tt.answer}, true)
+			switch {
+			case tt.code == "" && len(errs) != 0:
+				t.Errorf("unexpected errors: %+v", errs)
+			case tt.code != "" && (len(errs) != 1 || errs[0].Code != tt.code):
+				t.Errorf("errors = %+v, want one %s", errs, tt.code)
+			}
+		})
+	}
+}
+
+func TestValidateMatrix(t *testing.T) {
+	field := models.Field{ID: "f", Type: models.FieldTypeMatrix, Rows: []string{"Speed", "Price"}, Columns: []string{"Good", "Bad"}}
+	tests := []struct {
+		name     string
+		multiple bool
+		required bool
+		answer   interface{}
+		code     string
+		params   map[string]interface{}
+	}{
+		{"one column per row", false, true, map[string]interface{}{"Speed": "Good", "Price": "Bad"}, "", nil},
+		{"rows left out", false, false, map[string]interface{}{"Speed": "Good"}, "", nil},
+		{"not an object", false, false, "Good", models.CodeInvalidRow, nil},
+		{"unknown row", false, false, map[string]interface{}{"Looks": "Good"}, models.CodeInvalidRow, map[string]interface{}{"row": "Looks"}},
+		{"unknown column", false, false, map[string]interface{}{"Speed": "Fine"}, models.CodeInvalidOption, map[string]interface{}{"row": "Speed", "option": "Fine"}},
+		{"two columns in a row", false, false, map[string]interface{}{"Speed": []interface{}{"Good", "Bad"}}, models.CodeTooMany, map[string]interface{}{"row": "Speed", "max": 1}},
+		{"two columns allowed", true, false, map[string]interface{}{"Speed": []interface{}{"Good", "Bad"}}, "", nil},
+		{"required rows missing", false, true, map[string]interface{}{"Speed": "Good"}, models.CodeRequired, map[string]interface{}{"rows": []string{"Price"}}},
+		{"required and empty", false, true, map[string]interface{}{}, models.CodeRequired, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := field
+			f.MultiplePerRow, f.Required = tt.multiple, tt.required
+			errs := Validate([]models.Field{f}, map[string]interface{}{"f": tt.answer}, true)
+			switch {
+			case tt.code == "" && len(errs) != 0:
+				t.Fatalf("unexpected errors: %+v", errs)
+			case tt.code != "" && (len(errs) != 1 || errs[0].Code != tt.code):
+				t.Fatalf("errors = %+v, want one %s", errs, tt.code)
+			}
+			if tt.params != nil && !reflect.DeepEqual(errs[0].Params, tt.params) {
+				t.Errorf("params = %v, want %v", errs[0].Params, tt.params)
+			}
+		})
+	}
+}
+
+func TestValidateRanking(t *testing.T) {
+	field := models.Field{ID: "f", Type: models.FieldTypeRanking, Options: []string{"A", "B", "C"}}
+	tests := []struct {
+		name   string
+		min    *int
+		max    *int
+		answer interface{}
+		code   string
+	}{
+		{"every option ranked", nil, nil, []interface{}{"C", "A", "B"}, ""},
+		{"not a list", nil, nil, 3, models.CodeInvalidOption},
+		{"unlisted option", nil, nil, []interface{}{"A", "B", "Z"}, models.CodeInvalidOption},
+		{"option ranked twice", nil, nil, []interface{}{"A", "A", "B"}, models.CodeDuplicate},
+		{"every option required by default", nil, nil, []interface{}{"A", "B"}, models.CodeTooFew},
+		{"partial ranking allowed", intPtr(1), nil, []interface{}{"B"}, ""},
+		{"below minimum", intPtr(2), nil, []interface{}{"B"}, models.CodeTooFew},
+		{"above maximum", intPtr(1), intPtr(2), []interface{}{"A", "B", "C"}, models.CodeTooMany},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := field
+			f.MinSelections, f.MaxSelections = tt.min, tt.max
+			errs := Validate([]models.Field{f}, map[string]interface{}{"f": tt.answer}, true)
+			switch {
+			case tt.code == "" && len(errs) != 0:
+				t.Errorf("unexpected errors: %+v", errs)
+			case tt.code != "" && (len(errs) != 1 || errs[0].Code != tt.code):
+				t.Errorf("errors = %+v, want one %s", errs, tt.code)
+			}
+		})
+	}
+}
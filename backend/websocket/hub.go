@@ -17,8 +17,26 @@ type Client struct {
 	ID   string
 	Hub  *Hub
 	Send chan []byte
+
+	// UserID is the authenticated user behind the connection
+	UserID string
+
+	// forms the client is subscribed to; guarded by Hub.mutex
+	forms map[string]bool
 }
 
+// Access is what a subscriber may be sent about a form
+type Access int
+
+const (
+	// AccessNone may not subscribe
+	AccessNone Access = iota
+	// AccessAnalytics receives aggregated analytics only
+	AccessAnalytics
+	// AccessResponses also receives individual responses
+	AccessResponses
+)
+
 // Hub manages WebSocket connections
 type Hub struct {
 	Clients    map[*Client]bool
@@ -26,15 +44,25 @@ type Hub struct {
 	Register   chan *Client
 	Unregister chan *Client
 	mutex      sync.RWMutex
+
+	// subscribers indexes clients by the form IDs they subscribed to,
+	// with what each may be sent
+	subscribers map[string]map[*Client]Access
+
+	// Authorize, when set, decides what a user subscribing to a form's
+	// updates may be sent, and is asked again on every broadcast; without
+	// it subscribers get what they were given when subscribing
+	Authorize func(userID, formID string) Access
 }
 
 // NewHub creates a new WebSocket hub
 func NewHub() *Hub {
 	return &Hub{
-		Clients:    make(map[*Client]bool),
-		Broadcast:  make(chan Message),
-		Register:   make(chan *Client),
-		Unregister: make(chan *Client),
+		Clients:     make(map[*Client]bool),
+		Broadcast:   make(chan Message),
+		Register:    make(chan *Client),
+		Unregister:  make(chan *Client),
+		subscribers: make(map[string]map[*Client]Access),
 	}
 }
 
@@ -49,13 +77,9 @@ func (h *Hub) Run() {
 			log.Printf("Client %s connected", client.ID)
 
 		case client := <-h.Unregister:
-			h.mutex.Lock()
-			if _, ok := h.Clients[client]; ok {
-				delete(h.Clients, client)
-				close(client.Send)
+			if h.removeClient(client) {
+				log.Printf("Client %s disconnected", client.ID)
 			}
-			h.mutex.Unlock()
-			log.Printf("Client %s disconnected", client.ID)
 
 		case message := <-h.Broadcast:
 			h.mutex.RLock()
@@ -65,36 +89,124 @@ func (h *Hub) Run() {
 			}
 			h.mutex.RUnlock()
 
-			// Serialize message
-			data, err := json.Marshal(message)
-			if err != nil {
-				log.Printf("Error marshaling message: %v", err)
-				continue
-			}
+			h.send(clients, message)
+		}
+	}
+}
+
+// BroadcastToForm sends message to the clients subscribed to formID.
+// Clients that may not see individual responses are sent redacted
+// instead, or nothing when it is nil. Access is checked again with
+// Authorize, so a member whose role changed since subscribing gets what
+// their current role allows; those who lost access are unsubscribed.
+func (h *Hub) BroadcastToForm(formID string, message Message, redacted *Message) {
+	h.mutex.RLock()
+	subs := make(map[*Client]Access, len(h.subscribers[formID]))
+	for client, access := range h.subscribers[formID] {
+		subs[client] = access
+	}
+	h.mutex.RUnlock()
 
-			// Broadcast to all clients
-			for _, client := range clients {
-				select {
-				case client.Send <- data:
-				default:
-					close(client.Send)
-					h.mutex.Lock()
-					delete(h.Clients, client)
-					h.mutex.Unlock()
-				}
+	var full, limited, revoked []*Client
+	checked := make(map[string]Access)
+	for client, access := range subs {
+		if h.Authorize != nil {
+			a, ok := checked[client.UserID]
+			if !ok {
+				a = h.Authorize(client.UserID, formID)
+				checked[client.UserID] = a
 			}
+			access = a
+		}
+		switch {
+		case access >= AccessResponses:
+			full = append(full, client)
+		case access == AccessNone:
+			revoked = append(revoked, client)
+		default:
+			limited = append(limited, client)
+		}
+	}
+	if len(revoked) > 0 {
+		h.mutex.Lock()
+		for _, client := range revoked {
+			h.unsubscribeLocked(client, formID)
+		}
+		h.mutex.Unlock()
+	}
+
+	h.send(full, message)
+	if redacted != nil {
+		h.send(limited, *redacted)
+	}
+}
+
+// Subscribe registers client for updates about formID, with what it may
+// be sent, and returns the form's new subscriber count. It reports false
+// if the client is not (or no longer) connected.
+func (h *Hub) Subscribe(client *Client, formID string, access Access) (int, bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if _, ok := h.Clients[client]; !ok {
+		return len(h.subscribers[formID]), false
+	}
+	if h.subscribers[formID] == nil {
+		h.subscribers[formID] = make(map[*Client]Access)
+	}
+	h.subscribers[formID][client] = access
+	if client.forms == nil {
+		client.forms = make(map[string]bool)
+	}
+	client.forms[formID] = true
+	return len(h.subscribers[formID]), true
+}
+
+// Unsubscribe stops updates about formID for client and returns the
+// form's remaining subscriber count
+func (h *Hub) Unsubscribe(client *Client, formID string) int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.unsubscribeLocked(client, formID)
+	return len(h.subscribers[formID])
+}
+
+func (h *Hub) unsubscribeLocked(client *Client, formID string) {
+	delete(client.forms, formID)
+	if subs, ok := h.subscribers[formID]; ok {
+		delete(subs, client)
+		if len(subs) == 0 {
+			delete(h.subscribers, formID)
 		}
 	}
 }
 
-// BroadcastToForm broadcasts a message to clients watching a specific form
-func (h *Hub) BroadcastToForm(formID string, message Message) {
+// SubscriberCount returns how many clients are subscribed to formID
+func (h *Hub) SubscriberCount(formID string) int {
 	h.mutex.RLock()
-	clients := make([]*Client, 0, len(h.Clients))
-	for client := range h.Clients {
-		clients = append(clients, client)
+	defer h.mutex.RUnlock()
+	return len(h.subscribers[formID])
+}
+
+// SubscriberCounts returns the subscriber count of every form that has at
+// least one subscriber
+func (h *Hub) SubscriberCounts() map[string]int {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	out := make(map[string]int, len(h.subscribers))
+	for formID, subs := range h.subscribers {
+		out[formID] = len(subs)
+	}
+	return out
+}
+
+// send delivers message to clients, dropping any client whose buffer is full
+func (h *Hub) send(clients []*Client, message Message) {
+	if len(clients) == 0 {
+		return
 	}
-	h.mutex.RUnlock()
 
 	// Serialize message
 	data, err := json.Marshal(message)
@@ -103,15 +215,45 @@ func (h *Hub) BroadcastToForm(formID string, message Message) {
 		return
 	}
 
-	// Broadcast to all clients (in a real app, you'd filter by form ID)
+	// Sends are non-blocking; holding the read lock keeps removeClient from
+	// closing a channel mid-send.
+	var slow []*Client
+	h.mutex.RLock()
 	for _, client := range clients {
+		if !h.Clients[client] {
+			continue
+		}
 		select {
 		case client.Send <- data:
 		default:
-			close(client.Send)
-			h.mutex.Lock()
-			delete(h.Clients, client)
-			h.mutex.Unlock()
+			slow = append(slow, client)
 		}
 	}
-} 
\ No newline at end of file
+	h.mutex.RUnlock()
+
+	for _, client := range slow {
+		h.removeClient(client)
+	}
+}
+
+// SendTo delivers message to a single client
+func (h *Hub) SendTo(client *Client, message Message) {
+	h.send([]*Client{client}, message)
+}
+
+// removeClient forgets client and its subscriptions and closes its send
+// channel. It reports whether the client was still registered.
+func (h *Hub) removeClient(client *Client) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if _, ok := h.Clients[client]; !ok {
+		return false
+	}
+	for formID := range client.forms {
+		h.unsubscribeLocked(client, formID)
+	}
+	delete(h.Clients, client)
+	close(client.Send)
+	return true
+}
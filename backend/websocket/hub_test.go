@@ -0,0 +1,162 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// connect registers a client for userID without a connection behind it
+func connect(h *Hub, userID string) *Client {
+	c := &Client{ID: userID, Hub: h, Send: make(chan []byte, 8), UserID: userID}
+	h.mutex.Lock()
+	h.Clients[c] = true
+	h.mutex.Unlock()
+	return c
+}
+
+// received returns the types of the messages waiting for c
+func received(t *testing.T, c *Client) []string {
+	t.Helper()
+	var types []string
+	for {
+		select {
+		case data, ok := <-c.Send:
+			if !ok {
+				return types
+			}
+			var m Message
+			if err := json.Unmarshal(data, &m); err != nil {
+				t.Fatal(err)
+			}
+			types = append(types, m.Type)
+		default:
+			return types
+		}
+	}
+}
+
+func TestBroadcastRechecksAccess(t *testing.T) {
+	h := NewHub()
+	roles := map[string]Access{"reader": AccessResponses, "viewer": AccessAnalytics, "leaver": AccessResponses}
+	clients := map[string]*Client{}
+	for user, access := range roles {
+		clients[user] = connect(h, user)
+		h.Subscribe(clients[user], "form", access)
+	}
+
+	// Roles change after subscribing
+	roles["reader"], roles["viewer"], roles["leaver"] = AccessAnalytics, AccessResponses, AccessNone
+	h.Authorize = func(userID, formID string) Access { return roles[userID] }
+	h.BroadcastToForm("form", Message{Type: "full"}, &Message{Type: "redacted"})
+
+	tests := []struct {
+		user string
+		want string
+	}{
+		{"reader", "redacted"},
+		{"viewer", "full"},
+		{"leaver", ""},
+	}
+	for _, tt := range tests {
+		got := received(t, clients[tt.user])
+		if (tt.want == "" && len(got) != 0) || (tt.want != "" && (len(got) != 1 || got[0] != tt.want)) {
+			t.Errorf("%s received %v, want %q", tt.user, got, tt.want)
+		}
+	}
+	if n := h.SubscriberCount("form"); n != 2 {
+		t.Errorf("SubscriberCount = %d, want 2 after access was lost", n)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	h := NewHub()
+	a, b := connect(h, "a"), connect(h, "b")
+	gone := &Client{ID: "gone", Hub: h, Send: make(chan []byte, 1)}
+
+	tests := []struct {
+		name   string
+		op     func() (int, bool)
+		count  int
+		ok     bool
+		counts map[string]int
+	}{
+		{"first subscriber", func() (int, bool) { return h.Subscribe(a, "f1", AccessResponses) }, 1, true, map[string]int{"f1": 1}},
+		{"second subscriber", func() (int, bool) { return h.Subscribe(b, "f1", AccessAnalytics) }, 2, true, map[string]int{"f1": 2}},
+		{"same client again", func() (int, bool) { return h.Subscribe(a, "f1", AccessResponses) }, 2, true, map[string]int{"f1": 2}},
+		{"another form", func() (int, bool) { return h.Subscribe(a, "f2", AccessResponses) }, 1, true, map[string]int{"f1": 2, "f2": 1}},
+		{"disconnected client", func() (int, bool) { return h.Subscribe(gone, "f3", AccessResponses) }, 0, false, map[string]int{"f1": 2, "f2": 1}},
+		{"unsubscribe", func() (int, bool) { return h.Unsubscribe(b, "f1"), true }, 1, true, map[string]int{"f1": 1, "f2": 1}},
+		{"last unsubscribe", func() (int, bool) { return h.Unsubscribe(a, "f2"), true }, 0, true, map[string]int{"f1": 1}},
+		{"disconnect", func() (int, bool) { return 0, h.removeClient(a) }, 0, true, map[string]int{}},
+		{"disconnect twice", func() (int, bool) { return 0, h.removeClient(a) }, 0, false, map[string]int{}},
+	}
+	for _, tt := range tests {
+		count, ok := tt.op()
+		if count != tt.count || ok != tt.ok {
+			t.Errorf("%s: got %d, %v; want %d, %v", tt.name, count, ok, tt.count, tt.ok)
+		}
+		if got := h.SubscriberCounts(); len(got) != len(tt.counts) || !sameCounts(got, tt.counts) {
+			t.Errorf("%s: counts = %v, want %v", tt.name, got, tt.counts)
+		}
+	}
+}
+
+func sameCounts(a, b map[string]int) bool {
+	for k, v := range b {
+		if a[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBroadcastToForm(t *testing.T) {
+	tests := []struct {
+		name     string
+		access   Access
+		redacted bool
+		want     []string
+	}{
+		{"full access", AccessResponses, true, []string{"full"}},
+		{"analytics only", AccessAnalytics, true, []string{"redacted"}},
+		{"analytics only, nothing redacted", AccessAnalytics, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHub()
+			c := connect(h, "user")
+			h.Subscribe(c, "form", tt.access)
+			other := connect(h, "other")
+			h.Subscribe(other, "another form", AccessResponses)
+
+			var redacted *Message
+			if tt.redacted {
+				redacted = &Message{Type: "redacted"}
+			}
+			h.BroadcastToForm("form", Message{Type: "full"}, redacted)
+			if got := received(t, c); len(got) != len(tt.want) || (len(got) == 1 && got[0] != tt.want[0]) {
+				t.Errorf("received %v, want %v", got, tt.want)
+			}
+			if got := received(t, other); len(got) != 0 {
+				t.Errorf("other form's subscriber received %v", got)
+			}
+		})
+	}
+}
+
+func TestSlowClientDropped(t *testing.T) {
+	h := NewHub()
+	c := &Client{ID: "slow", Hub: h, Send: make(chan []byte), UserID: "slow"}
+	h.mutex.Lock()
+	h.Clients[c] = true
+	h.mutex.Unlock()
+	h.Subscribe(c, "form", AccessResponses)
+
+	h.BroadcastToForm("form", Message{Type: "full"}, nil)
+	if n := h.SubscriberCount("form"); n != 0 {
+		t.Errorf("SubscriberCount = %d, want 0", n)
+	}
+	if _, ok := <-c.Send; ok {
+		t.Error("send channel still open")
+	}
+}
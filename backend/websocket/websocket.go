@@ -9,86 +9,125 @@ import (
 	"github.com/google/uuid"
 )
 
-// HandleWebSocket handles individual WebSocket connections
-func HandleWebSocket(conn *websocket.Conn, hub *Hub) {
+// HandleWebSocket handles individual WebSocket connections for the
+// authenticated user userID
+func HandleWebSocket(conn *websocket.Conn, hub *Hub, userID string) {
 	client := &Client{
-		ID:   uuid.New().String(),
-		Hub:  hub,
-		Send: make(chan []byte, 256),
+		ID:     uuid.New().String(),
+		Hub:    hub,
+		Send:   make(chan []byte, 256),
+		UserID: userID,
 	}
 
 	// Register client
 	hub.Register <- client
 
-	// Start goroutine to read messages from client
+	// Write messages to the client until the hub closes its channel. The
+	// connection is released once this handler returns, so the handler
+	// waits for the writer before exiting.
+	done := make(chan struct{})
 	go func() {
-		defer func() {
-			hub.Unregister <- client
-			conn.Close()
-		}()
-
-		for {
-			_, message, err := conn.ReadMessage()
+		defer close(done)
+		for message := range client.Send {
+			w, err := conn.NextWriter(websocket.TextMessage)
 			if err != nil {
-				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-					log.Printf("WebSocket read error: %v", err)
-				}
-				break
-			}
-
-			// Handle incoming messages
-			var msg Message
-			if err := json.Unmarshal(message, &msg); err != nil {
-				log.Printf("Error unmarshaling message: %v", err)
+				conn.Close()
 				continue
 			}
-
-			// Process message based on type
-			switch msg.Type {
-			case "subscribe_form":
-				// Subscribe to form updates
-				log.Printf("Client %s subscribed to form updates", client.ID)
-			case "ping":
-				// Respond to ping
-				response := Message{
-					Type: "pong",
-					Data: map[string]interface{}{
-						"timestamp": time.Now().Unix(),
-					},
-				}
-				data, _ := json.Marshal(response)
-				client.Send <- data
-			default:
-				log.Printf("Unknown message type: %s", msg.Type)
+			w.Write(message)
+			if err := w.Close(); err != nil {
+				conn.Close()
 			}
 		}
+		conn.WriteMessage(websocket.CloseMessage, []byte{})
 	}()
 
-	// Start goroutine to write messages to client
-	go func() {
-		defer func() {
-			hub.Unregister <- client
-			conn.Close()
-		}()
+	// Read messages from the client until it disconnects
+	defer func() {
+		hub.Unregister <- client
+		<-done
+		conn.Close()
+	}()
 
-		for {
-			select {
-			case message, ok := <-client.Send:
-				if !ok {
-					conn.WriteMessage(websocket.CloseMessage, []byte{})
-					return
-				}
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				log.Printf("WebSocket read error: %v", err)
+			}
+			break
+		}
 
-				w, err := conn.NextWriter(websocket.TextMessage)
-				if err != nil {
-					return
-				}
-				w.Write(message)
+		// Handle incoming messages
+		var msg Message
+		if err := json.Unmarshal(message, &msg); err != nil {
+			log.Printf("Error unmarshaling message: %v", err)
+			continue
+		}
 
-				if err := w.Close(); err != nil {
-					return
-				}
+		// Process message based on type
+		switch msg.Type {
+		case "subscribe_form":
+			// Subscribe to form updates: { "formId": "..." }
+			formID := formIDFromData(msg.Data)
+			if formID == "" {
+				hub.SendTo(client, errorMessage("subscribe_form requires data.formId"))
+				continue
+			}
+			access := AccessResponses
+			if hub.Authorize != nil {
+				access = hub.Authorize(client.UserID, formID)
 			}
+			if access == AccessNone {
+				hub.SendTo(client, errorMessage("not allowed to subscribe to form "+formID))
+				continue
+			}
+			count, ok := hub.Subscribe(client, formID, access)
+			if !ok {
+				hub.SendTo(client, errorMessage("client is not connected"))
+				continue
+			}
+			log.Printf("Client %s subscribed to form %s", client.ID, formID)
+			hub.SendTo(client, Message{
+				Type: "subscribed",
+				Data: map[string]interface{}{"formId": formID, "subscribers": count},
+			})
+		case "unsubscribe_form":
+			formID := formIDFromData(msg.Data)
+			if formID == "" {
+				hub.SendTo(client, errorMessage("unsubscribe_form requires data.formId"))
+				continue
+			}
+			count := hub.Unsubscribe(client, formID)
+			log.Printf("Client %s unsubscribed from form %s", client.ID, formID)
+			hub.SendTo(client, Message{
+				Type: "unsubscribed",
+				Data: map[string]interface{}{"formId": formID, "subscribers": count},
+			})
+		case "ping":
+			// Respond to ping
+			hub.SendTo(client, Message{
+				Type: "pong",
+				Data: map[string]interface{}{
+					"timestamp": time.Now().Unix(),
+				},
+			})
+		default:
+			log.Printf("Unknown message type: %s", msg.Type)
 		}
-	}()
-} 
\ No newline at end of file
+	}
+}
+
+// formIDFromData extracts "formId" from a client message payload
+func formIDFromData(data interface{}) string {
+	m, ok := data.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	formID, _ := m["formId"].(string)
+	return formID
+}
+
+func errorMessage(text string) Message {
+	return Message{Type: "error", Data: map[string]interface{}{"message": text}}
+}
@@ -0,0 +1,177 @@
+package websocket
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	wsclient "github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+)
+
+// serve runs HandleWebSocket for hub on a local port; the user is taken
+// from the "user" query parameter
+func serve(t *testing.T, hub *Hub) string {
+	t.Helper()
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Get("/ws", func(c *fiber.Ctx) error {
+		c.Locals("user", c.Query("user"))
+		return c.Next()
+	}, websocket.New(func(c *websocket.Conn) {
+		HandleWebSocket(c, hub, c.Locals("user").(string))
+	}))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go app.Listener(ln)
+	t.Cleanup(func() { app.Shutdown() })
+	go hub.Run()
+	return "ws://" + ln.Addr().String() + "/ws?user="
+}
+
+func dial(t *testing.T, url, user string) *wsclient.Conn {
+	t.Helper()
+	conn, _, err := wsclient.DefaultDialer.Dial(url+user, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// exchange sends msg, if it has a type, and returns the next message
+func exchange(t *testing.T, conn *wsclient.Conn, msg Message) Message {
+	t.Helper()
+	if msg.Type != "" {
+		if err := conn.WriteJSON(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var got Message
+	if err := conn.ReadJSON(&got); err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func subscribe(formID string) Message {
+	return Message{Type: "subscribe_form", Data: map[string]interface{}{"formId": formID}}
+}
+
+// testHub lets "reader" see responses and "viewer" analytics of form "f1"
+func testHub() *Hub {
+	hub := NewHub()
+	hub.Authorize = func(userID, formID string) Access {
+		switch {
+		case formID != "f1":
+			return AccessNone
+		case userID == "reader":
+			return AccessResponses
+		case userID == "viewer":
+			return AccessAnalytics
+		}
+		return AccessNone
+	}
+	return hub
+}
+
+func TestHandleWebSocketMessages(t *testing.T) {
+	url := serve(t, testHub())
+	reader := dial(t, url, "reader")
+	viewer := dial(t, url, "viewer")
+	stranger := dial(t, url, "stranger")
+
+	tests := []struct {
+		name        string
+		conn        *wsclient.Conn
+		send        Message
+		want        string
+		subscribers float64
+	}{
+		{"ping", reader, Message{Type: "ping"}, "pong", 0},
+		{"subscribe without a form", reader, Message{Type: "subscribe_form", Data: map[string]interface{}{}}, "error", 0},
+		{"stranger subscribes", stranger, subscribe("f1"), "error", 0},
+		{"subscribe to another form", reader, subscribe("f2"), "error", 0},
+		{"reader subscribes", reader, subscribe("f1"), "subscribed", 1},
+		{"viewer subscribes", viewer, subscribe("f1"), "subscribed", 2},
+		{"subscribe again", reader, subscribe("f1"), "subscribed", 2},
+		{"unsubscribe", viewer, Message{Type: "unsubscribe_form", Data: map[string]interface{}{"formId": "f1"}}, "unsubscribed", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := exchange(t, tt.conn, tt.send)
+			if got.Type != tt.want {
+				t.Fatalf("got %+v, want %s", got, tt.want)
+			}
+			if tt.subscribers > 0 {
+				data, _ := got.Data.(map[string]interface{})
+				if data["subscribers"] != tt.subscribers || data["formId"] != "f1" {
+					t.Errorf("ack data = %v, want %v subscribers of f1", data, tt.subscribers)
+				}
+			}
+		})
+	}
+}
+
+func TestBroadcastToFormRedacts(t *testing.T) {
+	hub := testHub()
+	url := serve(t, hub)
+	reader := dial(t, url, "reader")
+	viewer := dial(t, url, "viewer")
+	for _, conn := range []*wsclient.Conn{reader, viewer} {
+		if got := exchange(t, conn, subscribe("f1")); got.Type != "subscribed" {
+			t.Fatalf("subscribe: %+v", got)
+		}
+	}
+
+	redacted := &Message{Type: "analytics_update", Data: "redacted"}
+	tests := []struct {
+		name     string
+		message  Message
+		redacted *Message
+		reader   []string
+		viewer   []string
+	}{
+		{"response", Message{Type: "new_response", Data: "full"}, nil, []string{"full"}, nil},
+		{"analytics", Message{Type: "analytics_update", Data: "full"}, redacted, []string{"full"}, []string{"redacted"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub.BroadcastToForm("f1", tt.message, tt.redacted)
+			hub.BroadcastToForm("f2", Message{Type: "other_form", Data: "other"}, &Message{Type: "other_form", Data: "other"})
+			if got := readUntilPong(t, reader); !equal(got, tt.reader) {
+				t.Errorf("reader received %v, want %v", got, tt.reader)
+			}
+			if got := readUntilPong(t, viewer); !equal(got, tt.viewer) {
+				t.Errorf("viewer received %v, want %v", got, tt.viewer)
+			}
+		})
+	}
+}
+
+// readUntilPong pings conn and returns the data of the messages that came
+// before the pong
+func readUntilPong(t *testing.T, conn *wsclient.Conn) []string {
+	t.Helper()
+	var out []string
+	for got := exchange(t, conn, Message{Type: "ping"}); got.Type != "pong"; got = exchange(t, conn, Message{}) {
+		s, _ := got.Data.(string)
+		out = append(out, s)
+	}
+	return out
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -3,6 +3,7 @@
 import { useState, useEffect } from 'react'
 import { useRouter } from 'next/navigation'
 import FormBuilder from '../../components/FormBuilder'
+import { authHeaders } from '../../lib/auth'
 import { Form } from '../../types/form'
 
 export default function BuilderPage() {
@@ -24,7 +25,7 @@ export default function BuilderPage() {
 
   const loadForm = async (formId: string) => {
     try {
-      const response = await fetch(`/api/forms/${formId}`)
+      const response = await fetch(`/api/forms/${formId}`, { headers: authHeaders() })
       if (response.ok) {
         const formData = await response.json()
         setForm(formData)
@@ -60,7 +60,8 @@ export default function FormResponsePage() {
     form.fields.forEach(field => {
       if (field.required) {
         const value = responses[field.id]
-        if (!value || (Array.isArray(value) && value.length === 0) || value === '') {
+        // 0 is an answer (an NPS score of 0)
+        if (value === undefined || value === null || value === '' || (Array.isArray(value) && value.length === 0)) {
           newErrors[field.id] = 'This field is required'
         }
       }
@@ -79,17 +80,31 @@ export default function FormResponsePage() {
     
     setSubmitting(true)
     
+    // Uploads go as multipart: the other answers in a "responses" JSON
+    // part and the files in parts named by field ID
+    const fileFields = form.fields.filter(field => field.type === 'file')
+    let request: RequestInit = {
+      method: 'POST',
+      headers: {
+        'Content-Type': 'application/json',
+      },
+      body: JSON.stringify({ responses }),
+    }
+    if (fileFields.length > 0) {
+      const body = new FormData()
+      const answers = { ...responses }
+      for (const field of fileFields) {
+        for (const file of (answers[field.id] as File[] | undefined) ?? []) {
+          body.append(field.id, file)
+        }
+        delete answers[field.id]
+      }
+      body.append('responses', JSON.stringify(answers))
+      request = { method: 'POST', body }
+    }
+
     try {
-      const response = await fetch('/api/responses', {
-        method: 'POST',
-        headers: {
-          'Content-Type': 'application/json',
-        },
-        body: JSON.stringify({
-          formId: form.id,
-          responses,
-        }),
-      })
+      const response = await fetch(`/api/forms/shareable/${shareableLink}/responses`, request)
       
       if (response.ok) {
         alert('Thank you for your response!')
@@ -97,7 +112,14 @@ export default function FormResponsePage() {
         setResponses({})
       } else {
         const errorData = await response.json()
-        alert(`Error: ${errorData.error}`)
+        if (errorData.errors) {
+          // Every invalid answer is listed; show each under its field
+          const next: Record<string, string> = {}
+          for (const e of errorData.errors) next[e.field] = e.message
+          setErrors(next)
+        } else {
+          alert(`Error: ${errorData.error}`)
+        }
       }
     } catch (error) {
       console.error('Error submitting response:', error)
@@ -202,6 +224,195 @@ export default function FormResponsePage() {
           </div>
         )
 
+      case 'phone':
+      case 'url':
+        return (
+          <input
+            type={field.type === 'phone' ? 'tel' : 'url'}
+            value={value || ''}
+            onChange={(e) => updateResponse(field.id, e.target.value)}
+            placeholder={field.placeholder || (field.type === 'phone' ? '+1 555 123 4567' : 'https://')}
+            className={`input-field ${error ? 'border-red-500' : ''}`}
+          />
+        )
+
+      case 'dropdown':
+        return (
+          <select
+            value={value || ''}
+            onChange={(e) => updateResponse(field.id, e.target.value)}
+            className={`input-field ${error ? 'border-red-500' : ''}`}
+          >
+            <option value="">{field.placeholder || 'Select an option'}</option>
+            {field.options?.map((option, index) => (
+              <option key={index} value={option}>{option}</option>
+            ))}
+          </select>
+        )
+
+      case 'boolean':
+        return (
+          <div className="flex space-x-4">
+            {[true, false].map((answer) => (
+              <label key={String(answer)} className="flex items-center space-x-2">
+                <input
+                  type="radio"
+                  name={`field-${field.id}`}
+                  checked={value === answer}
+                  onChange={() => updateResponse(field.id, answer)}
+                  className="text-primary-600 focus:ring-primary-500"
+                />
+                <span className="text-gray-700">{answer ? 'Yes' : 'No'}</span>
+              </label>
+            ))}
+          </div>
+        )
+
+      case 'slider': {
+        const lo = field.minValue ?? 0
+        const hi = field.maxValue ?? 100
+        return (
+          <div className="flex items-center space-x-3">
+            <span className="text-xs text-gray-500">{lo}</span>
+            <input
+              type="range"
+              min={lo}
+              max={hi}
+              step={field.step ?? 1}
+              value={value ?? lo}
+              onChange={(e) => updateResponse(field.id, parseFloat(e.target.value))}
+              className="flex-1"
+            />
+            <span className="text-xs text-gray-500">{hi}</span>
+            <span className="w-12 text-right text-sm text-gray-700">{value ?? '–'}</span>
+          </div>
+        )
+      }
+
+      case 'date':
+      case 'time':
+      case 'datetime':
+        return (
+          <input
+            type={field.type === 'datetime' ? 'datetime-local' : field.type}
+            value={value || ''}
+            onChange={(e) => updateResponse(field.id, e.target.value)}
+            min={field.earliest}
+            max={field.latest}
+            className={`input-field ${error ? 'border-red-500' : ''}`}
+          />
+        )
+
+      case 'nps':
+        return (
+          <div>
+            <div className="flex items-center space-x-1">
+              {Array.from({ length: 11 }, (_, score) => (
+                <button
+                  key={score}
+                  type="button"
+                  onClick={() => updateResponse(field.id, score)}
+                  className={`w-9 h-9 border rounded text-sm transition-colors ${
+                    value === score ? 'bg-primary-600 text-white' : 'text-gray-700 hover:bg-gray-100'
+                  }`}
+                >
+                  {score}
+                </button>
+              ))}
+            </div>
+            <div className="flex justify-between text-xs text-gray-500 mt-1">
+              <span>Not at all likely</span>
+              <span>Extremely likely</span>
+            </div>
+          </div>
+        )
+
+      case 'ranking': {
+        // Clicking options ranks them in turn; clicking a ranked one unranks it
+        const ranked: string[] = value || []
+        const toggle = (option: string) =>
+          updateResponse(
+            field.id,
+            ranked.includes(option) ? ranked.filter(o => o !== option) : [...ranked, option]
+          )
+        return (
+          <div className="space-y-2">
+            <p className="text-xs text-gray-500">Click the options in order of preference</p>
+            {field.options?.map((option, index) => {
+              const rank = ranked.indexOf(option)
+              return (
+                <button
+                  key={index}
+                  type="button"
+                  onClick={() => toggle(option)}
+                  className={`w-full flex items-center space-x-3 p-2 border rounded text-left transition-colors ${
+                    rank >= 0 ? 'border-primary-600 bg-primary-50' : 'hover:bg-gray-50'
+                  }`}
+                >
+                  <span className="w-6 h-6 flex items-center justify-center rounded-full border text-xs text-gray-700">
+                    {rank >= 0 ? rank + 1 : ''}
+                  </span>
+                  <span className="text-gray-700">{option}</span>
+                </button>
+              )
+            })}
+          </div>
+        )
+      }
+
+      case 'matrix': {
+        // Each row maps to the columns picked in it
+        const rows: Record<string, string[]> = value || {}
+        const pick = (row: string, col: string, checked: boolean) => {
+          const current = rows[row] ?? []
+          const next = field.multiplePerRow
+            ? checked ? [...current, col] : current.filter(c => c !== col)
+            : [col]
+          updateResponse(field.id, { ...rows, [row]: next })
+        }
+        return (
+          <table className="w-full text-sm">
+            <thead>
+              <tr>
+                <th />
+                {field.columns?.map((col, j) => (
+                  <th key={j} className="px-2 py-1 font-normal text-gray-600">{col}</th>
+                ))}
+              </tr>
+            </thead>
+            <tbody>
+              {field.rows?.map((row, i) => (
+                <tr key={i}>
+                  <td className="py-1 text-gray-700">{row}</td>
+                  {field.columns?.map((col, j) => (
+                    <td key={j} className="px-2 py-1 text-center">
+                      <input
+                        type={field.multiplePerRow ? 'checkbox' : 'radio'}
+                        name={`field-${field.id}-${i}`}
+                        checked={(rows[row] ?? []).includes(col)}
+                        onChange={(e) => pick(row, col, e.target.checked)}
+                        className="text-primary-600 focus:ring-primary-500"
+                      />
+                    </td>
+                  ))}
+                </tr>
+              ))}
+            </tbody>
+          </table>
+        )
+      }
+
+      case 'file':
+        return (
+          <input
+            type="file"
+            multiple={(field.maxFiles ?? 1) > 1}
+            accept={field.accept?.join(',')}
+            onChange={(e) => updateResponse(field.id, Array.from(e.target.files ?? []))}
+            className={`input-field ${error ? 'border-red-500' : ''}`}
+          />
+        )
+
       case 'rating':
         return (
           <div className="flex items-center space-x-1">
@@ -3,6 +3,7 @@
 import FormBuilder from '@/components/FormBuilder';
 import { notFound, useParams } from 'next/navigation';
 import { useEffect, useState } from 'react';
+import { authHeaders } from '@/lib/auth';
 
 // ✅ use the shared app types (do NOT re-declare)
 import type { Field, FieldType, Form as FormType } from '@/types/form';
@@ -18,7 +19,7 @@ export default function EditFormPage() {
       try {
         setLoading(true);
         setError(null);
-        const res = await fetch(`/api/forms/${formId}`);
+        const res = await fetch(`/api/forms/${formId}`, { headers: authHeaders() });
         if (res.status === 404) {
           notFound();
           return;
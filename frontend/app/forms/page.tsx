@@ -1,11 +1,28 @@
 "use client";
 import Link from "next/link";
 import { useEffect, useState } from "react";
+import { authHeaders } from "../../lib/auth";
 
-type Form = { id: string; title: string; description?: string };
+type Form = {
+  id: string;
+  title: string;
+  description?: string;
+  status?: string;
+  opensAt?: string;
+  closesAt?: string;
+  maxResponses?: number;
+  closedMessage?: string;
+  shareableLink?: string;
+  slug?: string;
+  linkOnly?: boolean;
+  lenient?: boolean;
+};
+type Membership = { workspace: { id: string; name: string }; role: string };
 
 export default function FormsIndex() {
   const [forms, setForms] = useState<Form[]>([]);
+  const [workspaces, setWorkspaces] = useState<Membership[]>([]);
+  const [workspaceId, setWorkspaceId] = useState("");
   const [loading, setLoading] = useState(true);
   const [err, setErr] = useState<string | null>(null);
 
@@ -13,8 +30,8 @@ export default function FormsIndex() {
     setLoading(true);
     setErr(null);
     try {
-      const token = typeof window !== "undefined" ? localStorage.getItem("token") : null;
-      const res = await fetch("/api/forms", { headers: token ? { Authorization: `Bearer ${token}` } : {} });
+      const url = workspaceId ? `/api/forms?workspaceId=${workspaceId}` : "/api/forms";
+      const res = await fetch(url, { headers: authHeaders() });
       if (res.status === 401) { setErr("You must log in at /login."); setForms([]); return; }
       if (!res.ok) throw new Error(`Failed to load forms (${res.status})`);
       setForms(await res.json());
@@ -22,13 +39,91 @@ export default function FormsIndex() {
     finally { setLoading(false); }
   }
 
-  useEffect(() => { load(); }, []);
+  // The status endpoint replaces the whole schedule, so send it back as is
+  async function setStatus(f: Form, status: string) {
+    const { opensAt, closesAt, maxResponses, closedMessage } = f;
+    const res = await fetch(`/api/forms/${f.id}/status`, {
+      method: "PUT",
+      headers: { "Content-Type": "application/json", ...authHeaders() },
+      body: JSON.stringify({ status, opensAt, closesAt, maxResponses, closedMessage }),
+    });
+    if (!res.ok) { setErr(`Failed to update status (${res.status})`); return; }
+    load();
+  }
+
+  // Public link: the custom slug if set, else the shareable link
+  function copyLink(f: Form) {
+    const key = f.slug || f.shareableLink;
+    if (!key) { alert("This form's link is revoked. Create a new link first."); return; }
+    navigator.clipboard.writeText(`${window.location.origin}/form/${key}`);
+    alert("Link copied to clipboard!");
+  }
+
+  async function changeLink(f: Form, method: "POST" | "DELETE") {
+    const res = await fetch(`/api/forms/${f.id}/link`, { method, headers: authHeaders() });
+    if (!res.ok) { setErr(`Failed to update link (${res.status})`); return; }
+    load();
+  }
+
+  async function toggleLenient(f: Form) {
+    const res = await fetch(`/api/forms/${f.id}/strict-mode`, {
+      method: "PUT",
+      headers: { "Content-Type": "application/json", ...authHeaders() },
+      body: JSON.stringify({ lenient: !f.lenient }),
+    });
+    if (!res.ok) { setErr(`Failed to update strict mode (${res.status})`); return; }
+    load();
+  }
+
+  async function toggleLinkOnly(f: Form) {
+    const res = await fetch(`/api/forms/${f.id}/access`, {
+      method: "PUT",
+      headers: { "Content-Type": "application/json", ...authHeaders() },
+      body: JSON.stringify({ linkOnly: !f.linkOnly }),
+    });
+    if (!res.ok) { setErr(`Failed to update access (${res.status})`); return; }
+    load();
+  }
+
+  async function editSlug(f: Form) {
+    const slug = prompt("Custom link (leave empty to remove)", f.slug ?? "");
+    if (slug === null) return;
+    const res = await fetch(`/api/forms/${f.id}/slug`, {
+      method: "PUT",
+      headers: { "Content-Type": "application/json", ...authHeaders() },
+      body: JSON.stringify({ slug }),
+    });
+    if (!res.ok) {
+      const body = await res.json().catch(() => ({}));
+      alert(body.error || `Failed to set slug (${res.status})`);
+      return;
+    }
+    load();
+  }
+
+  useEffect(() => {
+    fetch("/api/workspaces", { headers: authHeaders() })
+      .then(r => (r.ok ? r.json() : []))
+      .then(setWorkspaces)
+      .catch(() => setWorkspaces([]));
+  }, []);
+
+  // eslint-disable-next-line react-hooks/exhaustive-deps
+  useEffect(() => { load(); }, [workspaceId]);
 
   return (
     <main className="max-w-4xl mx-auto p-6 space-y-4">
       <div className="flex items-center justify-between">
         <h1 className="text-2xl font-semibold">Your Forms</h1>
         <div className="flex gap-2">
+          {workspaces.length > 0 && (
+            <select value={workspaceId} onChange={e => setWorkspaceId(e.target.value)} className="px-3 py-2 rounded border">
+              <option value="">Personal</option>
+              {workspaces.map(m => (
+                <option key={m.workspace.id} value={m.workspace.id}>{m.workspace.name} ({m.role})</option>
+              ))}
+            </select>
+          )}
           <Link href="/forms/new" className="px-3 py-2 rounded bg-black text-white">+ Create Form</Link>
           <button onClick={load} className="px-3 py-2 rounded border">Refresh</button>
         </div>
@@ -44,8 +139,31 @@ export default function FormsIndex() {
             <div>
               <div className="font-medium">{f.title}</div>
               {f.description && <div className="text-sm text-gray-600">{f.description}</div>}
+              <div className="text-xs text-gray-500">
+                {f.slug ? `/form/${f.slug}` : f.shareableLink ? `/form/${f.shareableLink}` : "Link revoked"}
+              </div>
             </div>
             <div className="flex gap-2">
+              <select value={f.status ?? "published"} onChange={e => setStatus(f, e.target.value)} className="px-2 py-1 rounded border">
+                <option value="draft">Draft</option>
+                <option value="published">Published</option>
+                <option value="closed">Closed</option>
+                <option value="archived">Archived</option>
+              </select>
+              <button onClick={() => copyLink(f)} className="px-3 py-1 border rounded">Copy link</button>
+              <button onClick={() => editSlug(f)} className="px-3 py-1 border rounded">Slug</button>
+              <button onClick={() => changeLink(f, "POST")} className="px-3 py-1 border rounded">New link</button>
+              {f.shareableLink && (
+                <button onClick={() => changeLink(f, "DELETE")} className="px-3 py-1 border rounded">Revoke</button>
+              )}
+              <label className="px-2 py-1 flex items-center gap-1 text-sm" title="Respondents can only use the link or slug">
+                <input type="checkbox" checked={Boolean(f.linkOnly)} onChange={() => toggleLinkOnly(f)} />
+                Link only
+              </label>
+              <label className="px-2 py-1 flex items-center gap-1 text-sm" title="Reject answers to unknown fields and unlisted options">
+                <input type="checkbox" checked={!f.lenient} onChange={() => toggleLenient(f)} />
+                Strict
+              </label>
               {/* Option B routing */}
               <Link className="px-3 py-1 border rounded" href={`/analytics/${f.id}`}>Analytics</Link>
               <Link className="px-3 py-1 border rounded" href={`/forms/${f.id}/edit`}>Edit</Link>
@@ -0,0 +1,55 @@
+"use client";
+import { useRouter } from "next/navigation";
+import { useState } from "react";
+import { setToken } from "../../lib/auth";
+
+export default function LoginPage() {
+  const router = useRouter();
+  const [mode, setMode] = useState<"login" | "register">("login");
+  const [email, setEmail] = useState("");
+  const [password, setPassword] = useState("");
+  const [name, setName] = useState("");
+  const [err, setErr] = useState<string | null>(null);
+  const [busy, setBusy] = useState(false);
+
+  async function submit(e: React.FormEvent) {
+    e.preventDefault();
+    setBusy(true);
+    setErr(null);
+    try {
+      const res = await fetch(`/api/auth/${mode}`, {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify(mode === "register" ? { email, password, name } : { email, password }),
+      });
+      const data = await res.json().catch(() => ({}));
+      if (!res.ok) throw new Error(data.error || `Request failed (${res.status})`);
+      setToken(data.token);
+      router.push("/forms");
+    } catch (e: any) {
+      setErr(e.message || "Something went wrong");
+    } finally {
+      setBusy(false);
+    }
+  }
+
+  return (
+    <main className="max-w-sm mx-auto p-6 space-y-4">
+      <h1 className="text-2xl font-semibold">{mode === "login" ? "Log in" : "Create an account"}</h1>
+      <form onSubmit={submit} className="space-y-3">
+        {mode === "register" && (
+          <input className="w-full border rounded px-3 py-2" placeholder="Name" value={name} onChange={e => setName(e.target.value)} />
+        )}
+        <input className="w-full border rounded px-3 py-2" type="email" placeholder="Email" value={email} onChange={e => setEmail(e.target.value)} required />
+        <input className="w-full border rounded px-3 py-2" type="password" placeholder="Password" value={password} onChange={e => setPassword(e.target.value)} minLength={mode === "register" ? 8 : undefined} required />
+        {err && <p className="text-red-600 text-sm">{err}</p>}
+        <button type="submit" disabled={busy} className="w-full px-3 py-2 rounded bg-black text-white disabled:opacity-50">
+          {busy ? "Please wait…" : mode === "login" ? "Log in" : "Sign up"}
+        </button>
+      </form>
+      <button className="text-sm underline" onClick={() => { setMode(mode === "login" ? "register" : "login"); setErr(null); }}>
+        {mode === "login" ? "No account? Sign up" : "Have an account? Log in"}
+      </button>
+    </main>
+  );
+}
@@ -1,19 +1,114 @@
 'use client';
 
-import { useParams } from 'next/navigation';
-import { useEffect, useState } from 'react';
+import { useParams, useSearchParams } from 'next/navigation';
+import { useEffect, useRef, useState } from 'react';
+import { hiddenFields, VisibilityRule } from '../../../lib/conditions';
 
 // align with your shared types if you have them
 type Field = {
   id: string;
-  type: 'text' | 'textarea' | 'email' | 'number' | 'multiple_choice' | 'checkbox' | 'rating';
+  type: 'text' | 'textarea' | 'email' | 'number' | 'multiple_choice' | 'checkbox' | 'rating' | 'date' | 'time' | 'datetime' | 'file' | 'matrix' | 'nps' | 'ranking' | 'dropdown' | 'boolean' | 'slider' | 'phone' | 'url';
   label: string;
   required?: boolean;
   options?: string[];
+  allowOther?: boolean;
   minValue?: number;
   maxValue?: number;
+  min?: number;
+  max?: number;
+  minLength?: number;
+  maxLength?: number;
+  pattern?: string;
+  integerOnly?: boolean;
+  step?: number;
+  earliest?: string;
+  latest?: string;
+  timezone?: string;
+  maxFiles?: number;
+  maxFileSize?: number;
+  accept?: string[];
+  rows?: string[];
+  columns?: string[];
+  multiplePerRow?: boolean;
+  countryCode?: string;
+  visibility?: VisibilityRule;
+  pageId?: string;
 };
-type Form = { id: string; title: string; description?: string; fields: Field[] };
+type Page = { id: string; title: string; description?: string };
+type Form = { id: string; title: string; description?: string; fields: Field[]; pages?: Page[] };
+// One invalid answer, as listed in a validation_failed response
+type FieldError = { field: string; code: string; message: string; params?: Record<string, unknown> };
+
+// Picking "Other" sends the text typed into the field's `<id>:other` input
+const OTHER = '__other__';
+
+// Checkbox answers are lists of the picked options, ranking answers the
+// ranked options best first, file answers the names of the chosen files and
+// matrix answers the columns picked by row; everything else is text
+type Answer = string | string[] | Record<string, string[]>;
+
+function answerOf(fd: FormData, f: Field): Answer {
+  if (f.type === 'file') return chosenFiles(fd, f).map((file) => file.name);
+  if (f.type === 'matrix') {
+    // Row inputs are named by index since rows may contain any character
+    const rows: Record<string, string[]> = {};
+    (f.rows ?? []).forEach((row, i) => {
+      const picked = fd.getAll(`${f.id}:${i}`).map(String);
+      if (picked.length) rows[row] = picked;
+    });
+    return rows;
+  }
+  if (f.type === 'ranking') {
+    // Each option has a select named by its index holding the rank given
+    return (f.options ?? [])
+      .map((option, i) => ({ option, rank: Number(fd.get(`${f.id}:${i}`) || 0) }))
+      .filter((r) => r.rank > 0)
+      .sort((a, b) => a.rank - b.rank)
+      .map((r) => r.option);
+  }
+  const other = String(fd.get(`${f.id}:other`) ?? '').trim();
+  const picked = fd
+    .getAll(f.id)
+    .map(String)
+    .flatMap((v) => (v === OTHER ? (other ? [other] : []) : [v]));
+  return f.type === 'checkbox' ? picked : picked.join(',');
+}
+
+// An empty file input still submits a nameless file
+function chosenFiles(fd: FormData, f: Field): File[] {
+  return fd.getAll(f.id).filter((v): v is File => v instanceof File && v.name !== '');
+}
+
+// Files can't be kept in drafts or JSON, so file answers are left out
+function withoutFiles(form: Form, answers: Record<string, Answer>): Record<string, Answer> {
+  const files = new Set(form.fields.filter((f) => f.type === 'file').map((f) => f.id));
+  return Object.fromEntries(Object.entries(answers).filter(([id]) => !files.has(id)));
+}
+
+// Forms with file fields are posted as multipart: the answers go in a
+// "responses" JSON part, the files in parts named by field ID
+function submission(form: Form, fd: FormData, body: Record<string, unknown>): RequestInit {
+  const fileFields = form.fields.filter((f) => f.type === 'file');
+  if (fileFields.length === 0) {
+    return { method: 'POST', headers: { 'Content-Type': 'application/json' }, body: JSON.stringify(body) };
+  }
+  const mp = new FormData();
+  for (const [k, v] of Object.entries(body)) {
+    mp.append(k, k === 'responses' ? JSON.stringify(withoutFiles(form, v as Record<string, Answer>)) : String(v));
+  }
+  for (const f of fileFields) {
+    for (const file of chosenFiles(fd, f)) mp.append(f.id, file);
+  }
+  return { method: 'POST', body: mp };
+}
+
+function answerText(a: Answer | undefined): string {
+  if (Array.isArray(a)) return a.join(', ');
+  if (a && typeof a === 'object') {
+    return Object.entries(a).map(([row, cols]) => `${row}: ${cols.join(', ')}`).join('; ');
+  }
+  return a ?? '';
+}
 
 const WORDS_ONLY = /^[A-Za-z\s]+$/;               // letters + spaces
 const EMAIL_TLD   = /^[^\s@]+@[^\s@]+\.[A-Za-z]{2,}$/; // simple TLD check
@@ -22,33 +117,158 @@ export default function ShareFormPage() {
   const { formId } = useParams<{ formId: string }>();
   const [form, setForm] = useState<Form | null>(null);
   const [err, setErr] = useState<string | null>(null);
+  const [closed, setClosed] = useState<{ title: string; message: string } | null>(null);
+  const [answers, setAnswers] = useState<Record<string, Answer>>({});
+  const [page, setPage] = useState(0);
+  const [resumeToken, setResumeToken] = useState<string | null>(null);
+  const [fieldErrors, setFieldErrors] = useState<Record<string, string>>({});
+  const [saved, setSaved] = useState<Record<string, Answer> | null>(null);
+  const formRef = useRef<HTMLFormElement>(null);
+  const saveTimer = useRef<ReturnType<typeof setTimeout>>();
+  const tokenRef = useRef<string | null>(null); // current token for pending saves
+  const searchParams = useSearchParams();
+  const draftKey = `draft:${formId}`;
 
   useEffect(() => {
     (async () => {
       try {
         const r = await fetch(`/api/forms/${formId}`);
-        if (!r.ok) throw new Error(`Failed to load form (${r.status})`);
-        setForm(await r.json());
+        if (!r.ok) {
+          // Unpublished, scheduled or closed forms come back with a code
+          // and the message to show respondents
+          const body = await r.json().catch(() => ({}));
+          if (body.code) { setClosed({ title: body.title ?? '', message: body.error }); return; }
+          throw new Error(`Failed to load form (${r.status})`);
+        }
+        const loaded: Form = await r.json();
+        setForm(loaded);
+
+        // Resume a saved draft from ?resume= or this browser
+        const token = searchParams.get('resume') || localStorage.getItem(draftKey);
+        if (!token) return;
+        const d = await fetch(`/api/drafts/${token}`);
+        if (!d.ok) { localStorage.removeItem(draftKey); return; }
+        const draft = await d.json();
+        const restored: Record<string, Answer> = {};
+        for (const [k, v] of Object.entries(draft.responses ?? {})) {
+          if (Array.isArray(v)) restored[k] = v.map(String);
+          else if (v && typeof v === 'object') restored[k] = v as Record<string, string[]>;
+          else restored[k] = String(v);
+        }
+        setResumeToken(token);
+        tokenRef.current = token;
+        localStorage.setItem(draftKey, token);
+        setAnswers(restored);
+        setSaved(restored);
+        const idx = (loaded.pages ?? []).findIndex((p) => p.id === draft.pageId);
+        if (idx > 0) setPage(idx);
       } catch (e: any) { setErr(e.message); }
     })();
+    // eslint-disable-next-line react-hooks/exhaustive-deps
   }, [formId]);
 
+  // Fill the (uncontrolled) inputs with a resumed draft's answers
+  useEffect(() => {
+    const el = formRef.current;
+    if (!el || !saved) return;
+    for (const [id, value] of Object.entries(saved)) {
+      const matrix = form?.fields.find((f) => f.id === id && f.type === 'matrix');
+      if (matrix) {
+        const rows = value as Record<string, string[]>;
+        (matrix.rows ?? []).forEach((row, i) => {
+          el.querySelectorAll<HTMLInputElement>(`input[name="${id}:${i}"]`).forEach((n) => {
+            n.checked = (rows[row] ?? []).includes(n.value);
+          });
+        });
+        continue;
+      }
+      const ranking = form?.fields.find((f) => f.id === id && f.type === 'ranking');
+      if (ranking && Array.isArray(value)) {
+        (ranking.options ?? []).forEach((option, i) => {
+          const select = el.elements.namedItem(`${id}:${i}`);
+          if (select instanceof HTMLSelectElement) {
+            const rank = value.indexOf(option);
+            select.value = rank < 0 ? '' : String(rank + 1);
+          }
+        });
+        continue;
+      }
+      const item = el.elements.namedItem(id);
+      if (item instanceof RadioNodeList) {
+        const chosen = Array.isArray(value) ? value : String(value).split(',');
+        item.forEach((n) => { (n as HTMLInputElement).checked = chosen.includes((n as HTMLInputElement).value); });
+      } else if (item && 'value' in item) {
+        (item as HTMLInputElement).value = answerText(value);
+        // A slider shows its value beside it
+        const out = (item as HTMLInputElement).parentElement?.querySelector('output');
+        if (out) out.value = answerText(value);
+      }
+    }
+  }, [saved, form]);
+
+  // Save progress as a draft, creating it the first time
+  async function saveDraft(next: Record<string, Answer>, pageIndex: number) {
+    if (!form) return;
+    const kept = withoutFiles(form, next);
+    const pageId = form.pages?.[pageIndex]?.id ?? '';
+    if (tokenRef.current) {
+      await fetch(`/api/drafts/${tokenRef.current}`, {
+        method: 'PATCH',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ responses: kept, pageId }),
+      });
+      return;
+    }
+    const r = await fetch('/api/drafts', {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ formId, responses: kept, pageId }),
+    });
+    if (r.ok) {
+      const { token } = await r.json();
+      setResumeToken(token);
+      tokenRef.current = token;
+      localStorage.setItem(draftKey, token);
+    }
+  }
+
+  // Save a moment after the respondent stops typing
+  function scheduleSave(next: Record<string, Answer>, pageIndex: number) {
+    clearTimeout(saveTimer.current);
+    saveTimer.current = setTimeout(() => saveDraft(next, pageIndex), 1000);
+  }
+
   async function onSubmit(e: React.FormEvent<HTMLFormElement>) {
     e.preventDefault();
     if (!form) return;
 
     const fd = new FormData(e.currentTarget);
+    const hidden = hiddenFields(form.fields, answers);
+    const visible = form.fields.filter((f) => !hidden.has(f.id));
 
     // client-side validation
-    for (const f of form.fields) {
-      const raw = fd.getAll(f.id);
-      const v = raw.length > 1 ? raw.join(',') : (raw[0] ?? '').toString().trim();
+    for (const f of visible) {
+      const v = answerText(answerOf(fd, f)).trim();
 
       if (f.required && !v) {
         alert(`"${f.label}" is required`);
         return;
       }
 
+      if (f.type === 'file') {
+        const files = chosenFiles(fd, f);
+        const maxSize = f.maxFileSize ?? 10 << 20;
+        if (files.length > (f.maxFiles ?? 1)) {
+          alert(`"${f.label}" takes at most ${f.maxFiles ?? 1} file(s)`);
+          return;
+        }
+        const big = files.find((file) => file.size > maxSize);
+        if (big) {
+          alert(`${big.name} is larger than ${Math.round(maxSize / 1024)} KB`);
+          return;
+        }
+      }
+
       if (v) {
         if (f.type === 'textarea' && !WORDS_ONLY.test(v)) {
           alert(`"${f.label}" must contain only letters and spaces`);
@@ -80,30 +300,121 @@ export default function ShareFormPage() {
     }
 
     // Build responses object expected by backend
-    const responses: Record<string, string> = {};
-    for (const f of form.fields) {
-      const raw = fd.getAll(f.id);
-      responses[f.id] = raw.length > 1 ? raw.join(',') : (raw[0] ?? '').toString();
+    const responses: Record<string, Answer> = {};
+    for (const f of visible) {
+      responses[f.id] = answerOf(fd, f);
     }
 
-    const res = await fetch(`/api/responses`, {
-      method: 'POST',
-      headers: { 'Content-Type': 'application/json' },
-      body: JSON.stringify({ formId: form.id, responses }),
-    });
+    clearTimeout(saveTimer.current);
+    const res = resumeToken
+      ? await fetch(`/api/drafts/${resumeToken}/submit`, submission(form, fd, { responses }))
+      : await fetch(`/api/responses`, submission(form, fd, { formId, responses }));
 
     if (!res.ok) {
       const text = await res.text();
-      alert(`Submit failed: ${res.status} ${text}`);
+      let message = `Submit failed: ${res.status} ${text}`;
+      try {
+        const body = JSON.parse(text);
+        if (body.errors) {
+          showErrors(body.errors);
+          return;
+        }
+        if (body.code) message = body.error;
+      } catch { /* not JSON */ }
+      alert(message);
       return;
     }
+    setFieldErrors({});
     alert('Thanks! Your response was recorded.');
     (e.target as HTMLFormElement).reset();
+    setAnswers({});
+    setPage(0);
+    setResumeToken(null);
+    tokenRef.current = null;
+    localStorage.removeItem(draftKey);
+  }
+
+  // Multi-page forms: the server validates the current page before the
+  // respondent moves on. Fields of other pages stay mounted (just hidden)
+  // so the final submit still sees every answer.
+  const pages = form?.pages ?? [];
+  const pageOf = (f: Field) => Math.max(0, pages.findIndex((p) => p.id === f.pageId));
+
+  // Show the server's errors under their fields, on the page of the first one
+  function showErrors(errors: FieldError[]) {
+    setFieldErrors(Object.fromEntries(errors.map((e) => [e.field, e.message])));
+    const first = form?.fields.find((f) => f.id === errors[0]?.field);
+    if (first && pages.length > 1) setPage(pageOf(first));
+  }
+
+  async function nextPage() {
+    if (!form) return;
+    const current = pages[page];
+    const fd = new FormData(formRef.current ?? undefined);
+    // Page progress is counted per draft, so make sure there is one
+    if (!tokenRef.current) {
+      clearTimeout(saveTimer.current);
+      await saveDraft(answers, page);
+    }
+    const res = await fetch(
+      `/api/forms/${formId}/pages/${current.id}/validate`,
+      submission(form, fd, { responses: answers, draftToken: tokenRef.current ?? '' }),
+    );
+    const body = await res.json().catch(() => ({}));
+    if (!res.ok) {
+      if (body.errors) showErrors(body.errors);
+      else alert(body.error || `Validation failed (${res.status})`);
+      return;
+    }
+    setFieldErrors({});
+
+    // Skip pages whose fields are all hidden by rules
+    const hidden = new Set<string>(body.hidden ?? []);
+    let next = page + 1;
+    while (
+      next < pages.length - 1 &&
+      !form.fields.some((f) => pageOf(f) === next && !hidden.has(f.id))
+    ) {
+      next++;
+    }
+    setPage(next);
+    scheduleSave(answers, next);
+  }
+
+  // Track answers so show/hide rules update as the respondent types
+  function onChange(e: React.FormEvent<HTMLFormElement>) {
+    const fd = new FormData(e.currentTarget);
+    const next: Record<string, Answer> = {};
+    for (const f of form?.fields ?? []) {
+      next[f.id] = answerOf(fd, f);
+    }
+    // An edited answer no longer shows its old error
+    const stillWrong = Object.entries(fieldErrors).filter(([id]) => answerText(next[id]) === answerText(answers[id]));
+    if (stillWrong.length !== Object.keys(fieldErrors).length) {
+      setFieldErrors(Object.fromEntries(stillWrong));
+    }
+    setAnswers(next);
+    scheduleSave(next, page);
   }
 
   if (err) return <main className="p-6 text-red-600">{err}</main>;
+  if (closed) {
+    return (
+      <main className="max-w-2xl mx-auto p-6 space-y-2">
+        <h1 className="text-3xl font-semibold">{closed.title}</h1>
+        <p className="text-gray-600">{closed.message}</p>
+      </main>
+    );
+  }
   if (!form) return <main className="p-6">Loading…</main>;
 
+  const hidden = hiddenFields(form.fields, answers);
+  const multiPage = pages.length > 1;
+  const lastPage = !multiPage || page === pages.length - 1;
+  const offPage = (f: Field) => multiPage && pageOf(f) !== page;
+  const errorOf = (f: Field) =>
+    fieldErrors[f.id] && <p className="text-sm text-red-600 mt-1">{fieldErrors[f.id]}</p>;
+
   return (
     <main className="max-w-2xl mx-auto p-6 space-y-6">
       <div>
@@ -111,24 +422,36 @@ export default function ShareFormPage() {
         {form.description && <p className="text-gray-600 mt-2">{form.description}</p>}
       </div>
 
-      <form onSubmit={onSubmit} className="space-y-6">
-        {form.fields.map((f) => {
+      {multiPage && (
+        <div>
+          <p className="text-sm text-gray-500">Page {page + 1} of {pages.length}</p>
+          {pages[page].title && <h2 className="text-xl font-semibold">{pages[page].title}</h2>}
+          {pages[page].description && <p className="text-gray-600 mt-1">{pages[page].description}</p>}
+        </div>
+      )}
+
+      <form ref={formRef} onSubmit={onSubmit} onChange={onChange} className="space-y-6">
+        {form.fields.filter((f) => !hidden.has(f.id)).map((f) => {
           switch (f.type) {
             case 'text':
               return (
-                <div key={f.id}>
+                <div key={f.id} hidden={offPage(f)}>
                   <label className="block text-sm font-medium mb-1">{f.label}</label>
                   <input
                     name={f.id}
                     className="w-full border rounded p-2"
                     required={!!f.required}
+                    minLength={f.minLength}
+                    maxLength={f.maxLength}
+                    pattern={f.pattern}
                   />
+                  {errorOf(f)}
                 </div>
               );
 
             case 'textarea':
               return (
-                <div key={f.id}>
+                <div key={f.id} hidden={offPage(f)}>
                   <label className="block text-sm font-medium mb-1">{f.label}</label>
                   {/* pattern isn’t enforced on textarea, so we validate in JS above */}
                   <textarea
@@ -136,30 +459,36 @@ export default function ShareFormPage() {
                     className="w-full border rounded p-2"
                     rows={4}
                     required={!!f.required}
+                    minLength={f.minLength}
+                    maxLength={f.maxLength}
                     placeholder="Letters and spaces only"
                   />
+                  {errorOf(f)}
                 </div>
               );
 
             case 'email':
               return (
-                <div key={f.id}>
+                <div key={f.id} hidden={offPage(f)}>
                   <label className="block text-sm font-medium mb-1">{f.label}</label>
                   <input
                     type="email"
                     name={f.id}
                     className="w-full border rounded p-2"
                     required={!!f.required}
+                    minLength={f.minLength}
+                    maxLength={f.maxLength}
                     // extra pattern for stricter TLDs
                     pattern="^[^\s@]+@[^\s@]+\.[A-Za-z]{2,}$"
                     placeholder="name@example.com"
                   />
+                  {errorOf(f)}
                 </div>
               );
 
             case 'number':
               return (
-                <div key={f.id}>
+                <div key={f.id} hidden={offPage(f)}>
                   <label className="block text-sm font-medium mb-1">{f.label}</label>
                   <input
                     type="number"
@@ -167,43 +496,247 @@ export default function ShareFormPage() {
                     className="w-full border rounded p-2"
                     required={!!f.required}
                     inputMode="numeric"
-                    step="any"
+                    step={f.step ?? (f.integerOnly ? 1 : 'any')}
                     {...(typeof f.minValue === 'number' ? { min: f.minValue } : {})}
                     {...(typeof f.maxValue === 'number' ? { max: f.maxValue } : {})}
                   />
+                  {errorOf(f)}
+                </div>
+              );
+
+            case 'phone':
+            case 'url':
+              return (
+                <div key={f.id} hidden={offPage(f)}>
+                  <label className="block text-sm font-medium mb-1">{f.label}</label>
+                  <input
+                    type={f.type === 'phone' ? 'tel' : 'url'}
+                    name={f.id}
+                    className="w-full border rounded p-2"
+                    required={!!f.required}
+                    autoComplete={f.type === 'phone' ? 'tel' : 'url'}
+                    placeholder={f.type === 'phone' ? (f.countryCode ? '' : '+1 555 123 4567') : 'https://'}
+                  />
+                  {errorOf(f)}
+                </div>
+              );
+
+            case 'slider': {
+              const lo = f.minValue ?? 0;
+              const hi = f.maxValue ?? 100;
+              return (
+                <div key={f.id} hidden={offPage(f)}>
+                  <label className="block text-sm font-medium mb-1">{f.label}</label>
+                  <div className="flex items-center gap-3">
+                    <span className="text-xs text-gray-500">{lo}</span>
+                    <input
+                      type="range"
+                      name={f.id}
+                      className="flex-1"
+                      min={lo}
+                      max={hi}
+                      step={f.step ?? 1}
+                      defaultValue={lo}
+                      onInput={(e) => {
+                        const out = e.currentTarget.parentElement?.querySelector('output');
+                        if (out) out.value = e.currentTarget.value;
+                      }}
+                    />
+                    <span className="text-xs text-gray-500">{hi}</span>
+                    <output className="w-12 text-right text-sm">{lo}</output>
+                  </div>
+                  {errorOf(f)}
+                </div>
+              );
+            }
+
+            case 'boolean':
+              return (
+                <div key={f.id} hidden={offPage(f)}>
+                  <span className="block text-sm font-medium mb-1">{f.label}</span>
+                  <div className="flex gap-4">
+                    <label className="flex items-center gap-2 text-sm">
+                      <input type="radio" name={f.id} value="true" required={!!f.required} /> Yes
+                    </label>
+                    <label className="flex items-center gap-2 text-sm">
+                      <input type="radio" name={f.id} value="false" /> No
+                    </label>
+                  </div>
+                  {errorOf(f)}
+                </div>
+              );
+
+            case 'dropdown':
+              return (
+                <div key={f.id} hidden={offPage(f)}>
+                  <label className="block text-sm font-medium mb-1">{f.label}</label>
+                  <select name={f.id} className="w-full border rounded p-2" required={!!f.required} defaultValue="">
+                    <option value="">Select…</option>
+                    {(f.options ?? []).map((o, i) => (
+                      <option key={i} value={o}>{o}</option>
+                    ))}
+                    {f.allowOther && <option value={OTHER}>Other…</option>}
+                  </select>
+                  {f.allowOther && (
+                    <input name={`${f.id}:other`} className="w-full border rounded p-2 mt-2" placeholder="Other" />
+                  )}
+                  {errorOf(f)}
+                </div>
+              );
+
+            case 'date':
+            case 'time':
+            case 'datetime':
+              // datetime-local has no offset; the server reads it in the
+              // field's timezone
+              return (
+                <div key={f.id} hidden={offPage(f)}>
+                  <label className="block text-sm font-medium mb-1">
+                    {f.label}
+                    {f.type === 'datetime' && (
+                      <span className="ml-1 text-xs text-gray-500">({f.timezone || 'UTC'})</span>
+                    )}
+                  </label>
+                  <input
+                    type={f.type === 'datetime' ? 'datetime-local' : f.type}
+                    name={f.id}
+                    className="border rounded p-2"
+                    required={!!f.required}
+                    min={f.earliest}
+                    max={f.latest}
+                  />
+                  {errorOf(f)}
+                </div>
+              );
+
+            case 'nps':
+              return (
+                <div key={f.id} hidden={offPage(f)}>
+                  <span className="block text-sm font-medium mb-1">{f.label}</span>
+                  <div className="flex gap-1">
+                    {Array.from({ length: 11 }, (_, score) => (
+                      <label key={score} className="flex flex-col items-center text-sm">
+                        <input type="radio" name={f.id} value={score} required={!!f.required} />
+                        {score}
+                      </label>
+                    ))}
+                  </div>
+                  <div className="flex justify-between text-xs text-gray-500 mt-1">
+                    <span>Not at all likely</span>
+                    <span>Extremely likely</span>
+                  </div>
+                  {errorOf(f)}
+                </div>
+              );
+
+            case 'ranking':
+              return (
+                <div key={f.id} hidden={offPage(f)}>
+                  <span className="block text-sm font-medium mb-1">{f.label}</span>
+                  <div className="space-y-1">
+                    {(f.options ?? []).map((option, i) => (
+                      <label key={i} className="flex items-center gap-2 text-sm">
+                        <select name={`${f.id}:${i}`} className="border rounded p-1" aria-label={`Rank of ${option}`}>
+                          <option value="">–</option>
+                          {(f.options ?? []).map((_, rank) => (
+                            <option key={rank} value={rank + 1}>{rank + 1}</option>
+                          ))}
+                        </select>
+                        {option}
+                      </label>
+                    ))}
+                  </div>
+                  {errorOf(f)}
+                </div>
+              );
+
+            case 'matrix':
+              return (
+                <div key={f.id} hidden={offPage(f)}>
+                  <span className="block text-sm font-medium mb-1">{f.label}</span>
+                  <table className="w-full text-sm">
+                    <thead>
+                      <tr>
+                        <th />
+                        {(f.columns ?? []).map((c, j) => (
+                          <th key={j} className="px-2 py-1 font-normal">{c}</th>
+                        ))}
+                      </tr>
+                    </thead>
+                    <tbody>
+                      {(f.rows ?? []).map((row, i) => (
+                        <tr key={i}>
+                          <td className="py-1">{row}</td>
+                          {(f.columns ?? []).map((c, j) => (
+                            <td key={j} className="px-2 py-1 text-center">
+                              <input
+                                type={f.multiplePerRow ? 'checkbox' : 'radio'}
+                                name={`${f.id}:${i}`}
+                                value={c}
+                                aria-label={`${row}: ${c}`}
+                              />
+                            </td>
+                          ))}
+                        </tr>
+                      ))}
+                    </tbody>
+                  </table>
+                  {errorOf(f)}
+                </div>
+              );
+
+            case 'file':
+              return (
+                <div key={f.id} hidden={offPage(f)}>
+                  <label className="block text-sm font-medium mb-1">{f.label}</label>
+                  <input
+                    type="file"
+                    name={f.id}
+                    className="w-full border rounded p-2"
+                    required={!!f.required}
+                    multiple={(f.maxFiles ?? 1) > 1}
+                    accept={f.accept?.join(',')}
+                  />
+                  {errorOf(f)}
                 </div>
               );
 
             case 'rating':
               return (
-                <div key={f.id}>
+                <div key={f.id} hidden={offPage(f)}>
                   <label className="block text-sm font-medium mb-1">{f.label}</label>
                   <input
                     type="number"
                     name={f.id}
                     className="w-24 border rounded p-2"
                     required={!!f.required}
-                    min={1}
-                    max={5}
+                    min={f.min ?? 1}
+                    max={f.max ?? 5}
                   />
+                  {errorOf(f)}
                 </div>
               );
 
             case 'multiple_choice':
               return (
-                <div key={f.id}>
+                <div key={f.id} hidden={offPage(f)}>
                   <label className="block text-sm font-medium mb-1">{f.label}</label>
                   <select name={f.id} className="w-full border rounded p-2" required={!!f.required}>
                     {(f.options ?? []).map((o, i) => (
                       <option key={i} value={o}>{o}</option>
                     ))}
+                    {f.allowOther && <option value={OTHER}>Other…</option>}
                   </select>
+                  {f.allowOther && (
+                    <input name={`${f.id}:other`} className="w-full border rounded p-2 mt-2" placeholder="Other" />
+                  )}
+                  {errorOf(f)}
                 </div>
               );
 
             case 'checkbox':
               return (
-                <div key={f.id}>
+                <div key={f.id} hidden={offPage(f)}>
                   <span className="block text-sm font-medium mb-1">{f.label}</span>
                   <div className="flex gap-4 flex-wrap">
                     {(f.options ?? []).map((o, i) => (
@@ -211,20 +744,47 @@ export default function ShareFormPage() {
                         <input type="checkbox" name={f.id} value={o} /> {o}
                       </label>
                     ))}
+                    {f.allowOther && (
+                      <label className="flex items-center gap-2 text-sm">
+                        <input type="checkbox" name={f.id} value={OTHER} /> Other:
+                        <input name={`${f.id}:other`} className="border rounded p-1" />
+                      </label>
+                    )}
                   </div>
+                  {errorOf(f)}
                 </div>
               );
 
             default:
               return (
-                <div key={f.id} className="text-gray-500">
+                <div key={f.id} hidden={offPage(f)} className="text-gray-500">
                   Unsupported: {f.type}
                 </div>
               );
           }
         })}
 
-        <button className="px-4 py-2 rounded bg-black text-white">Submit</button>
+        <div className="flex gap-2">
+          {multiPage && page > 0 && (
+            <button type="button" onClick={() => setPage(page - 1)} className="px-4 py-2 rounded border">
+              Back
+            </button>
+          )}
+          {lastPage ? (
+            <button className="px-4 py-2 rounded bg-black text-white">Submit</button>
+          ) : (
+            <button type="button" onClick={nextPage} className="px-4 py-2 rounded bg-black text-white">
+              Next
+            </button>
+          )}
+        </div>
+
+        {resumeToken && (
+          <p className="text-xs text-gray-500">
+            Progress saved. Finish later with{' '}
+            <a className="underline" href={`/share/${formId}?resume=${resumeToken}`}>this link</a>.
+          </p>
+        )}
       </form>
     </main>
   );
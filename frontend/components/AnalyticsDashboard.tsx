@@ -14,22 +14,82 @@ import {
   YAxis,
 } from "recharts";
 import { useWebSocket } from "../hooks/useWebSocket";
+import { authHeaders, withToken } from "../lib/auth";
 
 type FieldStats = {
   fieldId: string;
   fieldLabel: string;
   fieldType: string;
   responseCount: number;
+  skipCount?: number;
+  hiddenCount?: number;
   averageRating?: number;
   ratingDistribution?: Record<string, number>;
   optionCounts?: Record<string, number>;
   textResponses?: string[];
   numberSummary?: { average: number; min: number; max: number };
+  otherCount?: number;
+  otherResponses?: string[];
+  dateSummary?: DateSummary;
+  fileCount?: number;
+  rowCounts?: Record<string, Record<string, number>>;
+  nps?: NPSSummary;
+  ranking?: RankStats[];
+  boolean?: BooleanSummary;
+  histogram?: HistogramBin[];
+};
+
+// Yes/no answers; trueRatio is the share of yes
+type BooleanSummary = { true: number; false: number; trueRatio: number };
+// Slider answers from `from` to `to`, both included
+type HistogramBin = { from: number; to: number; count: number };
+
+// How a ranking field's option was ranked; averageRank counts only the
+// answers that ranked it, Borda gives n-1 points for first place
+type RankStats = {
+  option: string;
+  averageRank?: number;
+  borda: number;
+  firstChoices: number;
+  firstChoiceShare: number;
+};
+
+// NPS answers: promoters 9-10, passives 7-8, detractors 0-6; score from
+// -100 to 100 with its 95% confidence interval
+type NPSPoint = { date: string; score: number; responses: number };
+type NPSSummary = {
+  promoters: number;
+  passives: number;
+  detractors: number;
+  score: number;
+  low: number;
+  high: number;
+  distribution: Record<string, number>;
+  trend?: NPSPoint[];
+};
+
+// Date, time and datetime answers; time fields have no buckets
+type DateSummary = {
+  earliest: string;
+  latest: string;
+  byDay?: Record<string, number>;
+  byWeek?: Record<string, number>;
+  byMonth?: Record<string, number>;
 };
 
 type RatingPoint = { date: string; average: number };
 type MostSkippedItem = { fieldId: string; fieldLabel: string; count: number };
 type TopOption = { option: string; count: number };
+type PageStats = {
+  pageId: string;
+  title: string;
+  reached: number;
+  completed: number;
+  dropOff: number;
+  dropOffRate: number;
+};
+
+type FormRevision = { revision: number; title: string; createdAt: string };
 
 type Analytics = {
   formId: string;
@@ -40,22 +100,425 @@ type Analytics = {
   ratingOverTime?: RatingPoint[];
   mostSkipped?: MostSkippedItem[];
   topOptions?: Record<string, TopOption>;
+  pageDropOff?: PageStats[];
+  completion?: { started: number; completed: number; completionRate: number };
+};
+
+type FieldDelta = {
+  fieldId: string;
+  skipped?: boolean;
+  hidden?: boolean;
+  responseCount: number;
+  optionIncrements?: Record<string, number>;
+  ratingBucket?: string;
+  averageRating?: number;
+  numberSummary?: { average: number; min: number; max: number };
+  textResponse?: string;
+  otherResponse?: string;
+  dateSummary?: DateSummary;
+  fileCount?: number;
+  rowIncrements?: Record<string, Record<string, number>>;
+  nps?: NPSSummary;
+  npsPoint?: NPSPoint;
+  ranking?: RankStats[];
+  boolean?: BooleanSummary;
+  histogram?: HistogramBin[];
+};
+
+type AnalyticsDelta = {
+  formId: string;
+  totalResponses: number;
+  fields: Record<string, FieldDelta>;
+  skipped: string[];
+  hidden?: string[];
+  ratingPoint?: RatingPoint;
 };
 
+// An NPS field's score, its breakdown and its score per day
+function NPSCard({ fs, className }: { fs: FieldStats; className: string }) {
+  const nps = fs.nps;
+  const dist = Object.entries(nps?.distribution || {})
+    .map(([score, count]) => ({ score: Number(score), count }))
+    .sort((a, b) => a.score - b.score);
+  return (
+    <div className={className}>
+      <div className="flex items-center justify-between mb-2">
+        <div>
+          <h3 className="font-semibold">{fs.fieldLabel}</h3>
+          <SkipNote fs={fs} />
+        </div>
+        {nps && (
+          <div className="text-right">
+            <div className="text-2xl font-bold">{nps.score}</div>
+            <div className="text-xs text-gray-500">
+              95% CI {nps.low} to {nps.high}
+            </div>
+          </div>
+        )}
+      </div>
+      {!nps ? (
+        <p className="text-gray-500">No answers yet</p>
+      ) : (
+        <>
+          <p className="text-sm mb-2">
+            Promoters <strong>{nps.promoters}</strong> · Passives <strong>{nps.passives}</strong> ·
+            Detractors <strong>{nps.detractors}</strong>
+          </p>
+          <div className="w-full h-48">
+            <ResponsiveContainer>
+              <BarChart data={dist}>
+                <CartesianGrid strokeDasharray="3 3" />
+                <XAxis dataKey="score" />
+                <YAxis allowDecimals={false} />
+                <Tooltip />
+                <Bar dataKey="count" />
+              </BarChart>
+            </ResponsiveContainer>
+          </div>
+          {(nps.trend?.length || 0) > 1 && (
+            <div className="w-full h-48 mt-4">
+              <ResponsiveContainer>
+                <LineChart data={nps.trend}>
+                  <CartesianGrid strokeDasharray="3 3" />
+                  <XAxis dataKey="date" />
+                  <YAxis domain={[-100, 100]} />
+                  <Tooltip />
+                  <Line type="monotone" dataKey="score" />
+                </LineChart>
+              </ResponsiveContainer>
+            </div>
+          )}
+        </>
+      )}
+    </div>
+  );
+}
+
+// Stacked columns need telling apart, unlike the single-series charts
+const MATRIX_COLORS = ["#ef4444", "#f97316", "#eab308", "#84cc16", "#22c55e", "#06b6d4", "#6366f1"];
+
+// Picks per row of a matrix field, as a stacked bar per row
+function MatrixCard({ fs, className }: { fs: FieldStats; className: string }) {
+  const rows = Object.entries(fs.rowCounts || {});
+  const columns = Array.from(new Set(rows.flatMap(([, counts]) => Object.keys(counts))));
+  const data = rows.map(([row, counts]) => ({ row, ...counts }));
+  return (
+    <div className={className}>
+      <h3 className="font-semibold mb-2">{fs.fieldLabel}</h3>
+      <SkipNote fs={fs} />
+      <div className="w-full h-64">
+        <ResponsiveContainer>
+          <BarChart data={data} layout="vertical">
+            <CartesianGrid strokeDasharray="3 3" />
+            <XAxis type="number" allowDecimals={false} />
+            <YAxis type="category" dataKey="row" width={120} />
+            <Tooltip />
+            <Legend />
+            {columns.map((col, i) => (
+              <Bar key={col} dataKey={col} stackId="row" fill={MATRIX_COLORS[i % MATRIX_COLORS.length]} />
+            ))}
+          </BarChart>
+        </ResponsiveContainer>
+      </div>
+    </div>
+  );
+}
+
+// Yes and no answers of a yes/no field
+function BooleanCard({ fs, className }: { fs: FieldStats; className: string }) {
+  const b = fs.boolean;
+  const data = [
+    { answer: "Yes", count: b?.true || 0 },
+    { answer: "No", count: b?.false || 0 },
+  ];
+  return (
+    <div className={className}>
+      <div className="flex items-center justify-between mb-2">
+        <div>
+          <h3 className="font-semibold">{fs.fieldLabel}</h3>
+          <SkipNote fs={fs} />
+        </div>
+        {b && (
+          <div className="text-sm">
+            Yes: <strong>{Math.round(b.trueRatio * 100)}%</strong>
+          </div>
+        )}
+      </div>
+      {b ? (
+        <div className="w-full h-48">
+          <ResponsiveContainer>
+            <BarChart data={data} layout="vertical">
+              <CartesianGrid strokeDasharray="3 3" />
+              <XAxis type="number" allowDecimals={false} />
+              <YAxis type="category" dataKey="answer" width={40} />
+              <Tooltip />
+              <Bar dataKey="count" />
+            </BarChart>
+          </ResponsiveContainer>
+        </div>
+      ) : (
+        <p className="text-gray-500">No answers yet</p>
+      )}
+    </div>
+  );
+}
+
+// Histogram of a slider's answers over its range
+function SliderCard({ fs, className }: { fs: FieldStats; className: string }) {
+  const data = (fs.histogram || []).map((bin) => ({
+    range: bin.from === bin.to ? `${bin.from}` : `${bin.from}–${bin.to}`,
+    count: bin.count,
+  }));
+  const ns = fs.numberSummary;
+  return (
+    <div className={className}>
+      <div className="flex items-center justify-between mb-2">
+        <div>
+          <h3 className="font-semibold">{fs.fieldLabel}</h3>
+          <SkipNote fs={fs} />
+        </div>
+        {ns && (
+          <div className="text-sm">
+            Avg: <strong>{ns.average.toFixed(2)}</strong> · Min {ns.min} · Max {ns.max}
+          </div>
+        )}
+      </div>
+      <div className="w-full h-64">
+        <ResponsiveContainer>
+          <BarChart data={data}>
+            <CartesianGrid strokeDasharray="3 3" />
+            <XAxis dataKey="range" />
+            <YAxis allowDecimals={false} />
+            <Tooltip />
+            <Bar dataKey="count" />
+          </BarChart>
+        </ResponsiveContainer>
+      </div>
+    </div>
+  );
+}
+
+// Options of a ranking field, highest Borda count first
+function RankingCard({ fs, className }: { fs: FieldStats; className: string }) {
+  const ranking = fs.ranking || [];
+  return (
+    <div className={className}>
+      <h3 className="font-semibold mb-2">{fs.fieldLabel}</h3>
+      <SkipNote fs={fs} />
+      {fs.responseCount === 0 ? (
+        <p className="text-gray-500">No answers yet</p>
+      ) : (
+        <table className="w-full text-sm">
+          <thead>
+            <tr className="text-left text-gray-500">
+              <th className="py-1">Option</th>
+              <th className="py-1">Avg. rank</th>
+              <th className="py-1">Borda</th>
+              <th className="py-1">Ranked first</th>
+            </tr>
+          </thead>
+          <tbody>
+            {ranking.map((r) => (
+              <tr key={r.option} className="border-t">
+                <td className="py-1">{r.option}</td>
+                <td className="py-1">{r.averageRank !== undefined ? r.averageRank.toFixed(2) : "–"}</td>
+                <td className="py-1">{r.borda}</td>
+                <td className="py-1">
+                  {r.firstChoices} ({Math.round(r.firstChoiceShare * 100)}%)
+                </td>
+              </tr>
+            ))}
+          </tbody>
+        </table>
+      )}
+    </div>
+  );
+}
+
+// Answers of a date, time or datetime field, charted per day, week or month
+function DateCard({ fs, className }: { fs: FieldStats; className: string }) {
+  const [by, setBy] = useState<"byDay" | "byWeek" | "byMonth">("byDay");
+  const ds = fs.dateSummary;
+  const data = Object.entries(ds?.[by] || {})
+    .sort(([a], [b]) => a.localeCompare(b))
+    .map(([period, count]) => ({ period, count }));
+  return (
+    <div className={className}>
+      <div className="flex items-center justify-between mb-2">
+        <div>
+          <h3 className="font-semibold">{fs.fieldLabel}</h3>
+          <SkipNote fs={fs} />
+        </div>
+        {fs.fieldType !== "time" && (
+          <select
+            value={by}
+            onChange={(e) => setBy(e.target.value as typeof by)}
+            className="px-2 py-1 border rounded-lg text-sm bg-white dark:bg-gray-800"
+          >
+            <option value="byDay">By day</option>
+            <option value="byWeek">By week</option>
+            <option value="byMonth">By month</option>
+          </select>
+        )}
+      </div>
+      {ds ? (
+        <p className="text-sm mb-2">
+          Earliest: <strong>{ds.earliest}</strong> · Latest: <strong>{ds.latest}</strong>
+        </p>
+      ) : (
+        <p className="text-gray-500">No answers yet</p>
+      )}
+      {data.length > 0 && (
+        <div className="w-full h-64">
+          <ResponsiveContainer>
+            <BarChart data={data}>
+              <CartesianGrid strokeDasharray="3 3" />
+              <XAxis dataKey="period" />
+              <YAxis allowDecimals={false} />
+              <Tooltip />
+              <Bar dataKey="count" />
+            </BarChart>
+          </ResponsiveContainer>
+        </div>
+      )}
+    </div>
+  );
+}
+
+// Skipped answers vs. answers hidden by a conditional rule
+function SkipNote({ fs }: { fs: FieldStats }) {
+  if (!fs.skipCount && !fs.hiddenCount) return null;
+  return (
+    <p className="text-xs text-gray-500 mb-2">
+      Skipped {fs.skipCount || 0} · Hidden by rules {fs.hiddenCount || 0}
+    </p>
+  );
+}
+
+// Merge a server-computed analytics_update into the current analytics
+function applyDelta(prev: Analytics, delta: AnalyticsDelta): Analytics {
+  const fieldAnalytics = { ...prev.fieldAnalytics };
+  for (const fd of Object.values(delta.fields || {})) {
+    const fs = fieldAnalytics[fd.fieldId];
+    if (!fs) continue;
+    if (fd.hidden) {
+      fieldAnalytics[fd.fieldId] = { ...fs, hiddenCount: (fs.hiddenCount || 0) + 1 };
+      continue;
+    }
+    if (fd.skipped) {
+      fieldAnalytics[fd.fieldId] = { ...fs, skipCount: (fs.skipCount || 0) + 1 };
+      continue;
+    }
+    const next: FieldStats = { ...fs, responseCount: fd.responseCount };
+    if (fd.optionIncrements) {
+      next.optionCounts = { ...(fs.optionCounts || {}) };
+      for (const [opt, n] of Object.entries(fd.optionIncrements)) {
+        next.optionCounts[opt] = (next.optionCounts[opt] || 0) + n;
+      }
+    }
+    if (fd.ratingBucket) {
+      next.ratingDistribution = { ...(fs.ratingDistribution || {}) };
+      next.ratingDistribution[fd.ratingBucket] =
+        (next.ratingDistribution[fd.ratingBucket] || 0) + 1;
+    }
+    if (fd.averageRating !== undefined) next.averageRating = fd.averageRating;
+    if (fd.numberSummary) next.numberSummary = fd.numberSummary;
+    if (fd.dateSummary) next.dateSummary = fd.dateSummary;
+    if (fd.fileCount) next.fileCount = fd.fileCount;
+    if (fd.ranking) next.ranking = fd.ranking;
+    if (fd.boolean) next.boolean = fd.boolean;
+    if (fd.histogram) next.histogram = fd.histogram;
+    if (fd.nps) {
+      // The delta leaves out the trend; update the day it changed
+      let trend = fs.nps?.trend || [];
+      if (fd.npsPoint) {
+        const p = fd.npsPoint;
+        trend = [...trend.filter((t) => t.date !== p.date), p].sort((a, b) => a.date.localeCompare(b.date));
+      }
+      next.nps = { ...fd.nps, trend };
+    }
+    if (fd.rowIncrements) {
+      const rows = { ...(fs.rowCounts || {}) };
+      for (const [row, inc] of Object.entries(fd.rowIncrements)) {
+        rows[row] = { ...(rows[row] || {}) };
+        for (const [col, n] of Object.entries(inc)) rows[row][col] = (rows[row][col] || 0) + n;
+      }
+      next.rowCounts = rows;
+    }
+    if (fd.textResponse) {
+      next.textResponses = [...(fs.textResponses || []), fd.textResponse].slice(-20);
+    }
+    if (fd.otherResponse) {
+      next.otherCount = (fs.otherCount || 0) + 1;
+      next.otherResponses = [...(fs.otherResponses || []), fd.otherResponse].slice(-20);
+    }
+    fieldAnalytics[fd.fieldId] = next;
+  }
+
+  // Skipped counts
+  const skipped = new Map((prev.mostSkipped || []).map((s) => [s.fieldId, s]));
+  for (const id of delta.skipped || []) {
+    const cur = skipped.get(id);
+    skipped.set(id, {
+      fieldId: id,
+      fieldLabel: cur?.fieldLabel ?? fieldAnalytics[id]?.fieldLabel ?? id,
+      count: (cur?.count || 0) + 1,
+    });
+  }
+  const mostSkipped = Array.from(skipped.values())
+    .sort((a, b) => b.count - a.count)
+    .slice(0, 3);
+
+  // Top options
+  const topOptions = { ...(prev.topOptions || {}) };
+  for (const fd of Object.values(delta.fields || {})) {
+    const counts = fieldAnalytics[fd.fieldId]?.optionCounts;
+    if (!fd.optionIncrements || !counts) continue;
+    const [option, count] = Object.entries(counts).reduce((best, cur) =>
+      cur[1] > best[1] ? cur : best
+    );
+    topOptions[fd.fieldId] = { option, count };
+  }
+
+  // Rating trend
+  let ratingOverTime = prev.ratingOverTime || [];
+  if (delta.ratingPoint) {
+    const p = delta.ratingPoint;
+    ratingOverTime = [...ratingOverTime.filter((r) => r.date !== p.date), p].sort(
+      (a, b) => a.date.localeCompare(b.date)
+    );
+  }
+
+  return {
+    ...prev,
+    totalResponses: delta.totalResponses,
+    recentResponses: prev.recentResponses + 1,
+    fieldAnalytics,
+    mostSkipped,
+    topOptions,
+    ratingOverTime,
+    lastUpdated: new Date().toISOString(),
+  };
+}
+
 export default function AnalyticsDashboard({ formId }: { formId: string }) {
   const [analytics, setAnalytics] = useState<Analytics | null>(null);
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState<string | null>(null);
   const [timeRange, setTimeRange] = useState("all");
+  // "" is the live view of the current form; otherwise a revision number or "all"
+  const [revision, setRevision] = useState("");
+  const [revisions, setRevisions] = useState<FormRevision[]>([]);
   const intervalRef = useRef<NodeJS.Timeout | null>(null);
 
   // Use env-provided WS URL in prod, else rely on Next rewrites with a relative path
   const wsUrl = process.env.NEXT_PUBLIC_WS_URL || "/ws";
-  const { lastMessage } = useWebSocket(wsUrl);
+  const { lastMessage, connectionStatus, sendMessage } = useWebSocket(withToken(wsUrl));
 
   const loadAnalytics = async () => {
     try {
-      const response = await fetch(`/api/analytics/${formId}`);
+      const query = revision ? `?revision=${revision}` : "";
+      const response = await fetch(`/api/analytics/${formId}${query}`, { headers: authHeaders() });
       if (!response.ok) throw new Error("Failed to load analytics");
       const data = await response.json();
       setAnalytics(data);
@@ -74,8 +537,25 @@ export default function AnalyticsDashboard({ formId }: { formId: string }) {
       if (intervalRef.current) clearInterval(intervalRef.current);
     };
     // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [formId, revision]);
+
+  useEffect(() => {
+    fetch(`/api/forms/${formId}/revisions`, { headers: authHeaders() })
+      .then((r) => (r.ok ? r.json() : []))
+      .then((data: FormRevision[]) => setRevisions(data))
+      .catch(() => setRevisions([]));
   }, [formId]);
 
+  // (Re)subscribe to this form's updates whenever the socket (re)opens
+  useEffect(() => {
+    if (connectionStatus !== "open") return;
+    sendMessage({ type: "subscribe_form", data: { formId } });
+    return () => {
+      sendMessage({ type: "unsubscribe_form", data: { formId } });
+    };
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [connectionStatus, formId]);
+
   useEffect(() => {
     if (!lastMessage) return;
 
@@ -89,14 +569,15 @@ export default function AnalyticsDashboard({ formId }: { formId: string }) {
       }
     }
 
-    if (msg?.type === "new_response") {
-      const data = msg.data;
-      if (data?.formId === formId) {
-        loadAnalytics();
+    if (msg?.type === "analytics_update") {
+      const delta = msg.data as AnalyticsDelta;
+      // Deltas describe the live aggregate; revision views reload instead
+      if (delta?.formId === formId && !revision) {
+        setAnalytics((prev) => (prev ? applyDelta(prev, delta) : prev));
       }
     }
     // eslint-disable-next-line react-hooks/exhaustive-deps
-  }, [lastMessage, formId]);
+  }, [lastMessage, formId, revision]);
 
   if (loading) {
     return (
@@ -116,7 +597,7 @@ export default function AnalyticsDashboard({ formId }: { formId: string }) {
   const fieldList = Object.values(analytics.fieldAnalytics || {});
 
   const getFieldChartData = (fs: FieldStats) => {
-    if (fs.fieldType === "multiple_choice" || fs.fieldType === "checkbox") {
+    if (["multiple_choice", "dropdown", "checkbox"].includes(fs.fieldType)) {
       return Object.entries(fs.optionCounts || {}).map(([option, count]) => ({
         option,
         count,
@@ -148,13 +629,31 @@ export default function AnalyticsDashboard({ formId }: { formId: string }) {
         <div className="flex items-center gap-3">
           <a
             className="px-3 py-2 border rounded-lg hover:bg-gray-50 dark:hover:bg-gray-700"
-            href={`/api/responses/${analytics.formId}/csv`}
+            href={withToken(
+              `/api/responses/${analytics.formId}/csv${revision ? `?revision=${revision}` : ""}`
+            )}
             target="_blank"
             rel="noreferrer"
           >
             ⬇️ Export CSV
           </a>
 
+          {revisions.length > 1 && (
+            <select
+              value={revision}
+              onChange={(e) => setRevision(e.target.value)}
+              className="px-3 py-2 border rounded-lg bg-white dark:bg-gray-800"
+            >
+              <option value="">Current form</option>
+              <option value="all">All revisions</option>
+              {revisions.map((r) => (
+                <option key={r.revision} value={String(r.revision)}>
+                  Revision {r.revision}
+                </option>
+              ))}
+            </select>
+          )}
+
           <select
             value={timeRange}
             onChange={(e) => setTimeRange(e.target.value)}
@@ -169,7 +668,7 @@ export default function AnalyticsDashboard({ formId }: { formId: string }) {
       </div>
 
       {/* KPI cards */}
-      <div className="grid grid-cols-1 sm:grid-cols-3 gap-4">
+      <div className="grid grid-cols-1 sm:grid-cols-4 gap-4">
         <div className={card}>
           <p className="text-sm text-gray-600 dark:text-gray-300">Total Responses</p>
           <p className="text-3xl font-semibold">{analytics.totalResponses}</p>
@@ -178,6 +677,15 @@ export default function AnalyticsDashboard({ formId }: { formId: string }) {
           <p className="text-sm text-gray-600 dark:text-gray-300">Recent (24h)</p>
           <p className="text-3xl font-semibold">{analytics.recentResponses}</p>
         </div>
+        <div className={card}>
+          <p className="text-sm text-gray-600 dark:text-gray-300">Started → Completed</p>
+          <p className="text-3xl font-semibold">
+            {analytics.completion?.started ?? 0} → {analytics.completion?.completed ?? 0}
+          </p>
+          <p className="text-sm text-gray-500">
+            {Math.round((analytics.completion?.completionRate ?? 0) * 100)}% completion
+          </p>
+        </div>
         <div className={card}>
           <p className="text-sm text-gray-600 dark:text-gray-300">Last Updated</p>
           <p className="text-lg">{new Date(analytics.lastUpdated).toLocaleTimeString()}</p>
@@ -203,14 +711,44 @@ export default function AnalyticsDashboard({ formId }: { formId: string }) {
         </div>
       )}
 
+      {/* Drop-off by page (multi-page forms) */}
+      {analytics.pageDropOff && analytics.pageDropOff.length > 0 && (
+        <div className={section}>
+          <h3 className="font-semibold mb-3">Drop-off by Page</h3>
+          <table className="w-full text-sm">
+            <thead>
+              <tr className="text-left text-gray-600 dark:text-gray-300">
+                <th className="py-1">Page</th>
+                <th className="py-1">Reached</th>
+                <th className="py-1">Completed</th>
+                <th className="py-1">Dropped</th>
+              </tr>
+            </thead>
+            <tbody>
+              {analytics.pageDropOff.map((p, i) => (
+                <tr key={p.pageId} className="border-t">
+                  <td className="py-1">{p.title || `Page ${i + 1}`}</td>
+                  <td className="py-1">{p.reached}</td>
+                  <td className="py-1">{p.completed}</td>
+                  <td className="py-1">
+                    {p.dropOff} ({Math.round(p.dropOffRate * 100)}%)
+                  </td>
+                </tr>
+              ))}
+            </tbody>
+          </table>
+        </div>
+      )}
+
       {/* Field breakdowns */}
       <div className="grid grid-cols-1 lg:grid-cols-2 gap-6">
         {fieldList.map((fs) => {
-          if (["multiple_choice", "checkbox"].includes(fs.fieldType)) {
+          if (["multiple_choice", "dropdown", "checkbox"].includes(fs.fieldType)) {
             const data = getFieldChartData(fs);
             return (
               <div key={fs.fieldId} className={section}>
                 <h3 className="font-semibold mb-2">{fs.fieldLabel}</h3>
+                <SkipNote fs={fs} />
                 <div className="w-full h-64">
                   <ResponsiveContainer>
                     <BarChart data={data}>
@@ -223,6 +761,18 @@ export default function AnalyticsDashboard({ formId }: { formId: string }) {
                     </BarChart>
                   </ResponsiveContainer>
                 </div>
+                {(fs.otherCount || 0) > 0 && (
+                  <div className="mt-3">
+                    <p className="text-sm font-medium">Other ({fs.otherCount})</p>
+                    <div className="space-y-1 max-h-32 overflow-auto">
+                      {fs.otherResponses?.slice().reverse().slice(0, 10).map((t, i) => (
+                        <div key={i} className="p-2 border rounded-lg text-sm bg-white dark:bg-gray-800">
+                          {t}
+                        </div>
+                      ))}
+                    </div>
+                  </div>
+                )}
               </div>
             );
           }
@@ -232,7 +782,10 @@ export default function AnalyticsDashboard({ formId }: { formId: string }) {
             return (
               <div key={fs.fieldId} className={section}>
                 <div className="flex items-center justify-between mb-2">
-                  <h3 className="font-semibold">{fs.fieldLabel}</h3>
+                  <div>
+                    <h3 className="font-semibold">{fs.fieldLabel}</h3>
+                    <SkipNote fs={fs} />
+                  </div>
                   <div className="text-sm">
                     Avg: <strong>{fs.averageRating?.toFixed(2)}</strong>
                   </div>
@@ -253,10 +806,46 @@ export default function AnalyticsDashboard({ formId }: { formId: string }) {
             );
           }
 
-          if (["text", "textarea", "email"].includes(fs.fieldType)) {
+          if (["date", "time", "datetime"].includes(fs.fieldType)) {
+            return <DateCard key={fs.fieldId} fs={fs} className={section} />;
+          }
+
+          if (fs.fieldType === "nps") {
+            return <NPSCard key={fs.fieldId} fs={fs} className={section} />;
+          }
+
+          if (fs.fieldType === "boolean") {
+            return <BooleanCard key={fs.fieldId} fs={fs} className={section} />;
+          }
+
+          if (fs.fieldType === "slider") {
+            return <SliderCard key={fs.fieldId} fs={fs} className={section} />;
+          }
+
+          if (fs.fieldType === "ranking") {
+            return <RankingCard key={fs.fieldId} fs={fs} className={section} />;
+          }
+          if (fs.fieldType === "matrix") {
+            return <MatrixCard key={fs.fieldId} fs={fs} className={section} />;
+          }
+
+          if (fs.fieldType === "file") {
+            return (
+              <div key={fs.fieldId} className={section}>
+                <h3 className="font-semibold mb-2">{fs.fieldLabel}</h3>
+                <SkipNote fs={fs} />
+                <p className="text-sm">
+                  <strong>{fs.fileCount || 0}</strong> files from <strong>{fs.responseCount}</strong> responses
+                </p>
+              </div>
+            );
+          }
+
+          if (["text", "textarea", "email", "phone", "url"].includes(fs.fieldType)) {
             return (
               <div key={fs.fieldId} className={section}>
                 <h3 className="font-semibold mb-2">{fs.fieldLabel}</h3>
+                <SkipNote fs={fs} />
                 <div className="space-y-2 max-h-64 overflow-auto">
                   {fs.textResponses?.slice().reverse().slice(0, 10).map((t, i) => (
                     <div key={i} className="p-2 border rounded-lg bg-white dark:bg-gray-800">
@@ -9,6 +9,18 @@ import {
   List, 
   CheckSquare, 
   Star,
+  Calendar,
+  Clock,
+  CalendarClock,
+  Paperclip,
+  Table,
+  Gauge,
+  ListOrdered,
+  ChevronDownSquare,
+  ToggleLeft,
+  SlidersHorizontal,
+  Phone,
+  Link,
   Plus 
 } from 'lucide-react'
 
@@ -59,6 +71,78 @@ const fieldTypes = [
     icon: Star,
     description: '1-5 star rating',
   },
+  {
+    type: 'date',
+    label: 'Date',
+    icon: Calendar,
+    description: 'Calendar date',
+  },
+  {
+    type: 'time',
+    label: 'Time',
+    icon: Clock,
+    description: 'Time of day',
+  },
+  {
+    type: 'datetime',
+    label: 'Date & Time',
+    icon: CalendarClock,
+    description: 'Date and time in a timezone',
+  },
+  {
+    type: 'file',
+    label: 'File Upload',
+    icon: Paperclip,
+    description: 'Upload one or more files',
+  },
+  {
+    type: 'matrix',
+    label: 'Matrix',
+    icon: Table,
+    description: 'Same scale across several rows',
+  },
+  {
+    type: 'nps',
+    label: 'Net Promoter Score',
+    icon: Gauge,
+    description: '0-10 likelihood to recommend',
+  },
+  {
+    type: 'ranking',
+    label: 'Ranking',
+    icon: ListOrdered,
+    description: 'Order options by preference',
+  },
+  {
+    type: 'dropdown',
+    label: 'Dropdown',
+    icon: ChevronDownSquare,
+    description: 'Pick one from a long list',
+  },
+  {
+    type: 'boolean',
+    label: 'Yes / No',
+    icon: ToggleLeft,
+    description: 'A yes or no answer',
+  },
+  {
+    type: 'slider',
+    label: 'Slider',
+    icon: SlidersHorizontal,
+    description: 'Pick a value in a range',
+  },
+  {
+    type: 'phone',
+    label: 'Phone',
+    icon: Phone,
+    description: 'Phone number',
+  },
+  {
+    type: 'url',
+    label: 'Website',
+    icon: Link,
+    description: 'Web address',
+  },
 ]
 
 export default function FieldSidebar({ onAddField }: FieldSidebarProps) {
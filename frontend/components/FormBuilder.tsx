@@ -4,6 +4,7 @@ import { Copy, Eye, GripVertical, Plus, Save, Trash2 } from 'lucide-react';
 import { useRouter } from 'next/navigation';
 import { useCallback, useEffect, useRef, useState } from 'react';
 import { useFormState } from '../hooks/useFormState';
+import { authHeaders } from '../lib/auth';
 import { Field } from '../types/form';
 import FieldSidebar from './FieldSidebar';
 import FormField from './FormField';
@@ -123,7 +124,7 @@ export default function FormBuilder({ initialForm, onSave }: FormBuilderProps) {
 
       const response = await fetch(endpoint, {
         method,
-        headers: { 'Content-Type': 'application/json' },
+        headers: { 'Content-Type': 'application/json', ...authHeaders() },
         body: JSON.stringify({
           title: form.title,
           description: form.description ?? '',
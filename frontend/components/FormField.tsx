@@ -80,7 +80,7 @@ export default function FormField({ field, onUpdate, preview = false }: FormFiel
           </div>
 
           {/* Placeholder */}
-          {['text', 'textarea', 'email', 'number'].includes(field.type) && (
+          {['text', 'textarea', 'email', 'number', 'dropdown', 'phone', 'url'].includes(field.type) && (
             <div>
               <label className="block text-sm font-medium text-gray-700 mb-1">
                 Placeholder Text
@@ -143,8 +143,384 @@ export default function FormField({ field, onUpdate, preview = false }: FormFiel
             </div>
           )}
 
-          {/* Multiple Choice and Checkbox Options */}
-          {(field.type === 'multiple_choice' || field.type === 'checkbox') && (
+          {/* Slider range */}
+          {field.type === 'slider' && (
+            <div className="grid grid-cols-3 gap-4">
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">
+                  Minimum
+                </label>
+                <input
+                  type="number"
+                  value={field.minValue ?? ''}
+                  onChange={(e) => updateField({
+                    minValue: e.target.value ? parseInt(e.target.value) : undefined
+                  })}
+                  className="input-field"
+                  placeholder="0"
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">
+                  Maximum
+                </label>
+                <input
+                  type="number"
+                  value={field.maxValue ?? ''}
+                  onChange={(e) => updateField({
+                    maxValue: e.target.value ? parseInt(e.target.value) : undefined
+                  })}
+                  className="input-field"
+                  placeholder="100"
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">
+                  Step
+                </label>
+                <input
+                  type="number"
+                  min={0}
+                  step="any"
+                  value={field.step ?? ''}
+                  onChange={(e) => updateField({
+                    step: e.target.value ? parseFloat(e.target.value) : undefined
+                  })}
+                  className="input-field"
+                  placeholder="1"
+                />
+              </div>
+            </div>
+          )}
+
+          {/* Phone country code */}
+          {field.type === 'phone' && (
+            <div>
+              <label className="block text-sm font-medium text-gray-700 mb-1">
+                Default Country Code
+              </label>
+              <input
+                type="text"
+                value={field.countryCode ?? ''}
+                onChange={(e) => updateField({ countryCode: e.target.value.trim() || undefined })}
+                className="input-field"
+                placeholder="None, e.g. 44 to accept 020 7946 0958"
+              />
+            </div>
+          )}
+
+          {/* Text length and format */}
+          {['text', 'textarea', 'email', 'url'].includes(field.type) && (
+            <div className="grid grid-cols-3 gap-4">
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">
+                  Min Length
+                </label>
+                <input
+                  type="number"
+                  min={0}
+                  value={field.minLength ?? ''}
+                  onChange={(e) => updateField({
+                    minLength: e.target.value ? parseInt(e.target.value) : undefined
+                  })}
+                  className="input-field"
+                  placeholder="None"
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">
+                  Max Length
+                </label>
+                <input
+                  type="number"
+                  min={0}
+                  value={field.maxLength ?? ''}
+                  onChange={(e) => updateField({
+                    maxLength: e.target.value ? parseInt(e.target.value) : undefined
+                  })}
+                  className="input-field"
+                  placeholder="None"
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">
+                  Pattern
+                </label>
+                <input
+                  type="text"
+                  value={field.pattern || ''}
+                  onChange={(e) => updateField({ pattern: e.target.value || undefined })}
+                  className="input-field font-mono"
+                  placeholder="e.g. [A-Z]{3}-\d+"
+                />
+              </div>
+            </div>
+          )}
+
+          {/* Number format */}
+          {field.type === 'number' && (
+            <div className="grid grid-cols-2 gap-4">
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">
+                  Step
+                </label>
+                <input
+                  type="number"
+                  min={0}
+                  step="any"
+                  value={field.step ?? ''}
+                  onChange={(e) => updateField({
+                    step: e.target.value ? parseFloat(e.target.value) : undefined
+                  })}
+                  className="input-field"
+                  placeholder="Any"
+                />
+              </div>
+              <label className="flex items-center space-x-2 text-sm text-gray-700 mt-6">
+                <input
+                  type="checkbox"
+                  checked={!!field.integerOnly}
+                  onChange={(e) => updateField({ integerOnly: e.target.checked || undefined })}
+                  className="rounded border-gray-300 text-primary-600 focus:ring-primary-500"
+                />
+                <span>Whole numbers only</span>
+              </label>
+            </div>
+          )}
+
+          {/* Date and time bounds */}
+          {['date', 'time', 'datetime'].includes(field.type) && (
+            <div className="grid grid-cols-2 gap-4">
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">
+                  Earliest
+                </label>
+                <input
+                  type={temporalInput(field)}
+                  value={field.earliest ?? ''}
+                  onChange={(e) => updateField({ earliest: e.target.value || undefined })}
+                  className="input-field"
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">
+                  Latest
+                </label>
+                <input
+                  type={temporalInput(field)}
+                  value={field.latest ?? ''}
+                  onChange={(e) => updateField({ latest: e.target.value || undefined })}
+                  className="input-field"
+                />
+              </div>
+              {field.type === 'datetime' && (
+                <div className="col-span-2">
+                  <label className="block text-sm font-medium text-gray-700 mb-1">
+                    Timezone
+                  </label>
+                  <input
+                    type="text"
+                    value={field.timezone ?? ''}
+                    onChange={(e) => updateField({ timezone: e.target.value || undefined })}
+                    className="input-field"
+                    placeholder="UTC, e.g. Europe/Berlin"
+                  />
+                </div>
+              )}
+            </div>
+          )}
+
+          {/* Upload limits */}
+          {field.type === 'file' && (
+            <div className="grid grid-cols-2 gap-4">
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">
+                  Max Files
+                </label>
+                <input
+                  type="number"
+                  min={1}
+                  value={field.maxFiles ?? ''}
+                  onChange={(e) => updateField({
+                    maxFiles: e.target.value ? parseInt(e.target.value) : undefined
+                  })}
+                  className="input-field"
+                  placeholder="1"
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">
+                  Max File Size (MB)
+                </label>
+                <input
+                  type="number"
+                  min={0}
+                  step="any"
+                  value={field.maxFileSize ? field.maxFileSize / MB : ''}
+                  onChange={(e) => updateField({
+                    maxFileSize: e.target.value ? Math.round(parseFloat(e.target.value) * MB) : undefined
+                  })}
+                  className="input-field"
+                  placeholder="10"
+                />
+              </div>
+              <div className="col-span-2">
+                <label className="block text-sm font-medium text-gray-700 mb-1">
+                  Accepted Types
+                </label>
+                <input
+                  type="text"
+                  value={(field.accept ?? []).join(', ')}
+                  onChange={(e) => {
+                    const accept = e.target.value.split(',').map((t) => t.trim()).filter(Boolean)
+                    updateField({ accept: accept.length ? accept : undefined })
+                  }}
+                  className="input-field"
+                  placeholder="Any, e.g. application/pdf, image/*"
+                />
+              </div>
+            </div>
+          )}
+
+          {/* Rating scale */}
+          {field.type === 'rating' && (
+            <div className="grid grid-cols-2 gap-4">
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">
+                  Lowest Rating
+                </label>
+                <input
+                  type="number"
+                  value={field.min ?? ''}
+                  onChange={(e) => updateField({
+                    min: e.target.value ? parseInt(e.target.value) : undefined
+                  })}
+                  className="input-field"
+                  placeholder="1"
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">
+                  Highest Rating
+                </label>
+                <input
+                  type="number"
+                  value={field.max ?? ''}
+                  onChange={(e) => updateField({
+                    max: e.target.value ? parseInt(e.target.value) : undefined
+                  })}
+                  className="input-field"
+                  placeholder="5"
+                />
+              </div>
+            </div>
+          )}
+
+          {/* Checkbox selection limits; for a ranking, how many to rank */}
+          {(field.type === 'checkbox' || field.type === 'ranking') && (
+            <div className="grid grid-cols-2 gap-4">
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">
+                  {field.type === 'ranking' ? 'Rank At Least' : 'Min Selections'}
+                </label>
+                <input
+                  type="number"
+                  min={0}
+                  value={field.minSelections ?? ''}
+                  onChange={(e) => updateField({
+                    minSelections: e.target.value ? parseInt(e.target.value) : undefined
+                  })}
+                  className="input-field"
+                  placeholder={field.type === 'ranking' ? 'All' : 'None'}
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">
+                  {field.type === 'ranking' ? 'Rank At Most' : 'Max Selections'}
+                </label>
+                <input
+                  type="number"
+                  min={0}
+                  value={field.maxSelections ?? ''}
+                  onChange={(e) => updateField({
+                    maxSelections: e.target.value ? parseInt(e.target.value) : undefined
+                  })}
+                  className="input-field"
+                  placeholder="None"
+                />
+              </div>
+            </div>
+          )}
+
+          {/* Matrix rows and columns, one per line */}
+          {field.type === 'matrix' && (
+            <div className="space-y-4">
+              <div className="grid grid-cols-2 gap-4">
+                <div>
+                  <label className="block text-sm font-medium text-gray-700 mb-1">
+                    Rows
+                  </label>
+                  <textarea
+                    value={(field.rows ?? []).join('\n')}
+                    onChange={(e) => updateField({ rows: e.target.value.split('\n') })}
+                    rows={5}
+                    className="input-field"
+                    placeholder="One statement per line"
+                  />
+                </div>
+                <div>
+                  <label className="block text-sm font-medium text-gray-700 mb-1">
+                    Columns
+                  </label>
+                  <textarea
+                    value={(field.columns ?? []).join('\n')}
+                    onChange={(e) => updateField({ columns: e.target.value.split('\n') })}
+                    rows={5}
+                    className="input-field"
+                    placeholder="One choice per line"
+                  />
+                </div>
+              </div>
+              <label className="flex items-center space-x-2 text-sm text-gray-700">
+                <input
+                  type="checkbox"
+                  checked={!!field.multiplePerRow}
+                  onChange={(e) => updateField({ multiplePerRow: e.target.checked || undefined })}
+                  className="rounded border-gray-300 text-primary-600 focus:ring-primary-500"
+                />
+                <span>Allow several choices per row</span>
+              </label>
+            </div>
+          )}
+
+          {/* Dropdown options, one per line since lists can be long */}
+          {field.type === 'dropdown' && (
+            <div>
+              <label className="block text-sm font-medium text-gray-700 mb-1">
+                Options
+              </label>
+              <label className="flex items-center space-x-2 text-sm text-gray-700 mb-2">
+                <input
+                  type="checkbox"
+                  checked={!!field.allowOther}
+                  onChange={(e) => updateField({ allowOther: e.target.checked || undefined })}
+                  className="rounded border-gray-300 text-primary-600 focus:ring-primary-500"
+                />
+                <span>Allow an &quot;Other&quot; answer</span>
+              </label>
+              <textarea
+                value={(field.options ?? []).join('\n')}
+                onChange={(e) => updateField({ options: e.target.value.split('\n') })}
+                rows={8}
+                className="input-field"
+                placeholder="One option per line; paste a whole list"
+              />
+              <p className="text-xs text-gray-500 mt-1">{field.options?.length ?? 0} options</p>
+            </div>
+          )}
+
+          {/* Multiple Choice, Checkbox and Ranking Options */}
+          {(field.type === 'multiple_choice' || field.type === 'checkbox' || field.type === 'ranking') && (
             <div>
               <div className="flex items-center justify-between mb-2">
                 <label className="block text-sm font-medium text-gray-700">
@@ -159,6 +535,18 @@ export default function FormField({ field, onUpdate, preview = false }: FormFiel
                 </button>
               </div>
               
+              {field.type !== 'ranking' && (
+                <label className="flex items-center space-x-2 text-sm text-gray-700 mb-2">
+                  <input
+                    type="checkbox"
+                    checked={!!field.allowOther}
+                    onChange={(e) => updateField({ allowOther: e.target.checked || undefined })}
+                    className="rounded border-gray-300 text-primary-600 focus:ring-primary-500"
+                  />
+                  <span>Allow an &quot;Other&quot; answer</span>
+                </label>
+              )}
+
               <div className="space-y-2">
                 {field.options?.map((option, index) => (
                   <div key={index} className="flex items-center space-x-2">
@@ -251,6 +639,54 @@ function FormFieldPreview({ field }: { field: Field }) {
           />
         )
 
+      case 'phone':
+      case 'url':
+        return (
+          <input
+            type={field.type === 'phone' ? 'tel' : 'url'}
+            value={value}
+            onChange={(e) => setValue(e.target.value)}
+            placeholder={field.placeholder || (field.type === 'phone' ? '+1 555 123 4567' : 'https://')}
+            className="input-field"
+            disabled
+          />
+        )
+
+      case 'dropdown':
+        return (
+          <select className="input-field" disabled>
+            <option>{field.placeholder || 'Select an option'}</option>
+            {field.options?.map((option, index) => (
+              <option key={index}>{option}</option>
+            ))}
+          </select>
+        )
+
+      case 'boolean':
+        return (
+          <div className="flex space-x-4">
+            {['Yes', 'No'].map((label) => (
+              <label key={label} className="flex items-center space-x-2">
+                <input type="radio" name={`preview-${field.id}`} disabled />
+                <span className="text-gray-700">{label}</span>
+              </label>
+            ))}
+          </div>
+        )
+
+      case 'slider': {
+        const [lo, hi, step] = sliderRange(field)
+        return (
+          <div>
+            <input type="range" min={lo} max={hi} step={step} className="w-full" disabled />
+            <div className="flex justify-between text-xs text-gray-500">
+              <span>{lo}</span>
+              <span>{hi}</span>
+            </div>
+          </div>
+        )
+      }
+
       case 'multiple_choice':
         return (
           <div className="space-y-2">
@@ -296,10 +732,89 @@ function FormFieldPreview({ field }: { field: Field }) {
           </div>
         )
 
+      case 'date':
+      case 'time':
+      case 'datetime':
+        return (
+          <input
+            type={temporalInput(field)}
+            value={value}
+            onChange={(e) => setValue(e.target.value)}
+            min={field.earliest}
+            max={field.latest}
+            className="input-field"
+            disabled
+          />
+        )
+
+      case 'ranking':
+        return (
+          <ol className="space-y-1">
+            {field.options?.map((option, index) => (
+              <li key={index} className="flex items-center space-x-2 p-2 border rounded text-gray-700">
+                <span className="w-5 text-right text-gray-400">{index + 1}.</span>
+                <span>{option}</span>
+              </li>
+            ))}
+          </ol>
+        )
+
+      case 'nps':
+        return (
+          <div className="flex items-center space-x-1">
+            {NPS_SCALE.map((score) => (
+              <button
+                key={score}
+                className="w-8 h-8 border rounded text-sm text-gray-600"
+                disabled
+              >
+                {score}
+              </button>
+            ))}
+          </div>
+        )
+
+      case 'matrix':
+        return (
+          <table className="w-full text-sm">
+            <thead>
+              <tr>
+                <th />
+                {field.columns?.map((col, i) => (
+                  <th key={i} className="px-2 py-1 font-normal text-gray-600">{col}</th>
+                ))}
+              </tr>
+            </thead>
+            <tbody>
+              {field.rows?.map((row, i) => (
+                <tr key={i}>
+                  <td className="py-1 text-gray-700">{row}</td>
+                  {field.columns?.map((col, j) => (
+                    <td key={j} className="px-2 py-1 text-center">
+                      <input type={field.multiplePerRow ? 'checkbox' : 'radio'} name={`${field.id}-${i}`} disabled />
+                    </td>
+                  ))}
+                </tr>
+              ))}
+            </tbody>
+          </table>
+        )
+
+      case 'file':
+        return (
+          <input
+            type="file"
+            multiple={(field.maxFiles ?? 1) > 1}
+            accept={field.accept?.join(',')}
+            className="input-field"
+            disabled
+          />
+        )
+
       case 'rating':
         return (
           <div className="flex items-center space-x-1">
-            {[1, 2, 3, 4, 5].map((star) => (
+            {ratingScale(field).map((star) => (
               <button
                 key={star}
                 onClick={() => setValue(star)}
@@ -328,4 +843,25 @@ function FormFieldPreview({ field }: { field: Field }) {
       {renderField()}
     </div>
   )
-} 
\ No newline at end of file
+}
+
+const MB = 1 << 20
+
+const NPS_SCALE = Array.from({ length: 11 }, (_, i) => i)
+
+// A slider's lowest and highest values and its step, 0 to 100 by 1 by default
+function sliderRange(field: Field): [number, number, number] {
+  return [field.minValue ?? 0, field.maxValue ?? 100, field.step ?? 1]
+}
+
+// The HTML input type for a date, time or datetime field
+function temporalInput(field: Field): string {
+  return field.type === 'datetime' ? 'datetime-local' : field.type
+}
+
+// The ratings a rating field offers, lowest first
+function ratingScale(field: Field): number[] {
+  const lo = field.min ?? 1
+  const hi = field.max ?? 5
+  return hi >= lo ? Array.from({ length: hi - lo + 1 }, (_, i) => lo + i) : []
+}
@@ -1,6 +1,9 @@
 import { useCallback, useState } from 'react'
 import { Field, Form } from '../types/form'
 
+// Columns a new matrix field starts with
+const LIKERT = ['Strongly disagree', 'Disagree', 'Neutral', 'Agree', 'Strongly agree']
+
 interface UseFormStateProps {
   initialForm?: {
     id?: string
@@ -31,9 +34,16 @@ export function useFormState({ initialForm }: UseFormStateProps = {}) {
       required: false,
       order: form.fields.length,
       options:
-        fieldType === 'multiple_choice' || fieldType === 'checkbox'
+        fieldType === 'multiple_choice' || fieldType === 'checkbox' || fieldType === 'dropdown'
           ? ['Option 1']
-          : undefined,
+          : fieldType === 'ranking'
+            ? ['Option 1', 'Option 2']
+            : undefined,
+      ...(fieldType === 'matrix' && {
+        rows: ['Statement 1'],
+        columns: LIKERT,
+      }),
+      ...(fieldType === 'slider' && { minValue: 0, maxValue: 100, step: 1 }),
     }
 
     setForm(prev => ({
@@ -0,0 +1,105 @@
+// Client-side mirror of backend/conditions: decides which fields a form's
+// show/hide rules hide for the current answers. The server re-evaluates the
+// rules on submit, so this only drives what the respondent sees.
+
+export type ConditionOperator =
+  | "equals"
+  | "not_equals"
+  | "contains"
+  | "greater_than"
+  | "less_than";
+
+export type Condition = {
+  fieldId: string;
+  operator: ConditionOperator;
+  value: unknown;
+};
+
+export type VisibilityRule = {
+  action: "show" | "hide";
+  logic?: "and" | "or";
+  conditions: Condition[];
+};
+
+type RuleField = { id: string; type: string; visibility?: VisibilityRule };
+type Answers = Record<string, unknown>;
+
+function answerValues(field: RuleField | undefined, v: unknown): string[] {
+  if (Array.isArray(v)) return v.map(String);
+  if (v === undefined || v === null) return [];
+  const s = String(v);
+  if (!s.trim()) return [];
+  if (field?.type !== "checkbox") return [s];
+  return s.split(",").map((p) => p.trim()).filter(Boolean);
+}
+
+function sameValue(a: string, b: string): boolean {
+  if (a === b) return true;
+  const x = Number(a);
+  const y = Number(b);
+  return a.trim() !== "" && b.trim() !== "" && !Number.isNaN(x) && x === y;
+}
+
+function equals(values: string[], want: unknown): boolean {
+  if (want === undefined || want === null) return values.length === 0;
+  const wants = Array.isArray(want) ? want.map(String) : [String(want)];
+  return (
+    values.length === wants.length &&
+    values.every((v) => wants.some((w) => sameValue(v, w)))
+  );
+}
+
+export function hiddenFields(fields: RuleField[], answers: Answers): Set<string> {
+  const byId = new Map(fields.map((f) => [f.id, f]));
+  const visible = new Map<string, boolean>();
+  const seen = new Set<string>();
+
+  const isVisible = (id: string): boolean => {
+    const known = visible.get(id);
+    if (known !== undefined) return known;
+    if (seen.has(id)) return true; // cycle; the server rejects these
+    seen.add(id);
+
+    let result = true;
+    const rule = byId.get(id)?.visibility;
+    if (rule && rule.conditions?.length) {
+      const test = (c: Condition) => check(c);
+      const matched =
+        rule.logic === "or" ? rule.conditions.some(test) : rule.conditions.every(test);
+      result = rule.action === "hide" ? !matched : matched;
+    }
+    visible.set(id, result);
+    return result;
+  };
+
+  const check = (c: Condition): boolean => {
+    const field = byId.get(c.fieldId);
+    const answer = field && isVisible(c.fieldId) ? answers[c.fieldId] : undefined;
+    const values = answerValues(field, answer);
+    switch (c.operator) {
+      case "equals":
+        return equals(values, c.value);
+      case "not_equals":
+        return !equals(values, c.value);
+      case "contains": {
+        if (c.value === undefined || c.value === null || values.length === 0) return false;
+        const want = String(c.value);
+        if (field?.type === "checkbox") return values.includes(want);
+        return values[0].toLowerCase().includes(want.toLowerCase());
+      }
+      case "greater_than":
+      case "less_than": {
+        if (values.length !== 1) return false;
+        const got = Number(values[0]);
+        const want = Number(c.value);
+        if (Number.isNaN(got) || Number.isNaN(want)) return false;
+        return c.operator === "greater_than" ? got > want : got < want;
+      }
+    }
+    return false;
+  };
+
+  const hidden = new Set<string>();
+  for (const f of fields) if (!isVisible(f.id)) hidden.add(f.id);
+  return hidden;
+}
@@ -6,6 +6,18 @@ export type FieldType =
   | 'multiple_choice'
   | 'checkbox'
   | 'rating'
+  | 'date'
+  | 'time'
+  | 'datetime'
+  | 'file'
+  | 'matrix'
+  | 'nps'
+  | 'ranking'
+  | 'dropdown'
+  | 'boolean'
+  | 'slider'
+  | 'phone'
+  | 'url'
 
 export interface Field {
   id: string
@@ -14,25 +26,117 @@ export interface Field {
   required: boolean
   placeholder?: string
   options?: string[]
+  // multiple choice, dropdown and checkbox: accept a free-text "Other" answer
+  allowOther?: boolean
+  // number bounds, or a slider's range (0 to 100 in steps of 1 by default)
   minValue?: number
   maxValue?: number
+  // rating scale, 1 to 5 by default
+  min?: number
+  max?: number
+  minLength?: number
+  maxLength?: number
+  pattern?: string
+  // checkbox picks, or how many options a ranking ranks (all by default)
+  minSelections?: number
+  maxSelections?: number
+  integerOnly?: boolean
+  step?: number
+  // date, time and datetime bounds, written like the answers
+  earliest?: string
+  latest?: string
+  // IANA timezone datetime answers without an offset are read in
+  timezone?: string
+  // file uploads: at most maxFiles files (1 by default) of maxFileSize
+  // bytes each (10 MB by default), limited to the accepted MIME types
+  maxFiles?: number
+  maxFileSize?: number
+  accept?: string[]
+  // matrix: each row is answered with one column, or several when
+  // multiplePerRow is set
+  rows?: string[]
+  columns?: string[]
+  multiplePerRow?: boolean
+  // phone: calling code (e.g. '44') of numbers written without one
+  countryCode?: string
+  // custom messages keyed by rule code, e.g. { too_short: '...' }
+  messages?: Record<string, string>
   order: number
+  visibility?: VisibilityRule
+  pageId?: string
 }
 
+export interface Page {
+  id: string
+  title: string
+  description?: string
+}
+
+export type ConditionOperator =
+  | 'equals'
+  | 'not_equals'
+  | 'contains'
+  | 'greater_than'
+  | 'less_than'
+
+export interface Condition {
+  fieldId: string
+  operator: ConditionOperator
+  value: any
+}
+
+export interface VisibilityRule {
+  action: 'show' | 'hide'
+  logic?: 'and' | 'or'
+  conditions: Condition[]
+}
+
+export type FormStatus = 'draft' | 'published' | 'closed' | 'archived'
+
 export interface Form {
   id?: string
   title: string
   description: string
   fields: Field[]
+  pages?: Page[]
+  revision?: number
+  status?: FormStatus
+  opensAt?: string
+  closesAt?: string
+  maxResponses?: number
+  closedMessage?: string
   shareableLink?: string
+  slug?: string
+  linkOnly?: boolean
+  // strict mode is on unless lenient
+  lenient?: boolean
   createdAt?: string
   updatedAt?: string
 }
 
+// A file uploaded to a file field; download it from
+// /api/responses/:formId/:responseId/files/:id
+export interface FileRef {
+  id: string
+  name: string
+  contentType: string
+  size: number
+}
+
+// A stored answer: text, a number (number, rating and NPS fields), the picked
+// options of a checkbox field, the uploads of a file field or the columns
+// picked per row of a matrix field
+export type AnswerValue = string | number | string[] | FileRef[] | Record<string, string[]>
+
 export interface FormResponse {
   id?: string
   formId: string
-  responses: Record<string, any>
+  responses: Record<string, AnswerValue>
+  hidden?: string[]
+  draftId?: string
+  revision?: number
+  // free-text "Other" answers by field ID
+  other?: Record<string, string>
   submittedAt?: string
 }
 
@@ -41,9 +145,82 @@ export interface FieldStats {
   fieldLabel: string
   fieldType: FieldType
   responseCount: number
+  skipCount?: number
+  hiddenCount?: number
   averageRating?: number
   optionCounts?: Record<string, number>
   textResponses?: string[]
+  otherCount?: number
+  otherResponses?: string[]
+  dateSummary?: DateSummary
+  // files uploaded to a file field
+  fileCount?: number
+  // matrix: times each column was picked, by row
+  rowCounts?: Record<string, Record<string, number>>
+  nps?: NPSSummary
+  // ranking: options by Borda count, highest first
+  ranking?: RankStats[]
+  // yes/no: answers of each kind and the share of yes
+  boolean?: BooleanSummary
+  // slider: answers per bin of its range
+  histogram?: HistogramBin[]
+}
+
+export interface BooleanSummary {
+  true: number
+  false: number
+  trueRatio: number
+}
+
+// Answers from `from` to `to`, both included
+export interface HistogramBin {
+  from: number
+  to: number
+  count: number
+}
+
+// How one option of a ranking field was ranked. averageRank (1 is first)
+// covers the answers that ranked it; borda gives n-1 points for first
+// place down to 0 for last or unranked.
+export interface RankStats {
+  option: string
+  averageRank?: number
+  borda: number
+  firstChoices: number
+  firstChoiceShare: number
+}
+
+// NPS field answers: promoters scored 9-10, passives 7-8 and detractors
+// 0-6. score runs from -100 to 100, with low and high bounding its 95%
+// confidence interval.
+export interface NPSSummary {
+  promoters: number
+  passives: number
+  detractors: number
+  score: number
+  low: number
+  high: number
+  distribution: Record<string, number>
+  trend?: { date: string; score: number; responses: number }[]
+}
+
+// Earliest and latest answers of a date, time or datetime field, with
+// counts per day, ISO week and month for dates and datetimes
+export interface DateSummary {
+  earliest: string
+  latest: string
+  byDay?: Record<string, number>
+  byWeek?: Record<string, number>
+  byMonth?: Record<string, number>
+}
+
+export interface PageStats {
+  pageId: string
+  title: string
+  reached: number
+  completed: number
+  dropOff: number
+  dropOffRate: number
 }
 
 export interface Analytics {
@@ -51,18 +228,72 @@ export interface Analytics {
   totalResponses: number
   fieldAnalytics: Record<string, FieldStats>
   lastUpdated: string
+  pageDropOff?: PageStats[]
+  completion?: CompletionStats
+}
+
+export interface CompletionStats {
+  started: number
+  completed: number
+  completionRate: number
+}
+
+export interface FormRevision {
+  formId: string
+  revision: number
+  title: string
+  description: string
+  fields: Field[]
+  pages?: Page[]
+  createdBy?: string
+  createdAt: string
+}
+
+export interface ValueChange {
+  from: any
+  to: any
+}
+
+export interface FieldChange {
+  fieldId: string
+  label: string
+  changes: Record<string, ValueChange>
+}
+
+export interface RevisionDiff {
+  from: number
+  to: number
+  title?: ValueChange
+  description?: ValueChange
+  pages?: ValueChange
+  added: Field[]
+  removed: Field[]
+  changed: FieldChange[]
+}
+
+export interface Draft {
+  id: string
+  formId: string
+  responses: Record<string, any>
+  pageId?: string
+  createdAt: string
+  updatedAt: string
+  expiresAt: string
 }
 
 export interface CreateFormRequest {
   title: string
   description: string
   fields: Field[]
+  pages?: Page[]
+  status?: FormStatus
 }
 
 export interface UpdateFormRequest {
   title: string
   description: string
   fields: Field[]
+  pages?: Page[]
 }
 
 export interface SubmitResponseRequest {